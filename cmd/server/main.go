@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 	"payslip-generation-system/internal/handler"
+	"payslip-generation-system/internal/mailer"
 	"payslip-generation-system/internal/middleware"
 	"payslip-generation-system/internal/repository"
 	"payslip-generation-system/internal/service"
@@ -35,27 +37,125 @@ func main() {
 	// admin route
 	adminRepo := repository.NewAdminRepository(db)
 	payrollRepo := repository.NewPayrollRepository(db)
-	payrollService := service.NewPayrollService(payrollRepo)
+	unitOfWork := repository.NewUnitOfWork(db)
+	payrollService := service.NewPayrollService(payrollRepo, unitOfWork)
 	adminHandler := handler.NewAdminHandler(adminRepo, payrollService)
 
 	adminMux := http.NewServeMux()
 	adminMux.Handle("/attendance-period", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.CreateAttendancePeriodHandler())))
+	adminMux.Handle("/attendance-periods", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.GetAttendancePeriodsHandler())))
+	adminMux.Handle("/attendance-period-detail", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.GetAttendancePeriodHandler())))
+	adminMux.Handle("/attendance-period-update", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.UpdateAttendancePeriodHandler())))
+	adminMux.Handle("/attendance-period-delete", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.DeleteAttendancePeriodHandler())))
+	adminMux.Handle("/attendances", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.GetAttendancesHandler())))
+	adminMux.Handle("/attendance-period-unlock", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.UnlockAttendancePeriodHandler())))
 	adminMux.Handle("/payroll-run", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.RunPayroll())))
+	adminMux.Handle("/payroll-preview", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.PreviewPayrollHandler())))
+	adminMux.Handle("/payroll-transition", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.TransitionPayrollHandler())))
+	adminMux.Handle("/payroll-reverse", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.ReversePayrollHandler())))
 	adminMux.Handle("/payslip-summary", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.GetPayslipSummaryHandler())))
+	adminMux.Handle("/employee-tax-profile", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.UpdateTaxProfileHandler())))
+	adminMux.Handle("/employee-bpjs-profile", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.UpdateBPJSProfileHandler())))
+	adminMux.Handle("/employee-personal-profile", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.UpdatePersonalProfileHandler())))
+	adminMux.Handle("/bpjs-rate", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.CreateBPJSRateHandler())))
+	adminMux.Handle("/bpjs-rates", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.GetBPJSRatesHandler())))
+	adminMux.Handle("/salary", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.CreateSalaryChangeHandler())))
+	adminMux.Handle("/salary-history", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.GetSalaryHistoryHandler())))
+	adminMux.Handle("/company-settings", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.GetCompanySettingsHandler())))
+	adminMux.Handle("/company-settings-update", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.UpdateCompanySettingsHandler())))
+	adminMux.Handle("/overtime-rules", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.GetOvertimeRulesHandler())))
+	adminMux.Handle("/overtime-rules-update", middleware.AuthMiddleware(http.HandlerFunc(adminHandler.UpdateOvertimeRulesHandler())))
+
+	holidayRepo := repository.NewHolidayRepository(db)
+	holidayHandler := handler.NewHolidayHandler(holidayRepo)
+	adminMux.Handle("/holiday", middleware.AuthMiddleware(http.HandlerFunc(holidayHandler.CreateHolidayHandler())))
+	adminMux.Handle("/holidays", middleware.AuthMiddleware(http.HandlerFunc(holidayHandler.GetHolidaysHandler())))
+	adminMux.Handle("/holiday-update", middleware.AuthMiddleware(http.HandlerFunc(holidayHandler.UpdateHolidayHandler())))
+	adminMux.Handle("/holiday-delete", middleware.AuthMiddleware(http.HandlerFunc(holidayHandler.DeleteHolidayHandler())))
+	adminMux.Handle("/holiday-import", middleware.AuthMiddleware(http.HandlerFunc(holidayHandler.ImportHolidaysHandler())))
+	adminMux.Handle("/holiday-export", middleware.AuthMiddleware(http.HandlerFunc(holidayHandler.ExportHolidaysHandler())))
+
+	shiftRepo := repository.NewShiftRepository(db)
+	shiftHandler := handler.NewShiftHandler(shiftRepo)
+	adminMux.Handle("/shift", middleware.AuthMiddleware(http.HandlerFunc(shiftHandler.CreateShiftHandler())))
+	adminMux.Handle("/shifts", middleware.AuthMiddleware(http.HandlerFunc(shiftHandler.GetShiftsHandler())))
+	adminMux.Handle("/shift-update", middleware.AuthMiddleware(http.HandlerFunc(shiftHandler.UpdateShiftHandler())))
+	adminMux.Handle("/shift-delete", middleware.AuthMiddleware(http.HandlerFunc(shiftHandler.DeleteShiftHandler())))
+	adminMux.Handle("/roster", middleware.AuthMiddleware(http.HandlerFunc(shiftHandler.AssignRosterHandler())))
+	adminMux.Handle("/rosters", middleware.AuthMiddleware(http.HandlerFunc(shiftHandler.GetRostersHandler())))
+	adminMux.Handle("/roster-delete", middleware.AuthMiddleware(http.HandlerFunc(shiftHandler.DeleteRosterHandler())))
+
+	leaveRepo := repository.NewLeaveRepository(db)
+	leaveService := service.NewLeaveService(leaveRepo, unitOfWork)
+	leaveHandler := handler.NewLeaveHandler(leaveRepo, leaveService)
+	adminMux.Handle("/leave-types", middleware.AuthMiddleware(http.HandlerFunc(leaveHandler.GetLeaveTypesHandler())))
+	adminMux.Handle("/leave-accrual", middleware.AuthMiddleware(http.HandlerFunc(leaveHandler.AccrueLeaveHandler())))
+	adminMux.Handle("/leave-approvals", middleware.AuthMiddleware(http.HandlerFunc(leaveHandler.GetPendingApprovalsHandler())))
+	adminMux.Handle("/leave-review", middleware.AuthMiddleware(http.HandlerFunc(leaveHandler.ReviewLeaveHandler())))
+	adminMux.Handle("/employee-manager", middleware.AuthMiddleware(http.HandlerFunc(leaveHandler.UpdateEmployeeManagerHandler())))
+
+	overtimeRepo := repository.NewOvertimeRepository(db)
+	overtimeService := service.NewOvertimeService(unitOfWork)
+	overtimeHandler := handler.NewOvertimeHandler(overtimeRepo, overtimeService)
+	adminMux.Handle("/overtime-approvals", middleware.AuthMiddleware(http.HandlerFunc(overtimeHandler.GetPendingOvertimesHandler())))
+	adminMux.Handle("/overtime-review", middleware.AuthMiddleware(http.HandlerFunc(overtimeHandler.ReviewOvertimeHandler())))
+
+	reimbursementRepo := repository.NewReimbursementRepository(db)
+	reimbursementService := service.NewReimbursementService(unitOfWork)
+	reimbursementHandler := handler.NewReimbursementHandler(reimbursementRepo, reimbursementService)
+	adminMux.Handle("/reimbursement-categories", middleware.AuthMiddleware(http.HandlerFunc(reimbursementHandler.GetCategoriesHandler())))
+	adminMux.Handle("/reimbursement-limit", middleware.AuthMiddleware(http.HandlerFunc(reimbursementHandler.UpdateLimitHandler())))
+	adminMux.Handle("/employee-grade", middleware.AuthMiddleware(http.HandlerFunc(reimbursementHandler.UpdateEmployeeGradeHandler())))
+	adminMux.Handle("/reimbursement-approvals", middleware.AuthMiddleware(http.HandlerFunc(reimbursementHandler.GetPendingReimbursementsHandler())))
+	adminMux.Handle("/reimbursement-review", middleware.AuthMiddleware(http.HandlerFunc(reimbursementHandler.ReviewReimbursementHandler())))
+	adminMux.Handle("/reimbursement-attachment", middleware.AuthMiddleware(http.HandlerFunc(reimbursementHandler.GetAttachmentHandler())))
+
+	payslipRepo := repository.NewPayslipRepository(db)
+	payslipService := service.NewPayslipService(payslipRepo)
+	deliveryRepo := repository.NewPayslipDeliveryRepository(db)
+	payslipHandler := handler.NewPayslipHandler(payslipRepo, deliveryRepo, payslipService)
+	adminMux.Handle("/payslip-pdfs", middleware.AuthMiddleware(http.HandlerFunc(payslipHandler.DownloadPayslipsHandler())))
+	adminMux.Handle("/payslip-deliveries", middleware.AuthMiddleware(http.HandlerFunc(payslipHandler.GetPayslipDeliveriesHandler())))
+	adminMux.Handle("/payslip-template", middleware.AuthMiddleware(http.HandlerFunc(payslipHandler.GetPayslipTemplateHandler())))
+	adminMux.Handle("/payslip-template-update", middleware.AuthMiddleware(http.HandlerFunc(payslipHandler.UpdatePayslipTemplateHandler())))
 	http.Handle("/admin/", http.StripPrefix("/admin", adminMux))
 
 	// employee route
 	employeeRepo := repository.NewEmployeeRepository(db)
 
-	employeeHandler := handler.NewEmployeeHandler(employeeRepo)
+	employeeHandler := handler.NewEmployeeHandler(employeeRepo, payslipService)
 
 	employeeMux := http.NewServeMux()
 	employeeMux.Handle("/attendance", middleware.AuthMiddleware(http.HandlerFunc(employeeHandler.SubmitAttendanceHanlder())))
+	employeeMux.Handle("/attendance-checkout", middleware.AuthMiddleware(http.HandlerFunc(employeeHandler.CheckOutAttendanceHandler())))
+	employeeMux.Handle("/attendances", middleware.AuthMiddleware(http.HandlerFunc(employeeHandler.GetAttendancesHandler())))
+	employeeMux.Handle("/rosters", middleware.AuthMiddleware(http.HandlerFunc(shiftHandler.GetRostersHandler())))
 	employeeMux.Handle("/overtime", middleware.AuthMiddleware(http.HandlerFunc(employeeHandler.SubmitOvertimeHandler())))
+	employeeMux.Handle("/overtimes", middleware.AuthMiddleware(http.HandlerFunc(overtimeHandler.GetOvertimesHandler())))
+	employeeMux.Handle("/overtime-withdraw", middleware.AuthMiddleware(http.HandlerFunc(overtimeHandler.WithdrawOvertimeHandler())))
+	employeeMux.Handle("/overtime-approvals", middleware.AuthMiddleware(http.HandlerFunc(overtimeHandler.GetPendingOvertimesHandler())))
+	employeeMux.Handle("/overtime-review", middleware.AuthMiddleware(http.HandlerFunc(overtimeHandler.ReviewOvertimeHandler())))
 	employeeMux.Handle("/reimbursement", middleware.AuthMiddleware(http.HandlerFunc(employeeHandler.SubmitReimbursementHandler())))
+	employeeMux.Handle("/reimbursements", middleware.AuthMiddleware(http.HandlerFunc(reimbursementHandler.GetReimbursementsHandler())))
+	employeeMux.Handle("/reimbursement-approvals", middleware.AuthMiddleware(http.HandlerFunc(reimbursementHandler.GetPendingReimbursementsHandler())))
+	employeeMux.Handle("/reimbursement-review", middleware.AuthMiddleware(http.HandlerFunc(reimbursementHandler.ReviewReimbursementHandler())))
+	employeeMux.Handle("/reimbursement-attachment", middleware.AuthMiddleware(http.HandlerFunc(reimbursementHandler.GetAttachmentHandler())))
 	employeeMux.Handle("/payslip", middleware.AuthMiddleware(http.HandlerFunc(employeeHandler.GetPayslipHandler())))
+	employeeMux.Handle("/payslips", middleware.AuthMiddleware(http.HandlerFunc(employeeHandler.GetPayslipsHandler())))
+	employeeMux.Handle("/payslip-pdf", middleware.AuthMiddleware(http.HandlerFunc(payslipHandler.GetPayslipPDFHandler())))
+	employeeMux.Handle("/payslip-pin", middleware.AuthMiddleware(http.HandlerFunc(employeeHandler.UpdatePayslipPINHandler())))
+	employeeMux.Handle("/leave-request", middleware.AuthMiddleware(http.HandlerFunc(leaveHandler.RequestLeaveHandler())))
+	employeeMux.Handle("/leave-requests", middleware.AuthMiddleware(http.HandlerFunc(leaveHandler.GetLeaveRequestsHandler())))
+	employeeMux.Handle("/leave-balances", middleware.AuthMiddleware(http.HandlerFunc(leaveHandler.GetLeaveBalancesHandler())))
+	employeeMux.Handle("/leave-cancel", middleware.AuthMiddleware(http.HandlerFunc(leaveHandler.CancelLeaveHandler())))
+	employeeMux.Handle("/leave-approvals", middleware.AuthMiddleware(http.HandlerFunc(leaveHandler.GetPendingApprovalsHandler())))
+	employeeMux.Handle("/leave-review", middleware.AuthMiddleware(http.HandlerFunc(leaveHandler.ReviewLeaveHandler())))
 	http.Handle("/employee/", http.StripPrefix("/employee", employeeMux))
 
+	// email the payslips of published payrolls in the background
+	dispatcher := service.NewPayslipDispatcher(deliveryRepo, payslipService, mailer.SMTPMailerFromEnv())
+	go dispatcher.Run(context.Background())
+
 	log.Println("Server running on :8081")
 	http.ListenAndServe(":8081", nil)
 }
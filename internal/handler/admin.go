@@ -2,16 +2,20 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/mail"
 	"payslip-generation-system/internal/helper"
 	"payslip-generation-system/internal/middleware"
 	"payslip-generation-system/internal/model"
 	"payslip-generation-system/internal/repository"
 	"payslip-generation-system/internal/service"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type AttendancePeriodRequest struct {
@@ -23,19 +27,151 @@ type PayrollRequest struct {
 	PeriodID string `json:"attendancePeriodId"`
 }
 
+type ReversePayrollRequest struct {
+	PayrollID string `json:"payrollID"`
+	Reason    string `json:"reason"`
+}
+
+type PayrollTransitionRequest struct {
+	PayrollID string `json:"payrollID"`
+	Status    string `json:"status"`
+}
+
+type UnlockPeriodRequest struct {
+	PeriodID string `json:"attendancePeriodId"`
+	Reason   string `json:"reason"`
+}
+
 type SummaryRequest struct {
 	PayrollID string `json:"payrollID"`
 }
 
 type SummaryResponse struct {
 	EmployeeSummaries []EmployeeSummary `json:"employee_summaries"`
-	TotalTakeHome     int               `json:"total_take_home"`
+	TotalGross        int               `json:"total_gross"`
+	TotalTax          int               `json:"total_tax"`
+	TotalNet          int               `json:"total_net"`
+	TotalEmployerCost int               `json:"total_employer_cost"`
 }
 
 type EmployeeSummary struct {
-	UserID      uuid.UUID `json:"user_id"`
-	Username    string    `json:"username"`
-	TakeHomePay int       `json:"take_home_pay"`
+	UserID       uuid.UUID `json:"user_id"`
+	Username     string    `json:"username"`
+	GrossPay     int       `json:"gross_pay"`
+	TaxAmount    int       `json:"tax_amount"`
+	NetPay       int       `json:"net_pay"`
+	EmployerCost int       `json:"employer_cost"`
+}
+
+type TaxProfileRequest struct {
+	UserID     string `json:"userID"`
+	PTKPStatus string `json:"ptkpStatus"`
+	NPWP       string `json:"npwp"`
+}
+
+type BPJSProfileRequest struct {
+	UserID       string `json:"userID"`
+	JKKRiskClass int    `json:"jkkRiskClass"`
+}
+
+type PersonalProfileRequest struct {
+	UserID         string `json:"userID"`
+	BirthDate      string `json:"birthDate"`
+	EmployeeNumber string `json:"employeeNumber"`
+	Email          string `json:"email"`
+}
+
+type BPJSRateRequest struct {
+	Program       string  `json:"program"`
+	RiskClass     *int    `json:"riskClass"`
+	EmployerRate  float64 `json:"employerRate"`
+	EmployeeRate  float64 `json:"employeeRate"`
+	SalaryCap     *int    `json:"salaryCap"`
+	EffectiveFrom string  `json:"effectiveFrom"`
+}
+
+type BPJSRateResponse struct {
+	ID            uuid.UUID `json:"id"`
+	Program       string    `json:"program"`
+	RiskClass     *int      `json:"riskClass"`
+	EmployerRate  float64   `json:"employerRate"`
+	EmployeeRate  float64   `json:"employeeRate"`
+	SalaryCap     *int      `json:"salaryCap"`
+	EffectiveFrom string    `json:"effectiveFrom"`
+}
+
+type SalaryChangeRequest struct {
+	UserID        string `json:"userID"`
+	Salary        int    `json:"salary"`
+	EffectiveFrom string `json:"effectiveFrom"`
+	Reason        string `json:"reason"`
+}
+
+type SalaryHistoryResponse struct {
+	ID            uuid.UUID `json:"id"`
+	Salary        int       `json:"salary"`
+	EffectiveFrom string    `json:"effectiveFrom"`
+	Reason        string    `json:"reason"`
+	CreatedBy     uuid.UUID `json:"createdBy"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type CompanySettingsRequest struct {
+	HoursPerDay             float64 `json:"hoursPerDay"`
+	ProrationBasis          string  `json:"prorationBasis"`
+	FixedDivisor            float64 `json:"fixedDivisor"`
+	OvertimeDivisor         float64 `json:"overtimeDivisor"`
+	MaxOvertimeHoursPerDay  float64 `json:"maxOvertimeHoursPerDay"`
+	MaxOvertimeHoursPerWeek float64 `json:"maxOvertimeHoursPerWeek"`
+	LatenessDeduction       bool    `json:"latenessDeduction"`
+	LatenessGraceMinutes    int     `json:"latenessGraceMinutes"`
+	CompanyName             string  `json:"companyName"`
+	CompanyAddress          string  `json:"companyAddress"`
+	CompanyNPWP             string  `json:"companyNPWP"`
+	PayslipPasswordScheme   string  `json:"payslipPasswordScheme"`
+}
+
+type CompanySettingsResponse struct {
+	HoursPerDay             float64    `json:"hoursPerDay"`
+	ProrationBasis          string     `json:"prorationBasis"`
+	FixedDivisor            float64    `json:"fixedDivisor"`
+	OvertimeDivisor         float64    `json:"overtimeDivisor"`
+	MaxOvertimeHoursPerDay  float64    `json:"maxOvertimeHoursPerDay"`
+	MaxOvertimeHoursPerWeek float64    `json:"maxOvertimeHoursPerWeek"`
+	LatenessDeduction       bool       `json:"latenessDeduction"`
+	LatenessGraceMinutes    int        `json:"latenessGraceMinutes"`
+	CompanyName             string     `json:"companyName"`
+	CompanyAddress          string     `json:"companyAddress"`
+	CompanyNPWP             string     `json:"companyNPWP"`
+	PayslipPasswordScheme   string     `json:"payslipPasswordScheme"`
+	UpdatedBy               *uuid.UUID `json:"updatedBy"`
+	UpdatedAt               time.Time  `json:"updatedAt"`
+}
+
+type PayrollPreviewResponse struct {
+	PeriodID         uuid.UUID                `json:"periodId"`
+	ProrationBasis   string                   `json:"prorationBasis"`
+	WorkingDays      int                      `json:"workingDays"`
+	ProrationDivisor float64                  `json:"prorationDivisor"`
+	HoursPerDay      float64                  `json:"hoursPerDay"`
+	Employees        []PayslipPreviewResponse `json:"employees"`
+	Totals           PayrollTotalsResponse    `json:"totals"`
+}
+
+type PayslipPreviewResponse struct {
+	UserID   uuid.UUID `json:"userId"`
+	Username string    `json:"username"`
+	PayslipResponse
+	Warnings []string `json:"warnings"`
+}
+
+type PayrollTotalsResponse struct {
+	Employees       int `json:"employees"`
+	GrossPay        int `json:"grossPay"`
+	TaxAmount       int `json:"taxAmount"`
+	TotalDeductions int `json:"totalDeductions"`
+	NetPay          int `json:"netPay"`
+	EmployerCost    int `json:"employerCost"`
 }
 
 type AdminHandler struct {
@@ -67,7 +203,7 @@ func (adh *AdminHandler) CreateAttendancePeriodHandler() http.HandlerFunc {
 
 		startDate, err1 := time.Parse("2006-01-02", req.StartDate)
 		endDate, err2 := time.Parse("2006-01-02", req.EndDate)
-		if err1 != nil || err2 != nil || !startDate.Before(endDate) {
+		if err1 != nil || err2 != nil || endDate.Before(startDate) {
 			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid date range", nil, nil))
 			return
 		}
@@ -89,11 +225,21 @@ func (adh *AdminHandler) CreateAttendancePeriodHandler() http.HandlerFunc {
 
 		saveErr := adh.AdminRepo.SaveAttendancePeriod(&period)
 		if saveErr != nil {
-			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to create period", nil, nil))
+			if isPeriodOverlapError(saveErr) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "period overlaps another attendance period", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to create period", nil, nil))
+			}
+			return
+		}
+
+		resp, err := adh.toAttendancePeriodResponse(&period)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to check adjacent periods", nil, nil))
 			return
 		}
 
-		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "attendance period created successfully", nil, nil))
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "attendance period created successfully", resp, nil))
 	}
 }
 
@@ -128,7 +274,14 @@ func (adh *AdminHandler) RunPayroll() http.HandlerFunc {
 			middleware.GetRequestID(r),
 		)
 		if err != nil {
-			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, err.Error(), nil, nil))
+			switch {
+			case errors.Is(err, service.ErrPeriodNotFound):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, err.Error(), nil, nil))
+			case errors.Is(err, service.ErrPayrollAlreadyProcessed):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, err.Error(), nil, nil))
+			default:
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, err.Error(), nil, nil))
+			}
 			return
 		}
 
@@ -136,6 +289,164 @@ func (adh *AdminHandler) RunPayroll() http.HandlerFunc {
 	}
 }
 
+func (adh *AdminHandler) ReversePayrollHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req ReversePayrollRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid JSON", nil, nil))
+			return
+		}
+
+		payrollID, err := uuid.Parse(req.PayrollID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid payroll ID", nil, nil))
+			return
+		}
+
+		// the reason is kept with the voided payroll and its audit log
+		reason := strings.TrimSpace(req.Reason)
+		if reason == "" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "reason is required", nil, nil))
+			return
+		}
+
+		err = adh.PayrollService.ReversePayroll(
+			payrollID,
+			uuid.MustParse(middleware.GetUserID(r)),
+			reason,
+			r.RemoteAddr,
+			middleware.GetRequestID(r),
+		)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrPayrollNotFound):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, err.Error(), nil, nil))
+			case errors.Is(err, service.ErrPayrollAlreadyVoided):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, err.Error(), nil, nil))
+			default:
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, err.Error(), nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "payroll reversed", nil, nil))
+	}
+}
+
+func (adh *AdminHandler) TransitionPayrollHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req PayrollTransitionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid JSON", nil, nil))
+			return
+		}
+
+		payrollID, err := uuid.Parse(req.PayrollID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid payroll ID", nil, nil))
+			return
+		}
+
+		err = adh.PayrollService.TransitionPayroll(
+			payrollID,
+			uuid.MustParse(middleware.GetUserID(r)),
+			req.Status,
+			r.RemoteAddr,
+			middleware.GetRequestID(r),
+		)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidPayrollStatus):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
+			case errors.Is(err, service.ErrPayrollNotFound):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, err.Error(), nil, nil))
+			case errors.Is(err, service.ErrPayrollSelfApproval):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, err.Error(), nil, nil))
+			case errors.Is(err, service.ErrInvalidPayrollTransition), errors.Is(err, service.ErrPayrollAlreadyVoided):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, err.Error(), nil, nil))
+			default:
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, err.Error(), nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "payroll status updated", nil, nil))
+	}
+}
+
+func (adh *AdminHandler) UnlockAttendancePeriodHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req UnlockPeriodRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid JSON", nil, nil))
+			return
+		}
+
+		periodID, err := uuid.Parse(req.PeriodID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid period ID", nil, nil))
+			return
+		}
+
+		reason := strings.TrimSpace(req.Reason)
+		if reason == "" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "reason is required", nil, nil))
+			return
+		}
+
+		err = adh.PayrollService.UnlockPeriod(
+			periodID,
+			uuid.MustParse(middleware.GetUserID(r)),
+			reason,
+			r.RemoteAddr,
+			middleware.GetRequestID(r),
+		)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrPeriodNotFound):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, err.Error(), nil, nil))
+			case errors.Is(err, service.ErrPeriodNotLocked):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, err.Error(), nil, nil))
+			default:
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, err.Error(), nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "attendance period unlocked", nil, nil))
+	}
+}
+
 func (adh *AdminHandler) GetPayslipSummaryHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -162,22 +473,589 @@ func (adh *AdminHandler) GetPayslipSummaryHandler() http.HandlerFunc {
 		}
 		fmt.Printf("%v\n", rows)
 
-		summaries := []EmployeeSummary{}
-		total := 0
+		resp := SummaryResponse{EmployeeSummaries: []EmployeeSummary{}}
 		for _, row := range rows {
-			summaries = append(summaries, EmployeeSummary{
-				UserID:      row.UserID,
-				Username:    row.Username,
-				TakeHomePay: row.TakeHomePay,
+			resp.EmployeeSummaries = append(resp.EmployeeSummaries, EmployeeSummary{
+				UserID:       row.UserID,
+				Username:     row.Username,
+				GrossPay:     row.GrossPay,
+				TaxAmount:    row.TaxAmount,
+				NetPay:       row.NetPay,
+				EmployerCost: row.EmployerCost,
 			})
-			total += row.TakeHomePay
+			resp.TotalGross += row.GrossPay
+			resp.TotalTax += row.TaxAmount
+			resp.TotalNet += row.NetPay
+			resp.TotalEmployerCost += row.EmployerCost
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get payslip summary", resp, nil))
+	}
+}
+
+func (adh *AdminHandler) UpdateTaxProfileHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
 		}
 
-		resp := SummaryResponse{
-			EmployeeSummaries: summaries,
-			TotalTakeHome:     total,
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
 		}
 
-		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get payslip summary", resp, nil))
+		var req TaxProfileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid user ID", nil, nil))
+			return
+		}
+
+		if !service.IsValidPTKPStatus(req.PTKPStatus) {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid PTKP status", nil, nil))
+			return
+		}
+
+		if err := adh.AdminRepo.UpdateTaxProfile(userID, req.PTKPStatus, req.NPWP); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "employee not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update tax profile", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "tax profile updated successfully", nil, nil))
+	}
+}
+
+func (adh *AdminHandler) UpdateBPJSProfileHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req BPJSProfileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid user ID", nil, nil))
+			return
+		}
+
+		if req.JKKRiskClass < 1 || req.JKKRiskClass > 5 {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "JKK risk class must be between 1 and 5", nil, nil))
+			return
+		}
+
+		if err := adh.AdminRepo.UpdateJKKRiskClass(userID, req.JKKRiskClass); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "employee not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update BPJS profile", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "BPJS profile updated successfully", nil, nil))
+	}
+}
+
+// UpdatePersonalProfileHandler sets the birth date and employee number of an employee, the
+// default payslip password is derived from them, and the email the payslips are sent to
+// when one is given
+func (adh *AdminHandler) UpdatePersonalProfileHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req PersonalProfileRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid user ID", nil, nil))
+			return
+		}
+
+		birthDate, err := time.Parse("2006-01-02", req.BirthDate)
+		if err != nil || birthDate.After(time.Now()) {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid birth date", nil, nil))
+			return
+		}
+
+		employeeNumber := strings.TrimSpace(req.EmployeeNumber)
+		if employeeNumber == "" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "employee number is required", nil, nil))
+			return
+		}
+
+		email := strings.TrimSpace(req.Email)
+		if email != "" {
+			address, err := mail.ParseAddress(email)
+			if err != nil || address.Name != "" {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid email", nil, nil))
+				return
+			}
+		}
+
+		if err := adh.AdminRepo.UpdatePersonalProfile(userID, birthDate, employeeNumber, email); err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "employee not found", nil, nil))
+			case strings.Contains(err.Error(), "duplicate key"):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "employee number is already in use", nil, nil))
+			default:
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update personal profile", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "personal profile updated successfully", nil, nil))
+	}
+}
+
+func (adh *AdminHandler) CreateBPJSRateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req BPJSRateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid effective date", nil, nil))
+			return
+		}
+
+		switch req.Program {
+		case model.BPJSKesehatan, model.BPJSJHT, model.BPJSJP, model.BPJSJKM:
+			req.RiskClass = nil
+		case model.BPJSJKK:
+			if req.RiskClass == nil || *req.RiskClass < 1 || *req.RiskClass > 5 {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "JKK rate requires a risk class between 1 and 5", nil, nil))
+				return
+			}
+		default:
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid BPJS program", nil, nil))
+			return
+		}
+
+		if req.EmployerRate < 0 || req.EmployeeRate < 0 || (req.SalaryCap != nil && *req.SalaryCap <= 0) {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid rate or salary cap", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		rate := model.BPJSRate{
+			Program:       req.Program,
+			RiskClass:     req.RiskClass,
+			EmployerRate:  req.EmployerRate,
+			EmployeeRate:  req.EmployeeRate,
+			SalaryCap:     req.SalaryCap,
+			EffectiveFrom: effectiveFrom,
+			CreatedBy:     userID,
+			RequestIP:     r.RemoteAddr,
+			CreatedAt:     time.Now(),
+		}
+
+		if err := adh.AdminRepo.SaveBPJSRate(&rate); err != nil {
+			if strings.Contains(err.Error(), "duplicate key") {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "rate already exists for this date", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to create BPJS rate", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "BPJS rate created successfully", nil, nil))
+	}
+}
+
+func (adh *AdminHandler) GetBPJSRatesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		rates, err := adh.AdminRepo.GetBPJSRates()
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get BPJS rates", nil, nil))
+			return
+		}
+
+		resp := []BPJSRateResponse{}
+		for _, rate := range rates {
+			resp = append(resp, BPJSRateResponse{
+				ID:            rate.ID,
+				Program:       rate.Program,
+				RiskClass:     rate.RiskClass,
+				EmployerRate:  rate.EmployerRate,
+				EmployeeRate:  rate.EmployeeRate,
+				SalaryCap:     rate.SalaryCap,
+				EffectiveFrom: rate.EffectiveFrom.Format("2006-01-02"),
+			})
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get BPJS rates", resp, nil))
+	}
+}
+
+func (adh *AdminHandler) CreateSalaryChangeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req SalaryChangeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		employeeID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid user ID", nil, nil))
+			return
+		}
+
+		effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+		if err != nil || req.Salary <= 0 {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid salary or effective date", nil, nil))
+			return
+		}
+
+		isEmployee, err := adh.AdminRepo.IsEmployee(employeeID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to find employee", nil, nil))
+			return
+		}
+		if !isEmployee {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "employee not found", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		salary := model.SalaryHistory{
+			UserID:        employeeID,
+			Salary:        req.Salary,
+			EffectiveFrom: effectiveFrom,
+			Reason:        req.Reason,
+			CreatedBy:     userID,
+			RequestIP:     r.RemoteAddr,
+			CreatedAt:     time.Now(),
+		}
+
+		if err := adh.AdminRepo.SaveSalaryChange(&salary); err != nil {
+			if errors.Is(err, repository.ErrSalaryAlreadyPaid) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, err.Error(), nil, nil))
+			} else if strings.Contains(err.Error(), "duplicate key") {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "salary change already exists for this date", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to create salary change", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "salary change created successfully", nil, nil))
+	}
+}
+
+func (adh *AdminHandler) GetSalaryHistoryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		employeeID, err := uuid.Parse(r.URL.Query().Get("userID"))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid user ID", nil, nil))
+			return
+		}
+
+		history, err := adh.AdminRepo.GetSalaryHistory(employeeID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get salary history", nil, nil))
+			return
+		}
+
+		resp := []SalaryHistoryResponse{}
+		for _, h := range history {
+			resp = append(resp, SalaryHistoryResponse{
+				ID:            h.ID,
+				Salary:        h.Salary,
+				EffectiveFrom: h.EffectiveFrom.Format("2006-01-02"),
+				Reason:        h.Reason,
+				CreatedBy:     h.CreatedBy,
+				CreatedAt:     h.CreatedAt,
+			})
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get salary history", resp, nil))
+	}
+}
+
+func (adh *AdminHandler) GetCompanySettingsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		settings, err := adh.AdminRepo.GetCompanySettings()
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get company settings", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get company settings", toCompanySettingsResponse(settings), nil))
+	}
+}
+
+func (adh *AdminHandler) UpdateCompanySettingsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req CompanySettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		switch req.ProrationBasis {
+		case model.ProrationWorkingDays, model.ProrationCalendarDays, model.ProrationFixed:
+		default:
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid proration basis", nil, nil))
+			return
+		}
+
+		if req.HoursPerDay <= 0 || req.HoursPerDay > 24 || (req.ProrationBasis == model.ProrationFixed && req.FixedDivisor <= 0) {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid hours per day or fixed divisor", nil, nil))
+			return
+		}
+
+		if req.LatenessGraceMinutes < 0 {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid lateness grace minutes", nil, nil))
+			return
+		}
+
+		if req.PayslipPasswordScheme != "" && !service.IsValidPayslipPasswordScheme(req.PayslipPasswordScheme) {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid payslip password scheme", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		settings, err := adh.AdminRepo.GetCompanySettings()
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get company settings", nil, nil))
+			return
+		}
+
+		settings.HoursPerDay = req.HoursPerDay
+		settings.ProrationBasis = req.ProrationBasis
+		if req.FixedDivisor > 0 {
+			settings.FixedDivisor = req.FixedDivisor
+		}
+		if req.OvertimeDivisor > 0 {
+			settings.OvertimeDivisor = req.OvertimeDivisor
+		}
+		if req.MaxOvertimeHoursPerDay > 0 {
+			settings.MaxOvertimeHoursPerDay = req.MaxOvertimeHoursPerDay
+		}
+		if req.MaxOvertimeHoursPerWeek > 0 {
+			settings.MaxOvertimeHoursPerWeek = req.MaxOvertimeHoursPerWeek
+		}
+		settings.LatenessDeduction = req.LatenessDeduction
+		settings.LatenessGraceMinutes = req.LatenessGraceMinutes
+		if name := strings.TrimSpace(req.CompanyName); name != "" {
+			settings.CompanyName = name
+		}
+		if address := strings.TrimSpace(req.CompanyAddress); address != "" {
+			settings.CompanyAddress = address
+		}
+		if npwp := strings.TrimSpace(req.CompanyNPWP); npwp != "" {
+			settings.CompanyNPWP = npwp
+		}
+		if req.PayslipPasswordScheme != "" {
+			settings.PayslipPasswordScheme = req.PayslipPasswordScheme
+		}
+		settings.UpdatedBy = &userID
+		settings.UpdatedAt = time.Now()
+
+		if err := adh.AdminRepo.UpdateCompanySettings(settings); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update company settings", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "company settings updated successfully", toCompanySettingsResponse(settings), nil))
+	}
+}
+
+func toCompanySettingsResponse(settings *model.CompanySettings) CompanySettingsResponse {
+	return CompanySettingsResponse{
+		HoursPerDay:             settings.HoursPerDay,
+		ProrationBasis:          settings.ProrationBasis,
+		FixedDivisor:            settings.FixedDivisor,
+		OvertimeDivisor:         settings.OvertimeDivisor,
+		MaxOvertimeHoursPerDay:  settings.MaxOvertimeHoursPerDay,
+		MaxOvertimeHoursPerWeek: settings.MaxOvertimeHoursPerWeek,
+		LatenessDeduction:       settings.LatenessDeduction,
+		LatenessGraceMinutes:    settings.LatenessGraceMinutes,
+		CompanyName:             settings.CompanyName,
+		CompanyAddress:          settings.CompanyAddress,
+		CompanyNPWP:             settings.CompanyNPWP,
+		PayslipPasswordScheme:   settings.PayslipPasswordScheme,
+		UpdatedBy:               settings.UpdatedBy,
+		UpdatedAt:               settings.UpdatedAt,
+	}
+}
+
+func (adh *AdminHandler) PreviewPayrollHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req PayrollRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid JSON", nil, nil))
+			return
+		}
+
+		periodID, err := uuid.Parse(req.PeriodID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid period ID", nil, nil))
+			return
+		}
+
+		preview, err := adh.PayrollService.PreviewPayroll(periodID)
+		if err != nil {
+			if errors.Is(err, service.ErrPeriodNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, err.Error(), nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, err.Error(), nil, nil))
+			}
+			return
+		}
+
+		resp := PayrollPreviewResponse{
+			PeriodID:         periodID,
+			ProrationBasis:   preview.Payroll.ProrationBasis,
+			WorkingDays:      preview.Payroll.WorkingDays,
+			ProrationDivisor: preview.Payroll.ProrationDivisor,
+			HoursPerDay:      preview.Payroll.HoursPerDay,
+			Employees:        []PayslipPreviewResponse{},
+			Totals: PayrollTotalsResponse{
+				Employees:       preview.Totals.Employees,
+				GrossPay:        preview.Totals.GrossPay,
+				TaxAmount:       preview.Totals.TaxAmount,
+				TotalDeductions: preview.Totals.TotalDeductions,
+				NetPay:          preview.Totals.NetPay,
+				EmployerCost:    preview.Totals.EmployerCost,
+			},
+		}
+		for _, p := range preview.Payslips {
+			warnings := preview.Warnings[p.UserID]
+			if warnings == nil {
+				warnings = []string{}
+			}
+			resp.Employees = append(resp.Employees, PayslipPreviewResponse{
+				UserID:          p.UserID,
+				Username:        preview.Usernames[p.UserID],
+				PayslipResponse: toPayslipResponse(p),
+				Warnings:        warnings,
+			})
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "payroll preview calculated", resp, nil))
 	}
 }
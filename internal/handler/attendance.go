@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"payslip-generation-system/internal/helper"
+	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"payslip-generation-system/internal/service"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AttendanceResponse struct {
+	ID                uuid.UUID  `json:"id"`
+	UserID            uuid.UUID  `json:"userId"`
+	Date              string     `json:"date"`
+	CheckInAt         *time.Time `json:"checkInAt"`
+	CheckOutAt        *time.Time `json:"checkOutAt"`
+	WorkedMinutes     int        `json:"workedMinutes"`
+	LateMinutes       int        `json:"lateMinutes"`
+	EarlyLeaveMinutes int        `json:"earlyLeaveMinutes"`
+}
+
+func (emh *EmployeeHandler) CheckOutAttendanceHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		// a shift crossing midnight is checked out of on the day after the check in
+		now := time.Now()
+		today := service.Midnight(now, now.Location())
+		attendance, err := emh.EmployeeRepo.GetOpenAttendance(userID, today.AddDate(0, 0, -1))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "no open check in", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get attendance", nil, nil))
+			}
+			return
+		}
+
+		schedule, _, err := emh.scheduleFor(userID, attendance.Date)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get roster", nil, nil))
+			return
+		}
+		if attendance.Date.Before(today) && !schedule.CrossesMidnight() {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "no open check in", nil, nil))
+			return
+		}
+
+		attendance.CheckOutAt = &now
+		if attendance.CheckInAt != nil {
+			attendance.WorkedMinutes = schedule.WorkedMinutes(*attendance.CheckInAt, now)
+		}
+		attendance.EarlyLeaveMinutes = schedule.EarlyLeaveMinutes(attendance.Date, now)
+		attendance.UpdatedAt = now
+
+		if err := emh.EmployeeRepo.UpdateAttendance(attendance); err != nil {
+			if errors.Is(err, repository.ErrPeriodLocked) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, err.Error(), nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to check out", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "checked out successfully", toAttendanceResponse(*attendance), nil))
+	}
+}
+
+func (emh *EmployeeHandler) GetAttendancesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		start, end, err := dateRange(r)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
+			return
+		}
+
+		attendances, err := emh.EmployeeRepo.GetAttendances(userID, start, end)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get attendances", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get attendances", toAttendanceResponses(attendances), nil))
+	}
+}
+
+func (adh *AdminHandler) GetAttendancesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		// attendances of every employee unless one is asked
+		userID := uuid.Nil
+		if v := r.URL.Query().Get("userID"); v != "" {
+			parsed, err := uuid.Parse(v)
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid user ID", nil, nil))
+				return
+			}
+			userID = parsed
+		}
+
+		start, end, err := dateRange(r)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
+			return
+		}
+
+		attendances, err := adh.AdminRepo.GetAttendances(userID, start, end)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get attendances", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get attendances", toAttendanceResponses(attendances), nil))
+	}
+}
+
+// dateRange reads the from and to dates of the query string, the current month by default
+func dateRange(r *http.Request) (time.Time, time.Time, error) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+
+	if v := r.URL.Query().Get("from"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return start, end, errors.New("invalid from date")
+		}
+		start = parsed
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return start, end, errors.New("invalid to date")
+		}
+		end = parsed
+	}
+	if end.Before(start) {
+		return start, end, errors.New("invalid date range")
+	}
+	return start, end, nil
+}
+
+func toAttendanceResponse(attendance model.Attendance) AttendanceResponse {
+	return AttendanceResponse{
+		ID:                attendance.ID,
+		UserID:            attendance.UserID,
+		Date:              attendance.Date.Format("2006-01-02"),
+		CheckInAt:         attendance.CheckInAt,
+		CheckOutAt:        attendance.CheckOutAt,
+		WorkedMinutes:     attendance.WorkedMinutes,
+		LateMinutes:       attendance.LateMinutes,
+		EarlyLeaveMinutes: attendance.EarlyLeaveMinutes,
+	}
+}
+
+func toAttendanceResponses(attendances []model.Attendance) []AttendanceResponse {
+	resp := []AttendanceResponse{}
+	for _, attendance := range attendances {
+		resp = append(resp, toAttendanceResponse(attendance))
+	}
+	return resp
+}
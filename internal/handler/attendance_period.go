@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"payslip-generation-system/internal/helper"
+	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AttendancePeriodUpdateRequest struct {
+	ID        string `json:"id"`
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+}
+
+type AttendancePeriodResponse struct {
+	ID         uuid.UUID `json:"id"`
+	StartDate  string    `json:"startDate"`
+	EndDate    string    `json:"endDate"`
+	Locked     bool      `json:"locked"`
+	PayrollRun bool      `json:"payrollRun"`
+	Warnings   []string  `json:"warnings,omitempty"`
+}
+
+func (adh *AdminHandler) GetAttendancePeriodsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		periods, err := adh.AdminRepo.GetAttendancePeriods()
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get attendance periods", nil, nil))
+			return
+		}
+
+		payrollRun, err := adh.AdminRepo.GetPeriodsWithPayroll()
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to check payroll", nil, nil))
+			return
+		}
+
+		// periods are ordered by start date, a gap is flagged on the period following it
+		resp := []AttendancePeriodResponse{}
+		for i := range periods {
+			period := newAttendancePeriodResponse(&periods[i], payrollRun[periods[i].ID])
+			if i > 0 {
+				if warning := periodGapWarning(&periods[i-1], &periods[i]); warning != "" {
+					period.Warnings = append(period.Warnings, warning)
+				}
+			}
+			resp = append(resp, period)
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get attendance periods", resp, nil))
+	}
+}
+
+func (adh *AdminHandler) GetAttendancePeriodHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		id, err := uuid.Parse(r.URL.Query().Get("id"))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid period ID", nil, nil))
+			return
+		}
+
+		period, err := adh.AdminRepo.GetAttendancePeriod(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "attendance period not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get attendance period", nil, nil))
+			}
+			return
+		}
+
+		resp, err := adh.toAttendancePeriodResponse(period)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get attendance period", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get attendance period", resp, nil))
+	}
+}
+
+func (adh *AdminHandler) UpdateAttendancePeriodHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req AttendancePeriodUpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		id, err := uuid.Parse(req.ID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid period ID", nil, nil))
+			return
+		}
+
+		startDate, err1 := time.Parse("2006-01-02", req.StartDate)
+		endDate, err2 := time.Parse("2006-01-02", req.EndDate)
+		if err1 != nil || err2 != nil || endDate.Before(startDate) {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid date range", nil, nil))
+			return
+		}
+
+		period, err := adh.AdminRepo.GetAttendancePeriod(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "attendance period not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get attendance period", nil, nil))
+			}
+			return
+		}
+
+		// the payslips of a payroll are calculated on the period dates, they can no longer change
+		if !adh.checkNoPayroll(w, period.ID) {
+			return
+		}
+
+		period.StartDate = startDate
+		period.EndDate = endDate
+		period.RequestIP = r.RemoteAddr
+		period.UpdatedAt = time.Now()
+
+		if err := adh.AdminRepo.UpdateAttendancePeriod(period); err != nil {
+			if isPeriodOverlapError(err) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "period overlaps another attendance period", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update attendance period", nil, nil))
+			}
+			return
+		}
+
+		resp, err := adh.toAttendancePeriodResponse(period)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to check adjacent periods", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "attendance period updated successfully", resp, nil))
+	}
+}
+
+func (adh *AdminHandler) DeleteAttendancePeriodHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		id, err := uuid.Parse(r.URL.Query().Get("id"))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid period ID", nil, nil))
+			return
+		}
+
+		if !adh.checkNoPayroll(w, id) {
+			return
+		}
+
+		if err := adh.AdminRepo.DeleteAttendancePeriod(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "attendance period not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to delete attendance period", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "attendance period deleted successfully", nil, nil))
+	}
+}
+
+// checkNoPayroll writes a conflict response when a payroll was run for the period
+func (adh *AdminHandler) checkNoPayroll(w http.ResponseWriter, periodID uuid.UUID) bool {
+	hasPayroll, err := adh.AdminRepo.HasPayroll(periodID)
+	if err != nil {
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to check payroll", nil, nil))
+		return false
+	}
+	if hasPayroll {
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "payroll already run for this period", nil, nil))
+		return false
+	}
+	return true
+}
+
+// newAttendancePeriodResponse describes the period on its own
+func newAttendancePeriodResponse(period *model.AttendancePeriod, payrollRun bool) AttendancePeriodResponse {
+	return AttendancePeriodResponse{
+		ID:         period.ID,
+		StartDate:  period.StartDate.Format("2006-01-02"),
+		EndDate:    period.EndDate.Format("2006-01-02"),
+		Locked:     period.LockedAt != nil,
+		PayrollRun: payrollRun,
+	}
+}
+
+// toAttendancePeriodResponse describes the period with the gaps to its adjacent periods
+func (adh *AdminHandler) toAttendancePeriodResponse(period *model.AttendancePeriod) (AttendancePeriodResponse, error) {
+	hasPayroll, err := adh.AdminRepo.HasPayroll(period.ID)
+	if err != nil {
+		return AttendancePeriodResponse{}, err
+	}
+	resp := newAttendancePeriodResponse(period, hasPayroll)
+
+	previous, next, err := adh.AdminRepo.GetAdjacentAttendancePeriods(period)
+	if err != nil {
+		return resp, err
+	}
+	if previous != nil {
+		if warning := periodGapWarning(previous, period); warning != "" {
+			resp.Warnings = append(resp.Warnings, warning)
+		}
+	}
+	if next != nil {
+		if warning := periodGapWarning(period, next); warning != "" {
+			resp.Warnings = append(resp.Warnings, warning)
+		}
+	}
+	return resp, nil
+}
+
+// periodGapWarning describes the days left uncovered between two consecutive periods
+func periodGapWarning(previous, next *model.AttendancePeriod) string {
+	gapStart := previous.EndDate.AddDate(0, 0, 1)
+	if !gapStart.Before(next.StartDate) {
+		return ""
+	}
+	gapEnd := next.StartDate.AddDate(0, 0, -1)
+	return fmt.Sprintf("no attendance period covers %s to %s", gapStart.Format("2006-01-02"), gapEnd.Format("2006-01-02"))
+}
+
+// isPeriodOverlapError checks for a violation of the attendance_periods_no_overlap exclusion constraint
+func isPeriodOverlapError(err error) bool {
+	return strings.Contains(err.Error(), "attendance_periods_no_overlap") || strings.Contains(err.Error(), "23P01")
+}
@@ -1,51 +1,120 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
-	"os"
 	"payslip-generation-system/internal/helper"
 	"payslip-generation-system/internal/middleware"
 	"payslip-generation-system/internal/model"
 	"payslip-generation-system/internal/repository"
+	"payslip-generation-system/internal/service"
+	"payslip-generation-system/internal/storage"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPayslipPageSize = 12
+	maxPayslipPageSize     = 100
 )
 
 type OvertimeRequest struct {
-	Hours int `json:"hours"`
+	Date      string `json:"date"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
 }
 
 type ReimbursementRequest struct {
+	Category    string `json:"category"`
 	Amount      int    `json:"amount"`
 	Description string `json:"description"`
+	ExpenseDate string `json:"expenseDate"`
 }
 
 type PayslipRequest struct {
 	PayrollID string `json:"payrollID"`
+	// "pdf" downloads the payslip as a PDF encrypted with the payslip password instead
+	Format string `json:"format"`
+}
+
+type PayslipPINRequest struct {
+	PIN string `json:"pin"`
 }
 
 type PayslipResponse struct {
-	BaseSalary         int `json:"baseSalary"`
-	AttendanceDays     int `json:"attendanceDays"`
-	ProratedSalary     int `json:"proratedSalary"`
-	OvertimeHours      int `json:"overtimeHours"`
-	OvertimePay        int `json:"overtimePay"`
-	ReimbursementTotal int `json:"reimbursementTotal"`
-	TakeHomePay        int `json:"takeHomePay"`
+	BaseSalary      int                   `json:"baseSalary"`
+	AttendanceDays  int                   `json:"attendanceDays"`
+	PaidLeaveDays   int                   `json:"paidLeaveDays"`
+	UnpaidLeaveDays int                   `json:"unpaidLeaveDays"`
+	OvertimeHours   float64               `json:"overtimeHours"`
+	Earnings        []PayslipItemResponse `json:"earnings"`
+	Deductions      []PayslipItemResponse `json:"deductions"`
+	EmployerCosts   []PayslipItemResponse `json:"employerContributions"`
+	GrossPay        int                   `json:"grossPay"`
+	TaxAmount       int                   `json:"taxAmount"`
+	TotalDeductions int                   `json:"totalDeductions"`
+	NetPay          int                   `json:"netPay"`
+	EmployerCost    int                   `json:"employerCost"`
+	// the totals of the tax year up to and including this payslip
+	YearToDate PayslipTotalsResponse `json:"yearToDate"`
+}
+
+type PayslipTotalsResponse struct {
+	GrossPay        int `json:"grossPay"`
+	TaxAmount       int `json:"taxAmount"`
+	TotalDeductions int `json:"totalDeductions"`
+	NetPay          int `json:"netPay"`
+}
+
+type PayslipHistoryItemResponse struct {
+	PayrollID       uuid.UUID             `json:"payrollId"`
+	PeriodID        uuid.UUID             `json:"periodId"`
+	StartDate       string                `json:"startDate"`
+	EndDate         string                `json:"endDate"`
+	PayrollStatus   string                `json:"payrollStatus"`
+	PublishedAt     *time.Time            `json:"publishedAt"`
+	GrossPay        int                   `json:"grossPay"`
+	TaxAmount       int                   `json:"taxAmount"`
+	TotalDeductions int                   `json:"totalDeductions"`
+	NetPay          int                   `json:"netPay"`
+	YearToDate      PayslipTotalsResponse `json:"yearToDate"`
+}
+
+type PayslipHistoryResponse struct {
+	Payslips []PayslipHistoryItemResponse `json:"payslips"`
+	Page     int                          `json:"page"`
+	PageSize int                          `json:"pageSize"`
+	Total    int64                        `json:"total"`
+}
+
+type PayslipItemResponse struct {
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	Quantity float64 `json:"quantity,omitempty"`
+	Amount   int     `json:"amount"`
 }
 
 type EmployeeHandler struct {
-	EmployeeRepo repository.EmployeeRepository
+	EmployeeRepo   repository.EmployeeRepository
+	PayslipService service.PayslipService
+	Schedule       service.WorkSchedule
+	Storage        storage.Storage
 }
 
-func NewEmployeeHandler(employeeRepo repository.EmployeeRepository) *EmployeeHandler {
-	return &EmployeeHandler{EmployeeRepo: employeeRepo}
+func NewEmployeeHandler(employeeRepo repository.EmployeeRepository, payslipService service.PayslipService) *EmployeeHandler {
+	return &EmployeeHandler{
+		EmployeeRepo:   employeeRepo,
+		PayslipService: payslipService,
+		Schedule:       service.WorkScheduleFromEnv(),
+		Storage:        storage.LocalStorageFromEnv(),
+	}
 }
 
 func (emh *EmployeeHandler) SubmitAttendanceHanlder() http.HandlerFunc {
@@ -61,34 +130,72 @@ func (emh *EmployeeHandler) SubmitAttendanceHanlder() http.HandlerFunc {
 			return
 		}
 
-		// to check is today weekend or weekday
-		today := time.Now().Truncate(24 * time.Hour)
-		weekday := today.Weekday()
-		if weekday == time.Saturday || weekday == time.Sunday {
-			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "cannot submit on weekend", nil, nil))
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
 			return
 		}
 
-		userID, err := uuid.Parse(middleware.GetUserID(r))
+		// to get the shift the employee is rostered on today, if any
+		now := time.Now()
+		today := service.Midnight(now, now.Location())
+		schedule, rostered, err := emh.scheduleFor(userID, today)
 		if err != nil {
-			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get roster", nil, nil))
 			return
 		}
 
+		// employees without a roster follow the office calendar, a shift worker is off on the
+		// dates they are not rostered on
+		if !rostered {
+			shiftWorker, err := emh.EmployeeRepo.IsShiftWorker(userID, today)
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get roster", nil, nil))
+				return
+			}
+			if shiftWorker {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "cannot submit on a day without a shift", nil, nil))
+				return
+			}
+
+			// to check is today weekend or weekday
+			weekday := today.Weekday()
+			if weekday == time.Saturday || weekday == time.Sunday {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "cannot submit on weekend", nil, nil))
+				return
+			}
+
+			// to check is today a public or company holiday
+			holiday, err := emh.EmployeeRepo.IsHoliday(today)
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to check holiday", nil, nil))
+				return
+			}
+			if holiday {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "cannot submit on holiday", nil, nil))
+				return
+			}
+		}
+
 		log.Printf("Attendance submission: user_id=%s date=%v", userID.String(), today.Format("2006-01-02"))
 
+		// submitting the attendance checks in, lateness is measured against the start of the shift
 		attendance := model.Attendance{
-			UserID:    userID,
-			Date:      today,
-			CreatedBy: userID,
-			RequestIP: r.RemoteAddr,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			UserID:      userID,
+			Date:        today,
+			CheckInAt:   &now,
+			LateMinutes: schedule.LateMinutes(today, now),
+			CreatedBy:   userID,
+			RequestIP:   r.RemoteAddr,
+			CreatedAt:   now,
+			UpdatedAt:   now,
 		}
 
 		saveErr := emh.EmployeeRepo.SaveAttendance(&attendance)
 		if saveErr != nil {
-			if strings.Contains(saveErr.Error(), "duplicate key") {
+			if errors.Is(saveErr, repository.ErrPeriodLocked) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, saveErr.Error(), nil, nil))
+			} else if strings.Contains(saveErr.Error(), "duplicate key") {
 				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "already submitted today", nil, nil))
 			} else {
 				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to create attendance", nil, nil))
@@ -96,7 +203,7 @@ func (emh *EmployeeHandler) SubmitAttendanceHanlder() http.HandlerFunc {
 			return
 		}
 
-		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "attendance submitted successfully", nil, nil))
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "attendance submitted successfully", toAttendanceResponse(attendance), nil))
 	}
 }
 
@@ -125,38 +232,106 @@ func (emh *EmployeeHandler) SubmitOvertimeHandler() http.HandlerFunc {
 			return
 		}
 
-		hourNow := time.Now().Hour()
-		fmt.Printf("%v\n", hourNow)
-		endOfWorkingHour, _ := strconv.Atoi(os.Getenv("WORK_HOUR_END"))
-		fmt.Printf("%v-%v\n", hourNow, endOfWorkingHour)
-		if hourNow < endOfWorkingHour {
-			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "overtime can only be submitted after 5PM", nil, nil))
+		// the overtime is worked on the date, today when no date is given
+		now := time.Now()
+		date := service.Midnight(now, now.Location())
+		if req.Date != "" {
+			parsed, err := time.Parse("2006-01-02", req.Date)
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid date format", nil, nil))
+				return
+			}
+			date = parsed
+		}
+
+		startAt, endAt, err := service.OvertimeWindow(date, req.StartTime, req.EndTime, now.Location())
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
+			return
+		}
+		if endAt.After(now) {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "overtime can only be submitted once it has ended", nil, nil))
+			return
+		}
+
+		schedule, rostered, err := emh.scheduleFor(userID, date)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get roster", nil, nil))
+			return
+		}
+
+		dayType, err := emh.dayTypeFor(userID, date, rostered)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get day type", nil, nil))
 			return
 		}
 
-		today := time.Now().Truncate(24 * time.Hour)
+		// on a working day overtime starts once the shift is over and ends before the check out
+		if dayType == model.DayTypeWorkday {
+			shiftEnd := schedule.EndOn(date, now.Location())
+			if startAt.Before(shiftEnd) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "overtime can only start after the shift ends at "+shiftEnd.Format("15:04"), nil, nil))
+				return
+			}
+
+			attendances, err := emh.EmployeeRepo.GetAttendances(userID, date, date)
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get attendance", nil, nil))
+				return
+			}
+			if len(attendances) == 0 {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "no attendance on the overtime date", nil, nil))
+				return
+			}
+			checkOut := attendances[0].CheckOutAt
+			if checkOut == nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "overtime can only be submitted after checking out", nil, nil))
+				return
+			}
+			if endAt.After(*checkOut) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "overtime can only end before the check out at "+checkOut.In(now.Location()).Format("15:04"), nil, nil))
+				return
+			}
+		}
 
 		overtime := model.Overtime{
+			ID:        uuid.New(),
 			UserID:    userID,
-			Date:      today,
-			Hours:     req.Hours,
+			Date:      date,
+			Hours:     service.OvertimeHours(startAt, endAt),
+			StartAt:   &startAt,
+			EndAt:     &endAt,
+			DayType:   dayType,
+			Status:    model.OvertimePending,
 			CreatedBy: userID,
 			RequestIP: r.RemoteAddr,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
 
-		saveErr := emh.EmployeeRepo.SaveOvertime(&overtime)
+		settings, err := emh.EmployeeRepo.GetCompanySettings()
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get company settings", nil, nil))
+			return
+		}
+		// the limits are checked against the week's overtime in the transaction that saves it
+		weekStart, weekEnd := service.WeekOf(date)
+		saveErr := emh.EmployeeRepo.SaveOvertime(&overtime, weekStart, weekEnd, func(submitted []model.Overtime) error {
+			return service.CheckOvertimeLimits(submitted, &overtime, settings.MaxOvertimeHoursPerDay, settings.MaxOvertimeHoursPerWeek)
+		})
 		if saveErr != nil {
-			if strings.Contains(saveErr.Error(), "duplicate key") {
-				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "already submitted today", nil, nil))
+			var limitErr *service.OvertimeLimitError
+			if errors.Is(saveErr, repository.ErrPeriodLocked) || errors.Is(saveErr, service.ErrOvertimeOverlap) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, saveErr.Error(), nil, nil))
+			} else if errors.As(saveErr, &limitErr) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, saveErr.Error(), nil, nil))
 			} else {
 				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to submit overtime", nil, nil))
 			}
 			return
 		}
 
-		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "overtime submitted successfully", nil, nil))
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "overtime submitted for approval", toOvertimeResponse(&overtime), nil))
 	}
 }
 
@@ -173,9 +348,9 @@ func (emh *EmployeeHandler) SubmitReimbursementHandler() http.HandlerFunc {
 			return
 		}
 
-		var req ReimbursementRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+		req, receipts, err := decodeReimbursementRequest(w, r)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
 			return
 		}
 
@@ -185,36 +360,132 @@ func (emh *EmployeeHandler) SubmitReimbursementHandler() http.HandlerFunc {
 			return
 		}
 
-		hourNow := time.Now().Hour()
-		fmt.Printf("%v\n", hourNow)
-		endOfWorkingHour, _ := strconv.Atoi(os.Getenv("WORK_HOUR_END"))
-		fmt.Printf("%v-%v\n", hourNow, endOfWorkingHour)
-		if hourNow < endOfWorkingHour {
-			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "overtime can only be submitted after 5PM", nil, nil))
+		if req.Amount <= 0 {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "amount must be above 0", nil, nil))
+			return
+		}
+		category := strings.ToUpper(strings.TrimSpace(req.Category))
+		if category == "" {
+			category = "OTHER"
+		}
+
+		// the grade of the employee decides the categories and limits they can claim
+		limit, err := emh.EmployeeRepo.GetReimbursementLimit(userID, category)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, service.ErrCategoryNotAllowed.Error(), nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get reimbursement limit", nil, nil))
+			}
+			return
+		}
+
+		// the expense was made on the date, today when no date is given
+		now := time.Now()
+		expenseDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		if req.ExpenseDate != "" {
+			parsed, err := time.Parse("2006-01-02", req.ExpenseDate)
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid expense date format", nil, nil))
+				return
+			}
+			if parsed.After(expenseDate) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "expense date can't be in the future", nil, nil))
+				return
+			}
+			expenseDate = parsed
+		}
+
+		start, end, err := emh.EmployeeRepo.GetClaimPeriod(expenseDate)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get claim period", nil, nil))
+			return
+		}
+		claimed, err := emh.EmployeeRepo.GetClaimedAmount(userID, category, start, end)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get claimed amount", nil, nil))
+			return
+		}
+		if err := service.CheckReimbursementLimit(limit, claimed, req.Amount); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
 			return
 		}
 
+		// the same receipt can't be claimed twice while its claim is not rejected, the unique
+		// checksum of the active receipts catches concurrent claims
+		if len(receipts) > 0 {
+			checksums := []string{}
+			for _, rc := range receipts {
+				checksums = append(checksums, rc.Checksum)
+			}
+			claimed, err := emh.EmployeeRepo.IsReceiptClaimed(checksums)
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to check receipts", nil, nil))
+				return
+			}
+			if claimed {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "receipt has already been claimed", nil, nil))
+				return
+			}
+		}
+
 		reimburse := model.Reimbursement{
+			ID:          uuid.New(),
 			UserID:      userID,
+			Category:    category,
 			Amount:      req.Amount,
 			Description: req.Description,
+			ExpenseDate: expenseDate,
+			Status:      model.ReimbursementPending,
 			CreatedBy:   userID,
 			RequestIP:   r.RemoteAddr,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+
+		for _, rc := range receipts {
+			attachmentID := uuid.New()
+			attachment := model.ReimbursementAttachment{
+				ID:              attachmentID,
+				ReimbursementID: reimburse.ID,
+				FileName:        rc.FileName,
+				ContentType:     rc.ContentType,
+				Size:            int64(len(rc.Content)),
+				Checksum:        rc.Checksum,
+				StorageKey:      "receipts/" + reimburse.ID.String() + "/" + attachmentID.String() + receiptExtensions[rc.ContentType],
+				Active:          true,
+				CreatedAt:       now,
+			}
+			if err := emh.Storage.Put(attachment.StorageKey, bytes.NewReader(rc.Content)); err != nil {
+				log.Println("failed to store receipt:", err)
+				emh.deleteReceipts(reimburse.Attachments)
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to store receipt", nil, nil))
+				return
+			}
+			reimburse.Attachments = append(reimburse.Attachments, attachment)
 		}
 
 		saveErr := emh.EmployeeRepo.SaveReimbursement(&reimburse)
 		if saveErr != nil {
+			emh.deleteReceipts(reimburse.Attachments)
 			if strings.Contains(saveErr.Error(), "duplicate key") {
-				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "already submitted today", nil, nil))
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "receipt has already been claimed", nil, nil))
 			} else {
-				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to submit overtime", nil, nil))
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to submit reimbursement", nil, nil))
 			}
 			return
 		}
 
-		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "overtime submitted successfully", nil, nil))
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "reimbursement submitted for approval", toReimbursementResponse(&reimburse), nil))
+	}
+}
+
+// deleteReceipts removes the stored receipts of a claim that wasn't saved
+func (emh *EmployeeHandler) deleteReceipts(attachments []model.ReimbursementAttachment) {
+	for _, attachment := range attachments {
+		if err := emh.Storage.Delete(attachment.StorageKey); err != nil {
+			log.Println("failed to delete receipt:", err)
+		}
 	}
 }
 
@@ -225,8 +496,8 @@ func (emh *EmployeeHandler) GetPayslipHandler() http.HandlerFunc {
 			return
 		}
 
-		userID := middleware.GetUserID(r)
-		if userID == "" {
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
 			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusUnauthorized, "unauthorized", nil, nil))
 			return
 		}
@@ -238,22 +509,233 @@ func (emh *EmployeeHandler) GetPayslipHandler() http.HandlerFunc {
 		}
 		defer r.Body.Close()
 
-		payslip, err := emh.EmployeeRepo.GetPayslip(uuid.MustParse(userID), uuid.MustParse(req.PayrollID))
+		payrollID, err := uuid.Parse(req.PayrollID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid payroll ID", nil, nil))
+			return
+		}
+
+		if req.Format == "pdf" {
+			file, err := emh.PayslipService.EmployeePayslip(userID, payrollID)
+			if err != nil {
+				writePayslipError(w, err, "failed to generate payslip")
+				return
+			}
+			writeFile(w, file)
+			return
+		}
+
+		payslip, err := emh.EmployeeRepo.GetPayslip(userID, payrollID)
 		if err != nil {
 			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "payslip not found", nil, nil))
 			return
 		}
 
-		resp := PayslipResponse{
-			BaseSalary:         payslip.BaseSalary,
-			AttendanceDays:     payslip.AttendanceDays,
-			ProratedSalary:     payslip.ProratedSalary,
-			OvertimeHours:      payslip.OvertimeHours,
-			OvertimePay:        payslip.OvertimePay,
-			ReimbursementTotal: payslip.ReimbursementTotal,
-			TakeHomePay:        payslip.TakeHomePay,
+		history, err := emh.EmployeeRepo.GetPayslipYearToDate(userID, payrollID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get year to date totals", nil, nil))
+			return
 		}
 
+		resp := toPayslipResponse(payslip)
+		resp.YearToDate = toPayslipTotalsResponse(history)
 		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "payslip has generated successfully", resp, nil))
 	}
 }
+
+// GetPayslipsHandler lists the payslips released to the employee, the latest period first,
+// ?page= from 1 and ?pageSize= up to 100
+func (emh *EmployeeHandler) GetPayslipsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		page, err := queryInt(r, "page", 1)
+		if err != nil || page < 1 {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid page", nil, nil))
+			return
+		}
+		pageSize, err := queryInt(r, "pageSize", defaultPayslipPageSize)
+		if err != nil || pageSize < 1 || pageSize > maxPayslipPageSize {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid page size", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		history, total, err := emh.EmployeeRepo.GetPayslipHistory(userID, (page-1)*pageSize, pageSize)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get payslips", nil, nil))
+			return
+		}
+
+		resp := PayslipHistoryResponse{Payslips: []PayslipHistoryItemResponse{}, Page: page, PageSize: pageSize, Total: total}
+		for i := range history {
+			h := &history[i]
+			resp.Payslips = append(resp.Payslips, PayslipHistoryItemResponse{
+				PayrollID:       h.PayrollID,
+				PeriodID:        h.PeriodID,
+				StartDate:       h.StartDate.Format("2006-01-02"),
+				EndDate:         h.EndDate.Format("2006-01-02"),
+				PayrollStatus:   h.PayrollStatus,
+				PublishedAt:     h.PublishedAt,
+				GrossPay:        h.GrossPay,
+				TaxAmount:       h.TaxAmount,
+				TotalDeductions: h.TotalDeductions,
+				NetPay:          h.NetPay,
+				YearToDate:      toPayslipTotalsResponse(h),
+			})
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get payslips", resp, nil))
+	}
+}
+
+// UpdatePayslipPINHandler sets the PIN the employee's payslip PDFs are encrypted with when the
+// company uses the PIN scheme
+func (emh *EmployeeHandler) UpdatePayslipPINHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req PayslipPINRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		if err := service.ValidatePayslipPIN(req.PIN); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		encrypted, err := service.EncryptPayslipPIN(req.PIN)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to encrypt payslip PIN", nil, nil))
+			return
+		}
+
+		if err := emh.EmployeeRepo.UpdatePayslipPIN(userID, encrypted); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update payslip PIN", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "payslip PIN updated successfully", nil, nil))
+	}
+}
+
+// scheduleFor returns the working hours of the shift the employee is rostered on for the date,
+// or the office hours when the employee is not rostered
+func (emh *EmployeeHandler) scheduleFor(userID uuid.UUID, date time.Time) (service.WorkSchedule, bool, error) {
+	roster, err := emh.EmployeeRepo.GetRoster(userID, date)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return emh.Schedule, false, nil
+		}
+		return service.WorkSchedule{}, false, err
+	}
+	schedule, err := service.WorkScheduleFromShift(roster.Shift)
+	return schedule, true, err
+}
+
+// dayTypeFor decides the overtime tiers of the date, a holiday is paid as a public holiday.
+// A shift worker rests on the dates they are not rostered on, and the employees following the
+// office calendar on the weekend
+func (emh *EmployeeHandler) dayTypeFor(userID uuid.UUID, date time.Time, rostered bool) (string, error) {
+	holiday, err := emh.EmployeeRepo.IsHoliday(date)
+	if err != nil {
+		return "", err
+	}
+	if holiday {
+		return model.DayTypePublicHoliday, nil
+	}
+	if rostered {
+		return model.DayTypeWorkday, nil
+	}
+
+	shiftWorker, err := emh.EmployeeRepo.IsShiftWorker(userID, date)
+	if err != nil {
+		return "", err
+	}
+	if shiftWorker || date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return model.DayTypeRestDay, nil
+	}
+	return model.DayTypeWorkday, nil
+}
+
+func toPayslipTotalsResponse(h *model.PayslipHistory) PayslipTotalsResponse {
+	return PayslipTotalsResponse{
+		GrossPay:        h.YTDGrossPay,
+		TaxAmount:       h.YTDTaxAmount,
+		TotalDeductions: h.YTDTotalDeductions,
+		NetPay:          h.YTDNetPay,
+	}
+}
+
+// queryInt reads an integer query parameter, the fallback when it is absent
+func queryInt(r *http.Request, key string, fallback int) (int, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func toPayslipResponse(payslip *model.Payslip) PayslipResponse {
+	resp := PayslipResponse{
+		BaseSalary:      payslip.BaseSalary,
+		AttendanceDays:  payslip.AttendanceDays,
+		PaidLeaveDays:   payslip.PaidLeaveDays,
+		UnpaidLeaveDays: payslip.UnpaidLeaveDays,
+		OvertimeHours:   payslip.OvertimeHours,
+		Earnings:        []PayslipItemResponse{},
+		Deductions:      []PayslipItemResponse{},
+		EmployerCosts:   []PayslipItemResponse{},
+		GrossPay:        payslip.GrossPay,
+		TaxAmount:       payslip.TaxAmount,
+		TotalDeductions: payslip.TotalDeductions,
+		NetPay:          payslip.NetPay,
+		EmployerCost:    payslip.EmployerCost,
+	}
+	for _, item := range payslip.Items {
+		line := PayslipItemResponse{
+			Code:     item.Code,
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Amount:   item.Amount,
+		}
+		switch item.Type {
+		case model.PayslipItemDeduction:
+			resp.Deductions = append(resp.Deductions, line)
+		case model.PayslipItemEmployerCost:
+			resp.EmployerCosts = append(resp.EmployerCosts, line)
+		default:
+			resp.Earnings = append(resp.Earnings, line)
+		}
+	}
+	return resp
+}
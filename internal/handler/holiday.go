@@ -0,0 +1,361 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"payslip-generation-system/internal/helper"
+	"payslip-generation-system/internal/ics"
+	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type HolidayRequest struct {
+	ID       string `json:"id"`
+	Date     string `json:"date"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+type HolidayResponse struct {
+	ID       uuid.UUID `json:"id"`
+	Date     string    `json:"date"`
+	Name     string    `json:"name"`
+	Category string    `json:"category"`
+}
+
+type HolidayImportResponse struct {
+	Imported int `json:"imported"`
+}
+
+type HolidayHandler struct {
+	HolidayRepo repository.HolidayRepository
+}
+
+func NewHolidayHandler(holidayRepo repository.HolidayRepository) *HolidayHandler {
+	return &HolidayHandler{HolidayRepo: holidayRepo}
+}
+
+func (hh *HolidayHandler) CreateHolidayHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req HolidayRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		date, err := time.Parse("2006-01-02", req.Date)
+		if err != nil || strings.TrimSpace(req.Name) == "" || !isValidHolidayCategory(req.Category) {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid date, name or category", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		holiday := model.Holiday{
+			Date:      date,
+			Name:      req.Name,
+			Category:  req.Category,
+			CreatedBy: userID,
+			RequestIP: r.RemoteAddr,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		if err := hh.HolidayRepo.SaveHoliday(&holiday); err != nil {
+			if strings.Contains(err.Error(), "duplicate key") {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "holiday already exists on this date", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to create holiday", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "holiday created successfully", toHolidayResponse(holiday), nil))
+	}
+}
+
+func (hh *HolidayHandler) GetHolidaysHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		start, end, err := yearRange(r)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid year", nil, nil))
+			return
+		}
+
+		holidays, err := hh.HolidayRepo.GetHolidays(start, end)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get holidays", nil, nil))
+			return
+		}
+
+		resp := []HolidayResponse{}
+		for _, holiday := range holidays {
+			resp = append(resp, toHolidayResponse(holiday))
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get holidays", resp, nil))
+	}
+}
+
+func (hh *HolidayHandler) UpdateHolidayHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req HolidayRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		id, err := uuid.Parse(req.ID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid holiday ID", nil, nil))
+			return
+		}
+
+		date, err := time.Parse("2006-01-02", req.Date)
+		if err != nil || strings.TrimSpace(req.Name) == "" || !isValidHolidayCategory(req.Category) {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid date, name or category", nil, nil))
+			return
+		}
+
+		holiday, err := hh.HolidayRepo.GetHoliday(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "holiday not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get holiday", nil, nil))
+			}
+			return
+		}
+
+		holiday.Date = date
+		holiday.Name = req.Name
+		holiday.Category = req.Category
+		holiday.UpdatedAt = time.Now()
+
+		if err := hh.HolidayRepo.UpdateHoliday(holiday); err != nil {
+			if strings.Contains(err.Error(), "duplicate key") {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "holiday already exists on this date", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update holiday", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "holiday updated successfully", toHolidayResponse(*holiday), nil))
+	}
+}
+
+func (hh *HolidayHandler) DeleteHolidayHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		id, err := uuid.Parse(r.URL.Query().Get("id"))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid holiday ID", nil, nil))
+			return
+		}
+
+		if err := hh.HolidayRepo.DeleteHoliday(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "holiday not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to delete holiday", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "holiday deleted successfully", nil, nil))
+	}
+}
+
+// ImportHolidaysHandler reads an ICS calendar from the request body, events without
+// a known category are imported with the category given in the query string
+func (hh *HolidayHandler) ImportHolidaysHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		category := r.URL.Query().Get("category")
+		if category == "" {
+			category = model.HolidayNational
+		}
+		if !isValidHolidayCategory(category) {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid category", nil, nil))
+			return
+		}
+
+		events, err := ics.Parse(r.Body)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid calendar: "+err.Error(), nil, nil))
+			return
+		}
+		defer r.Body.Close()
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		// the same date can only be imported once
+		holidays := []model.Holiday{}
+		seen := map[string]bool{}
+		for _, event := range events {
+			key := event.Date.Format("2006-01-02")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			eventCategory := event.Category
+			if !isValidHolidayCategory(eventCategory) {
+				eventCategory = category
+			}
+
+			holidays = append(holidays, model.Holiday{
+				Date:      event.Date,
+				Name:      event.Summary,
+				Category:  eventCategory,
+				CreatedBy: userID,
+				RequestIP: r.RemoteAddr,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			})
+		}
+
+		if err := hh.HolidayRepo.ImportHolidays(holidays); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to import holidays", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "holidays imported successfully", HolidayImportResponse{Imported: len(holidays)}, nil))
+	}
+}
+
+func (hh *HolidayHandler) ExportHolidaysHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		start, end, err := yearRange(r)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid year", nil, nil))
+			return
+		}
+
+		holidays, err := hh.HolidayRepo.GetHolidays(start, end)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get holidays", nil, nil))
+			return
+		}
+
+		events := []ics.Event{}
+		for _, holiday := range holidays {
+			events = append(events, ics.Event{
+				UID:      holiday.ID.String() + "@payslip-generation-system",
+				Date:     holiday.Date,
+				Summary:  holiday.Name,
+				Category: holiday.Category,
+			})
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"holidays-"+start.Format("2006")+".ics\"")
+		ics.Write(w, "-//payslip-generation-system//holidays//EN", events)
+	}
+}
+
+func isValidHolidayCategory(category string) bool {
+	switch category {
+	case model.HolidayNational, model.HolidayCutiBersama, model.HolidayCompany:
+		return true
+	}
+	return false
+}
+
+// yearRange reads the year query parameter, defaulting to the current year
+func yearRange(r *http.Request) (time.Time, time.Time, error) {
+	year := time.Now().Year()
+	if v := r.URL.Query().Get("year"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1900 || parsed > 9999 {
+			return time.Time{}, time.Time{}, errors.New("invalid year")
+		}
+		year = parsed
+	}
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(1, 0, -1), nil
+}
+
+func toHolidayResponse(holiday model.Holiday) HolidayResponse {
+	return HolidayResponse{
+		ID:       holiday.ID,
+		Date:     holiday.Date.Format("2006-01-02"),
+		Name:     holiday.Name,
+		Category: holiday.Category,
+	}
+}
@@ -0,0 +1,481 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"payslip-generation-system/internal/helper"
+	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"payslip-generation-system/internal/service"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LeaveRequestRequest struct {
+	LeaveType string `json:"leaveType"`
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+	Reason    string `json:"reason"`
+}
+
+type LeaveReviewRequest struct {
+	LeaveRequestID string `json:"leaveRequestID"`
+	Approve        bool   `json:"approve"`
+	Note           string `json:"note"`
+}
+
+type LeaveCancelRequest struct {
+	LeaveRequestID string `json:"leaveRequestID"`
+}
+
+type LeaveAccrualRequest struct {
+	Year int `json:"year"`
+}
+
+type EmployeeManagerRequest struct {
+	UserID    string `json:"userID"`
+	ManagerID string `json:"managerID"`
+}
+
+type LeaveRequestResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"userId"`
+	LeaveType  string     `json:"leaveType"`
+	StartDate  string     `json:"startDate"`
+	EndDate    string     `json:"endDate"`
+	Days       int        `json:"days"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	ReviewedBy *uuid.UUID `json:"reviewedBy"`
+	ReviewedAt *time.Time `json:"reviewedAt"`
+	ReviewNote string     `json:"reviewNote"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type LeaveTypeResponse struct {
+	Code              string `json:"code"`
+	Name              string `json:"name"`
+	Paid              bool   `json:"paid"`
+	Accrues           bool   `json:"accrues"`
+	AnnualEntitlement int    `json:"annualEntitlement"`
+	MaxCarryOver      int    `json:"maxCarryOver"`
+	MaxDaysPerRequest *int   `json:"maxDaysPerRequest"`
+}
+
+type LeaveBalanceResponse struct {
+	LeaveType   string `json:"leaveType"`
+	Year        int    `json:"year"`
+	Entitled    int    `json:"entitled"`
+	CarriedOver int    `json:"carriedOver"`
+	Used        int    `json:"used"`
+	Remaining   int    `json:"remaining"`
+}
+
+type LeaveHandler struct {
+	LeaveRepo    repository.LeaveRepository
+	LeaveService service.LeaveService
+}
+
+func NewLeaveHandler(leaveRepo repository.LeaveRepository, leaveService service.LeaveService) *LeaveHandler {
+	return &LeaveHandler{LeaveRepo: leaveRepo, LeaveService: leaveService}
+}
+
+func (lh *LeaveHandler) RequestLeaveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req LeaveRequestRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		startDate, err1 := time.Parse("2006-01-02", req.StartDate)
+		endDate, err2 := time.Parse("2006-01-02", req.EndDate)
+		if err1 != nil || err2 != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid date format", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		request, err := lh.LeaveService.RequestLeave(userID, strings.ToUpper(req.LeaveType), startDate, endDate, req.Reason, r.RemoteAddr)
+		if err != nil {
+			writeLeaveError(w, err, "failed to request leave")
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "leave requested successfully", toLeaveRequestResponse(request), nil))
+	}
+}
+
+func (lh *LeaveHandler) GetLeaveRequestsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		requests, err := lh.LeaveRepo.GetLeaveRequests(userID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get leave requests", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get leave requests", toLeaveRequestResponses(requests), nil))
+	}
+}
+
+func (lh *LeaveHandler) GetLeaveBalancesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		year := time.Now().Year()
+		if v := r.URL.Query().Get("year"); v != "" {
+			parsed, err := strconv.Atoi(v)
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid year", nil, nil))
+				return
+			}
+			year = parsed
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		balances, err := lh.LeaveRepo.GetLeaveBalances(userID, year)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get leave balances", nil, nil))
+			return
+		}
+
+		resp := []LeaveBalanceResponse{}
+		for _, b := range balances {
+			resp = append(resp, LeaveBalanceResponse{
+				LeaveType:   b.LeaveType,
+				Year:        b.Year,
+				Entitled:    b.Entitled,
+				CarriedOver: b.CarriedOver,
+				Used:        b.Used,
+				Remaining:   b.Remaining(),
+			})
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get leave balances", resp, nil))
+	}
+}
+
+func (lh *LeaveHandler) CancelLeaveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req LeaveCancelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		requestID, err := uuid.Parse(req.LeaveRequestID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid leave request ID", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		request, err := lh.LeaveService.CancelLeave(requestID, userID)
+		if err != nil {
+			writeLeaveError(w, err, "failed to cancel leave")
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "leave cancelled", toLeaveRequestResponse(request), nil))
+	}
+}
+
+// GetPendingApprovalsHandler lists the pending requests the caller reviews, a manager those of
+// their employees and an admin those of employees without a manager
+func (lh *LeaveHandler) GetPendingApprovalsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		var managerID *uuid.UUID
+		switch middleware.GetUserRole(r) {
+		case "admin":
+		case "employee":
+			parsed, err := uuid.Parse(middleware.GetUserID(r))
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+				return
+			}
+			managerID = &parsed
+		default:
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		requests, err := lh.LeaveRepo.GetPendingLeaveRequests(managerID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get leave requests", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get leave requests", toLeaveRequestResponses(requests), nil))
+	}
+}
+
+func (lh *LeaveHandler) ReviewLeaveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		role := middleware.GetUserRole(r)
+		if role != "admin" && role != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req LeaveReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		requestID, err := uuid.Parse(req.LeaveRequestID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid leave request ID", nil, nil))
+			return
+		}
+
+		reviewerID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		request, err := lh.LeaveService.ReviewLeave(requestID, reviewerID, role, req.Approve, strings.TrimSpace(req.Note))
+		if err != nil {
+			writeLeaveError(w, err, "failed to review leave")
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "leave "+request.Status, toLeaveRequestResponse(request), nil))
+	}
+}
+
+func (lh *LeaveHandler) GetLeaveTypesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		types, err := lh.LeaveRepo.GetLeaveTypes()
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get leave types", nil, nil))
+			return
+		}
+
+		resp := []LeaveTypeResponse{}
+		for _, t := range types {
+			resp = append(resp, LeaveTypeResponse{
+				Code:              t.Code,
+				Name:              t.Name,
+				Paid:              t.Paid,
+				Accrues:           t.Accrues,
+				AnnualEntitlement: t.AnnualEntitlement,
+				MaxCarryOver:      t.MaxCarryOver,
+				MaxDaysPerRequest: t.MaxDaysPerRequest,
+			})
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get leave types", resp, nil))
+	}
+}
+
+// AccrueLeaveHandler opens the balances of the year for every employee, carrying over what is
+// left of the previous year up to the leave type's limit. Balances already opened are kept
+func (lh *LeaveHandler) AccrueLeaveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req LeaveAccrualRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Year < 2000 || req.Year > 9999 {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid year", nil, nil))
+			return
+		}
+
+		count, err := lh.LeaveRepo.AccrueLeave(req.Year)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to accrue leave", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "leave accrued", map[string]interface{}{"year": req.Year, "balancesCreated": count}, nil))
+	}
+}
+
+// UpdateEmployeeManagerHandler sets the manager approving the employee's leave, an empty
+// managerID removes it so admins approve instead
+func (lh *LeaveHandler) UpdateEmployeeManagerHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req EmployeeManagerRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		employeeID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid user ID", nil, nil))
+			return
+		}
+
+		var managerID *uuid.UUID
+		if req.ManagerID != "" {
+			parsed, err := uuid.Parse(req.ManagerID)
+			if err != nil || parsed == employeeID {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid manager ID", nil, nil))
+				return
+			}
+			managerID = &parsed
+		}
+
+		if err := lh.LeaveRepo.UpdateManager(employeeID, managerID); err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "employee not found", nil, nil))
+			case strings.Contains(err.Error(), "foreign key"):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "manager not found", nil, nil))
+			default:
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update manager", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "manager updated successfully", nil, nil))
+	}
+}
+
+func writeLeaveError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrLeaveTypeNotFound), errors.Is(err, service.ErrLeaveRequestNotFound):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, err.Error(), nil, nil))
+	case errors.Is(err, service.ErrInvalidLeaveDates), errors.Is(err, service.ErrNoLeaveWorkingDays),
+		errors.Is(err, service.ErrLeaveTooLong):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
+	case errors.Is(err, service.ErrNotLeaveApprover):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, err.Error(), nil, nil))
+	case errors.Is(err, service.ErrLeaveOverlap), errors.Is(err, service.ErrNoLeaveBalance),
+		errors.Is(err, service.ErrInsufficientLeaveBalance), errors.Is(err, service.ErrLeaveNotPending),
+		errors.Is(err, service.ErrLeaveNotCancellable), errors.Is(err, repository.ErrPeriodLocked):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, err.Error(), nil, nil))
+	default:
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, fallback, nil, nil))
+	}
+}
+
+func toLeaveRequestResponse(request *model.LeaveRequest) LeaveRequestResponse {
+	return LeaveRequestResponse{
+		ID:         request.ID,
+		UserID:     request.UserID,
+		LeaveType:  request.LeaveType,
+		StartDate:  request.StartDate.Format("2006-01-02"),
+		EndDate:    request.EndDate.Format("2006-01-02"),
+		Days:       request.Days,
+		Reason:     request.Reason,
+		Status:     request.Status,
+		ReviewedBy: request.ReviewedBy,
+		ReviewedAt: request.ReviewedAt,
+		ReviewNote: request.ReviewNote,
+		CreatedAt:  request.CreatedAt,
+	}
+}
+
+func toLeaveRequestResponses(requests []model.LeaveRequest) []LeaveRequestResponse {
+	resp := []LeaveRequestResponse{}
+	for i := range requests {
+		resp = append(resp, toLeaveRequestResponse(&requests[i]))
+	}
+	return resp
+}
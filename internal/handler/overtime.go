@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"payslip-generation-system/internal/helper"
+	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"payslip-generation-system/internal/service"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OvertimeReviewRequest struct {
+	OvertimeID string `json:"overtimeID"`
+	Approve    bool   `json:"approve"`
+	Note       string `json:"note"`
+}
+
+type OvertimeWithdrawRequest struct {
+	OvertimeID string `json:"overtimeID"`
+}
+
+type OvertimeResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"userId"`
+	Date       string     `json:"date"`
+	Hours      float64    `json:"hours"`
+	StartAt    *time.Time `json:"startAt"`
+	EndAt      *time.Time `json:"endAt"`
+	DayType    string     `json:"dayType"`
+	Status     string     `json:"status"`
+	ReviewedBy *uuid.UUID `json:"reviewedBy"`
+	ReviewedAt *time.Time `json:"reviewedAt"`
+	ReviewNote string     `json:"reviewNote"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type OvertimeHandler struct {
+	OvertimeRepo    repository.OvertimeRepository
+	OvertimeService service.OvertimeService
+}
+
+func NewOvertimeHandler(overtimeRepo repository.OvertimeRepository, overtimeService service.OvertimeService) *OvertimeHandler {
+	return &OvertimeHandler{OvertimeRepo: overtimeRepo, OvertimeService: overtimeService}
+}
+
+// GetOvertimesHandler lists the employee's own overtime, optionally of one status
+func (oh *OvertimeHandler) GetOvertimesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "", model.OvertimePending, model.OvertimeApproved, model.OvertimeRejected, model.OvertimeWithdrawn:
+		default:
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid status", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		overtimes, err := oh.OvertimeRepo.GetOvertimes(userID, status)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get overtimes", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get overtimes", toOvertimeResponses(overtimes), nil))
+	}
+}
+
+func (oh *OvertimeHandler) WithdrawOvertimeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req OvertimeWithdrawRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		overtimeID, err := uuid.Parse(req.OvertimeID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid overtime ID", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		overtime, err := oh.OvertimeService.WithdrawOvertime(overtimeID, userID)
+		if err != nil {
+			writeOvertimeError(w, err, "failed to withdraw overtime")
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "overtime withdrawn", toOvertimeResponse(overtime), nil))
+	}
+}
+
+// GetPendingOvertimesHandler lists the overtime waiting for review, every pending overtime
+// for an admin and the overtime of their employees for a manager
+func (oh *OvertimeHandler) GetPendingOvertimesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		var managerID *uuid.UUID
+		switch middleware.GetUserRole(r) {
+		case "admin":
+		case "employee":
+			parsed, err := uuid.Parse(middleware.GetUserID(r))
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+				return
+			}
+			managerID = &parsed
+		default:
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		overtimes, err := oh.OvertimeRepo.GetPendingOvertimes(managerID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get overtimes", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get overtimes", toOvertimeResponses(overtimes), nil))
+	}
+}
+
+func (oh *OvertimeHandler) ReviewOvertimeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		role := middleware.GetUserRole(r)
+		if role != "admin" && role != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req OvertimeReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		overtimeID, err := uuid.Parse(req.OvertimeID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid overtime ID", nil, nil))
+			return
+		}
+
+		// a rejection tells the employee why
+		note := strings.TrimSpace(req.Note)
+		if !req.Approve && note == "" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "note is required to reject overtime", nil, nil))
+			return
+		}
+
+		reviewerID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		overtime, err := oh.OvertimeService.ReviewOvertime(overtimeID, reviewerID, role, req.Approve, note)
+		if err != nil {
+			writeOvertimeError(w, err, "failed to review overtime")
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "overtime "+overtime.Status, toOvertimeResponse(overtime), nil))
+	}
+}
+
+func writeOvertimeError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrOvertimeNotFound):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, err.Error(), nil, nil))
+	case errors.Is(err, service.ErrNotOvertimeReviewer):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, err.Error(), nil, nil))
+	case errors.Is(err, service.ErrOvertimeNotPending), errors.Is(err, repository.ErrPeriodLocked):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, err.Error(), nil, nil))
+	default:
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, fallback, nil, nil))
+	}
+}
+
+func toOvertimeResponse(overtime *model.Overtime) OvertimeResponse {
+	return OvertimeResponse{
+		ID:         overtime.ID,
+		UserID:     overtime.UserID,
+		Date:       overtime.Date.Format("2006-01-02"),
+		Hours:      overtime.Hours,
+		StartAt:    overtime.StartAt,
+		EndAt:      overtime.EndAt,
+		DayType:    overtime.DayType,
+		Status:     overtime.Status,
+		ReviewedBy: overtime.ReviewedBy,
+		ReviewedAt: overtime.ReviewedAt,
+		ReviewNote: overtime.ReviewNote,
+		CreatedAt:  overtime.CreatedAt,
+	}
+}
+
+func toOvertimeResponses(overtimes []model.Overtime) []OvertimeResponse {
+	resp := []OvertimeResponse{}
+	for i := range overtimes {
+		resp = append(resp, toOvertimeResponse(&overtimes[i]))
+	}
+	return resp
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"payslip-generation-system/internal/helper"
+	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/service"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OvertimeRuleRequest struct {
+	FromHour   int     `json:"fromHour"`
+	ToHour     *int    `json:"toHour"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+type OvertimeRulesRequest struct {
+	DayType string                `json:"dayType"`
+	Rules   []OvertimeRuleRequest `json:"rules"`
+}
+
+type OvertimeRuleResponse struct {
+	DayType    string     `json:"dayType"`
+	FromHour   int        `json:"fromHour"`
+	ToHour     *int       `json:"toHour"`
+	Multiplier float64    `json:"multiplier"`
+	UpdatedBy  *uuid.UUID `json:"updatedBy"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+func (adh *AdminHandler) GetOvertimeRulesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		rules, err := adh.AdminRepo.GetOvertimeRules()
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get overtime rules", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get overtime rules", toOvertimeRuleResponses(rules), nil))
+	}
+}
+
+// UpdateOvertimeRulesHandler replaces the tiers of one day type, the new tiers apply to the
+// payroll runs from then on
+func (adh *AdminHandler) UpdateOvertimeRulesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req OvertimeRulesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		if !service.IsValidDayType(req.DayType) {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid day type", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		rules := []model.OvertimeRule{}
+		for _, rule := range req.Rules {
+			rules = append(rules, model.OvertimeRule{
+				ID:         uuid.New(),
+				DayType:    req.DayType,
+				FromHour:   rule.FromHour,
+				ToHour:     rule.ToHour,
+				Multiplier: rule.Multiplier,
+				UpdatedBy:  &userID,
+				UpdatedAt:  time.Now(),
+			})
+		}
+		sort.Slice(rules, func(i, j int) bool { return rules[i].FromHour < rules[j].FromHour })
+
+		if err := service.ValidateOvertimeRules(rules); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
+			return
+		}
+
+		if err := adh.AdminRepo.ReplaceOvertimeRules(req.DayType, rules); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update overtime rules", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "overtime rules updated successfully", toOvertimeRuleResponses(rules), nil))
+	}
+}
+
+func toOvertimeRuleResponses(rules []model.OvertimeRule) []OvertimeRuleResponse {
+	resp := []OvertimeRuleResponse{}
+	for _, rule := range rules {
+		resp = append(resp, OvertimeRuleResponse{
+			DayType:    rule.DayType,
+			FromHour:   rule.FromHour,
+			ToHour:     rule.ToHour,
+			Multiplier: rule.Multiplier,
+			UpdatedBy:  rule.UpdatedBy,
+			UpdatedAt:  rule.UpdatedAt,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"payslip-generation-system/internal/helper"
+	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"payslip-generation-system/internal/service"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PayslipTemplateRequest struct {
+	Body string `json:"body"`
+}
+
+type PayslipTemplateResponse struct {
+	Body      string     `json:"body"`
+	IsDefault bool       `json:"isDefault"`
+	UpdatedBy *uuid.UUID `json:"updatedBy"`
+	UpdatedAt *time.Time `json:"updatedAt"`
+}
+
+type PayslipDeliveryResponse struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"userId"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"lastError"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt"`
+	SentAt        *time.Time `json:"sentAt"`
+}
+
+type PayslipHandler struct {
+	PayslipRepo    repository.PayslipRepository
+	DeliveryRepo   repository.PayslipDeliveryRepository
+	PayslipService service.PayslipService
+}
+
+func NewPayslipHandler(payslipRepo repository.PayslipRepository, deliveryRepo repository.PayslipDeliveryRepository, payslipService service.PayslipService) *PayslipHandler {
+	return &PayslipHandler{PayslipRepo: payslipRepo, DeliveryRepo: deliveryRepo, PayslipService: payslipService}
+}
+
+// GetPayslipPDFHandler downloads the PDF payslip of the employee of a published payroll
+func (psh *PayslipHandler) GetPayslipPDFHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		payrollID, err := uuid.Parse(r.URL.Query().Get("payrollID"))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid payroll ID", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		file, err := psh.PayslipService.EmployeePayslip(userID, payrollID)
+		if err != nil {
+			writePayslipError(w, err, "failed to generate payslip")
+			return
+		}
+
+		writeFile(w, file)
+	}
+}
+
+// DownloadPayslipsHandler downloads the PDF payslips of every employee of a payroll as a zip
+func (psh *PayslipHandler) DownloadPayslipsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		payrollID, err := uuid.Parse(r.URL.Query().Get("payrollID"))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid payroll ID", nil, nil))
+			return
+		}
+
+		file, err := psh.PayslipService.PayrollPayslips(payrollID)
+		if err != nil {
+			writePayslipError(w, err, "failed to generate payslips")
+			return
+		}
+
+		writeFile(w, file)
+	}
+}
+
+// GetPayslipDeliveriesHandler lists the payslip emails of a payroll, ?status=failed shows
+// the payslips that could not be sent and why
+func (psh *PayslipHandler) GetPayslipDeliveriesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		payrollID, err := uuid.Parse(r.URL.Query().Get("payrollID"))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid payroll ID", nil, nil))
+			return
+		}
+
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "", model.DeliveryPending, model.DeliverySent, model.DeliveryFailed, model.DeliveryCancelled:
+		default:
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid status", nil, nil))
+			return
+		}
+
+		if _, err := psh.PayslipRepo.GetPayroll(payrollID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "payroll not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get payroll", nil, nil))
+			}
+			return
+		}
+
+		deliveries, err := psh.DeliveryRepo.GetDeliveries(payrollID, status)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get payslip deliveries", nil, nil))
+			return
+		}
+
+		resp := []PayslipDeliveryResponse{}
+		for _, d := range deliveries {
+			resp = append(resp, toPayslipDeliveryResponse(&d))
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get payslip deliveries", resp, nil))
+	}
+}
+
+// GetPayslipTemplateHandler returns the payslip template in use, the built-in one until HR saves their own
+func (psh *PayslipHandler) GetPayslipTemplateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		resp := PayslipTemplateResponse{Body: service.DefaultPayslipTemplate, IsDefault: true}
+		saved, err := psh.PayslipRepo.GetTemplate()
+		switch {
+		case err == nil:
+			resp = PayslipTemplateResponse{Body: saved.Body, UpdatedBy: saved.UpdatedBy, UpdatedAt: &saved.UpdatedAt}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get payslip template", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get payslip template", resp, nil))
+	}
+}
+
+// UpdatePayslipTemplateHandler saves the payslip template of HR after trying it on a sample
+// payslip, an empty body goes back to the built-in template
+func (psh *PayslipHandler) UpdatePayslipTemplateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req PayslipTemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		if strings.TrimSpace(req.Body) == "" {
+			if err := psh.PayslipRepo.DeleteTemplate(); err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to reset payslip template", nil, nil))
+				return
+			}
+			resp := PayslipTemplateResponse{Body: service.DefaultPayslipTemplate, IsDefault: true}
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "payslip template reset to the default", resp, nil))
+			return
+		}
+
+		if err := psh.PayslipService.ValidateTemplate(req.Body); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		template := model.PayslipTemplate{Body: req.Body, UpdatedBy: &userID, UpdatedAt: time.Now()}
+		if err := psh.PayslipRepo.SaveTemplate(&template); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update payslip template", nil, nil))
+			return
+		}
+
+		resp := PayslipTemplateResponse{Body: template.Body, UpdatedBy: template.UpdatedBy, UpdatedAt: &template.UpdatedAt}
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "payslip template updated successfully", resp, nil))
+	}
+}
+
+func writePayslipError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, service.ErrPayslipNotFound), errors.Is(err, service.ErrPayrollNotFound):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, err.Error(), nil, nil))
+	case errors.Is(err, service.ErrNoPayslipPassword):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, err.Error(), nil, nil))
+	default:
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, fallback, nil, nil))
+	}
+}
+
+func toPayslipDeliveryResponse(d *model.PayslipDeliveryStatus) PayslipDeliveryResponse {
+	resp := PayslipDeliveryResponse{
+		ID:        d.ID,
+		UserID:    d.UserID,
+		Username:  d.Username,
+		Email:     d.Email,
+		Status:    d.Status,
+		Attempts:  d.Attempts,
+		LastError: d.LastError,
+		SentAt:    d.SentAt,
+	}
+	// only a pending delivery has a next attempt
+	if d.Status == model.DeliveryPending {
+		resp.NextAttemptAt = &d.NextAttemptAt
+	}
+	return resp
+}
+
+// writeFile sends a rendered file as a download
+func writeFile(w http.ResponseWriter, file *service.PayslipFile) {
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(file.Content)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(file.Content)
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxReceipts       = 5
+	maxReceiptSize    = 5 << 20
+	maxClaimFormSize  = maxReceipts*maxReceiptSize + 1<<20
+	receiptFormField  = "receipts"
+	multipartFormType = "multipart/form-data"
+)
+
+// receiptExtensions are the receipt types accepted, by the content type sniffed from the file
+var receiptExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+type receipt struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+	Checksum    string
+}
+
+// decodeReimbursementRequest reads a claim sent either as JSON or as a multipart form with its
+// receipts in the receipts field
+func decodeReimbursementRequest(w http.ResponseWriter, r *http.Request) (ReimbursementRequest, []receipt, error) {
+	var req ReimbursementRequest
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), multipartFormType) {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return req, nil, errors.New("invalid request")
+		}
+		return req, nil, nil
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxClaimFormSize)
+	if err := r.ParseMultipartForm(maxClaimFormSize); err != nil {
+		return req, nil, errors.New("invalid request")
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	amount, err := strconv.Atoi(r.FormValue("amount"))
+	if err != nil {
+		return req, nil, errors.New("invalid amount")
+	}
+	req = ReimbursementRequest{
+		Category:    r.FormValue("category"),
+		Amount:      amount,
+		Description: r.FormValue("description"),
+		ExpenseDate: r.FormValue("expenseDate"),
+	}
+
+	receipts, err := readReceipts(r.MultipartForm.File[receiptFormField])
+	return req, receipts, err
+}
+
+func readReceipts(files []*multipart.FileHeader) ([]receipt, error) {
+	if len(files) > maxReceipts {
+		return nil, fmt.Errorf("at most %d receipts can be attached", maxReceipts)
+	}
+
+	receipts := []receipt{}
+	seen := map[string]bool{}
+	for _, fh := range files {
+		name := filepath.Base(fh.Filename)
+		if fh.Size > maxReceiptSize {
+			return nil, fmt.Errorf("receipt %s is larger than %d MB", name, maxReceiptSize>>20)
+		}
+
+		content, err := readReceipt(fh)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read receipt %s", name)
+		}
+		if len(content) == 0 {
+			return nil, fmt.Errorf("receipt %s is empty", name)
+		}
+
+		// the type is sniffed from the content, the header sent by the client is not trusted
+		contentType := http.DetectContentType(content)
+		if _, ok := receiptExtensions[contentType]; !ok {
+			return nil, fmt.Errorf("receipt %s must be a JPEG, PNG, WebP image or a PDF", name)
+		}
+
+		sum := sha256.Sum256(content)
+		checksum := hex.EncodeToString(sum[:])
+		if seen[checksum] {
+			return nil, fmt.Errorf("receipt %s is attached twice", name)
+		}
+		seen[checksum] = true
+
+		receipts = append(receipts, receipt{FileName: name, ContentType: contentType, Content: content, Checksum: checksum})
+	}
+	return receipts, nil
+}
+
+func readReceipt(fh *multipart.FileHeader) ([]byte, error) {
+	file, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, maxReceiptSize))
+}
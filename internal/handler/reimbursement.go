@@ -0,0 +1,487 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"payslip-generation-system/internal/helper"
+	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"payslip-generation-system/internal/service"
+	"payslip-generation-system/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReimbursementReviewRequest struct {
+	ReimbursementID string `json:"reimbursementID"`
+	Approve         bool   `json:"approve"`
+	ApprovedAmount  *int   `json:"approvedAmount"`
+	Note            string `json:"note"`
+}
+
+type ReimbursementLimitRequest struct {
+	Category       string `json:"category"`
+	Grade          string `json:"grade"`
+	PerClaimLimit  *int   `json:"perClaimLimit"`
+	PerPeriodLimit *int   `json:"perPeriodLimit"`
+}
+
+type EmployeeGradeRequest struct {
+	UserID string `json:"userID"`
+	Grade  string `json:"grade"`
+}
+
+type ReimbursementResponse struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"userId"`
+	Category       string     `json:"category"`
+	Amount         int        `json:"amount"`
+	Description    string     `json:"description"`
+	ExpenseDate    string     `json:"expenseDate"`
+	Status         string     `json:"status"`
+	ApprovedAmount *int       `json:"approvedAmount"`
+	ReviewedBy     *uuid.UUID `json:"reviewedBy"`
+	ReviewedAt     *time.Time `json:"reviewedAt"`
+	ReviewNote     string     `json:"reviewNote"`
+	PeriodID       *uuid.UUID `json:"periodId"`
+	PaidInPayroll  *uuid.UUID `json:"paidInPayrollId"`
+	CreatedAt      time.Time  `json:"createdAt"`
+
+	Attachments []ReimbursementAttachmentResponse `json:"attachments"`
+}
+
+type ReimbursementAttachmentResponse struct {
+	ID          uuid.UUID `json:"id"`
+	FileName    string    `json:"fileName"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type ReimbursementLimitResponse struct {
+	Category       string     `json:"category"`
+	Grade          string     `json:"grade"`
+	PerClaimLimit  *int       `json:"perClaimLimit"`
+	PerPeriodLimit *int       `json:"perPeriodLimit"`
+	UpdatedBy      *uuid.UUID `json:"updatedBy"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+type ReimbursementCategoryResponse struct {
+	Code   string                       `json:"code"`
+	Name   string                       `json:"name"`
+	Limits []ReimbursementLimitResponse `json:"limits"`
+}
+
+type ReimbursementHandler struct {
+	ReimbursementRepo    repository.ReimbursementRepository
+	ReimbursementService service.ReimbursementService
+	Storage              storage.Storage
+}
+
+func NewReimbursementHandler(reimbursementRepo repository.ReimbursementRepository, reimbursementService service.ReimbursementService) *ReimbursementHandler {
+	return &ReimbursementHandler{ReimbursementRepo: reimbursementRepo, ReimbursementService: reimbursementService, Storage: storage.LocalStorageFromEnv()}
+}
+
+// GetReimbursementsHandler lists the employee's own claims, optionally of one status
+func (rh *ReimbursementHandler) GetReimbursementsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "", model.ReimbursementPending, model.ReimbursementApproved, model.ReimbursementRejected:
+		default:
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid status", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		reimbursements, err := rh.ReimbursementRepo.GetReimbursements(userID, status)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get reimbursements", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get reimbursements", toReimbursementResponses(reimbursements), nil))
+	}
+}
+
+// GetPendingReimbursementsHandler lists the claims waiting for review, every pending claim
+// for an admin and the claims of their employees for a manager
+func (rh *ReimbursementHandler) GetPendingReimbursementsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		var managerID *uuid.UUID
+		switch middleware.GetUserRole(r) {
+		case "admin":
+		case "employee":
+			parsed, err := uuid.Parse(middleware.GetUserID(r))
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+				return
+			}
+			managerID = &parsed
+		default:
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		reimbursements, err := rh.ReimbursementRepo.GetPendingReimbursements(managerID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get reimbursements", nil, nil))
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get reimbursements", toReimbursementResponses(reimbursements), nil))
+	}
+}
+
+// ReviewReimbursementHandler approves a claim, in part when an approved amount below the
+// claimed amount is given, or rejects it
+func (rh *ReimbursementHandler) ReviewReimbursementHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		role := middleware.GetUserRole(r)
+		if role != "admin" && role != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req ReimbursementReviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		reimbursementID, err := uuid.Parse(req.ReimbursementID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid reimbursement ID", nil, nil))
+			return
+		}
+
+		// a rejection or a partial approval tells the employee why
+		note := strings.TrimSpace(req.Note)
+		if (!req.Approve || req.ApprovedAmount != nil) && note == "" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "note is required to reject or partly approve a reimbursement", nil, nil))
+			return
+		}
+
+		reviewerID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		reimbursement, err := rh.ReimbursementService.ReviewReimbursement(reimbursementID, reviewerID, role, req.Approve, req.ApprovedAmount, note)
+		if err != nil {
+			writeReimbursementError(w, err, "failed to review reimbursement")
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "reimbursement "+reimbursement.Status, toReimbursementResponse(reimbursement), nil))
+	}
+}
+
+// GetAttachmentHandler downloads a receipt, only the employee who claimed it, their manager
+// and admins can download it
+func (rh *ReimbursementHandler) GetAttachmentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		role := middleware.GetUserRole(r)
+		if role != "admin" && role != "employee" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		attachmentID, err := uuid.Parse(r.URL.Query().Get("id"))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid attachment ID", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		attachment, err := rh.ReimbursementRepo.GetAttachment(attachmentID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "attachment not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get attachment", nil, nil))
+			}
+			return
+		}
+
+		if role == "employee" {
+			owner, err := rh.ReimbursementRepo.GetReimbursementOwner(attachment.ReimbursementID)
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get reimbursement", nil, nil))
+				return
+			}
+			if owner.ID != userID && (owner.ManagerID == nil || *owner.ManagerID != userID) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+				return
+			}
+		}
+
+		file, err := rh.Storage.Get(attachment.StorageKey)
+		if err != nil {
+			if errors.Is(err, storage.ErrFileNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "receipt file not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to read receipt", nil, nil))
+			}
+			return
+		}
+		defer file.Close()
+
+		w.Header().Set("Content-Type", attachment.ContentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+		w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+		io.Copy(w, file)
+	}
+}
+
+// GetCategoriesHandler lists the reimbursement categories with the limits of each grade
+func (rh *ReimbursementHandler) GetCategoriesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		categories, err := rh.ReimbursementRepo.GetCategories()
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get reimbursement categories", nil, nil))
+			return
+		}
+		limits, err := rh.ReimbursementRepo.GetLimits()
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get reimbursement limits", nil, nil))
+			return
+		}
+
+		resp := []ReimbursementCategoryResponse{}
+		for _, category := range categories {
+			item := ReimbursementCategoryResponse{Code: category.Code, Name: category.Name, Limits: []ReimbursementLimitResponse{}}
+			for i := range limits {
+				if limits[i].Category == category.Code {
+					item.Limits = append(item.Limits, toReimbursementLimitResponse(&limits[i]))
+				}
+			}
+			resp = append(resp, item)
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get reimbursement categories", resp, nil))
+	}
+}
+
+// UpdateLimitHandler sets the limits of a category for a grade, a missing limit is unlimited
+func (rh *ReimbursementHandler) UpdateLimitHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req ReimbursementLimitRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		grade := strings.TrimSpace(req.Grade)
+		if grade == "" || (req.PerClaimLimit != nil && *req.PerClaimLimit <= 0) || (req.PerPeriodLimit != nil && *req.PerPeriodLimit <= 0) {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid grade or limit", nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		limit := model.ReimbursementLimit{
+			ID:             uuid.New(),
+			Category:       strings.ToUpper(strings.TrimSpace(req.Category)),
+			Grade:          grade,
+			PerClaimLimit:  req.PerClaimLimit,
+			PerPeriodLimit: req.PerPeriodLimit,
+			UpdatedBy:      &userID,
+			UpdatedAt:      time.Now(),
+		}
+
+		if err := rh.ReimbursementRepo.SaveLimit(&limit); err != nil {
+			if strings.Contains(err.Error(), "foreign key") {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "reimbursement category not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update reimbursement limit", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "reimbursement limit updated successfully", toReimbursementLimitResponse(&limit), nil))
+	}
+}
+
+func (rh *ReimbursementHandler) UpdateEmployeeGradeHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req EmployeeGradeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		employeeID, err := uuid.Parse(req.UserID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid user ID", nil, nil))
+			return
+		}
+
+		grade := strings.TrimSpace(req.Grade)
+		if grade == "" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "grade is required", nil, nil))
+			return
+		}
+
+		if err := rh.ReimbursementRepo.UpdateGrade(employeeID, grade); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "employee not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update grade", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "grade updated successfully", nil, nil))
+	}
+}
+
+func writeReimbursementError(w http.ResponseWriter, err error, fallback string) {
+	var limitErr *service.ReimbursementLimitError
+	switch {
+	case errors.Is(err, service.ErrReimbursementNotFound):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, err.Error(), nil, nil))
+	case errors.Is(err, service.ErrNotReimbursementReviewer):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, err.Error(), nil, nil))
+	case errors.Is(err, service.ErrInvalidApprovedAmount), errors.Is(err, service.ErrCategoryNotAllowed), errors.As(err, &limitErr):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
+	case errors.Is(err, service.ErrReimbursementNotPending):
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, err.Error(), nil, nil))
+	default:
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, fallback, nil, nil))
+	}
+}
+
+func toReimbursementResponse(reimbursement *model.Reimbursement) ReimbursementResponse {
+	return ReimbursementResponse{
+		ID:             reimbursement.ID,
+		UserID:         reimbursement.UserID,
+		Category:       reimbursement.Category,
+		Amount:         reimbursement.Amount,
+		Description:    reimbursement.Description,
+		ExpenseDate:    reimbursement.ExpenseDate.Format("2006-01-02"),
+		Status:         reimbursement.Status,
+		ApprovedAmount: reimbursement.ApprovedAmount,
+		ReviewedBy:     reimbursement.ReviewedBy,
+		ReviewedAt:     reimbursement.ReviewedAt,
+		ReviewNote:     reimbursement.ReviewNote,
+		PeriodID:       reimbursement.PeriodID,
+		PaidInPayroll:  reimbursement.PaidInPayrollID,
+		CreatedAt:      reimbursement.CreatedAt,
+		Attachments:    toReimbursementAttachmentResponses(reimbursement.Attachments),
+	}
+}
+
+func toReimbursementAttachmentResponses(attachments []model.ReimbursementAttachment) []ReimbursementAttachmentResponse {
+	resp := []ReimbursementAttachmentResponse{}
+	for _, attachment := range attachments {
+		resp = append(resp, ReimbursementAttachmentResponse{
+			ID:          attachment.ID,
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+			CreatedAt:   attachment.CreatedAt,
+		})
+	}
+	return resp
+}
+
+func toReimbursementResponses(reimbursements []model.Reimbursement) []ReimbursementResponse {
+	resp := []ReimbursementResponse{}
+	for i := range reimbursements {
+		resp = append(resp, toReimbursementResponse(&reimbursements[i]))
+	}
+	return resp
+}
+
+func toReimbursementLimitResponse(limit *model.ReimbursementLimit) ReimbursementLimitResponse {
+	return ReimbursementLimitResponse{
+		Category:       limit.Category,
+		Grade:          limit.Grade,
+		PerClaimLimit:  limit.PerClaimLimit,
+		PerPeriodLimit: limit.PerPeriodLimit,
+		UpdatedBy:      limit.UpdatedBy,
+		UpdatedAt:      limit.UpdatedAt,
+	}
+}
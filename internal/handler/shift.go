@@ -0,0 +1,444 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"payslip-generation-system/internal/helper"
+	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"payslip-generation-system/internal/service"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// the longest date range a roster can be assigned over at once
+const maxRosterDays = 366
+
+type ShiftRequest struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	StartTime    string `json:"startTime"`
+	EndTime      string `json:"endTime"`
+	BreakMinutes int    `json:"breakMinutes"`
+}
+
+type ShiftResponse struct {
+	ID              uuid.UUID `json:"id"`
+	Name            string    `json:"name"`
+	StartTime       string    `json:"startTime"`
+	EndTime         string    `json:"endTime"`
+	BreakMinutes    int       `json:"breakMinutes"`
+	CrossesMidnight bool      `json:"crossesMidnight"`
+}
+
+type RosterRequest struct {
+	UserID    string `json:"userID"`
+	ShiftID   string `json:"shiftID"`
+	StartDate string `json:"startDate"`
+	EndDate   string `json:"endDate"`
+}
+
+type RosterResponse struct {
+	ID        uuid.UUID     `json:"id"`
+	UserID    uuid.UUID     `json:"userId"`
+	Date      string        `json:"date"`
+	Shift     ShiftResponse `json:"shift"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+type ShiftHandler struct {
+	ShiftRepo repository.ShiftRepository
+}
+
+func NewShiftHandler(shiftRepo repository.ShiftRepository) *ShiftHandler {
+	return &ShiftHandler{ShiftRepo: shiftRepo}
+}
+
+func (sh *ShiftHandler) CreateShiftHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req ShiftRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		if err := validateShiftRequest(req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		shift := model.Shift{
+			ID:           uuid.New(),
+			Name:         strings.TrimSpace(req.Name),
+			StartTime:    req.StartTime,
+			EndTime:      req.EndTime,
+			BreakMinutes: req.BreakMinutes,
+			CreatedBy:    userID,
+			RequestIP:    r.RemoteAddr,
+			CreatedAt:    time.Now(),
+			UpdatedAt:    time.Now(),
+		}
+
+		if err := sh.ShiftRepo.SaveShift(&shift); err != nil {
+			if strings.Contains(err.Error(), "duplicate key") {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "shift name already exists", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to create shift", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "shift created successfully", toShiftResponse(&shift), nil))
+	}
+}
+
+func (sh *ShiftHandler) GetShiftsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		shifts, err := sh.ShiftRepo.GetShifts()
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get shifts", nil, nil))
+			return
+		}
+
+		resp := []ShiftResponse{}
+		for i := range shifts {
+			resp = append(resp, toShiftResponse(&shifts[i]))
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get shifts", resp, nil))
+	}
+}
+
+func (sh *ShiftHandler) UpdateShiftHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req ShiftRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		id, err := uuid.Parse(req.ID)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid shift ID", nil, nil))
+			return
+		}
+
+		if err := validateShiftRequest(req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
+			return
+		}
+
+		shift, err := sh.ShiftRepo.GetShift(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "shift not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get shift", nil, nil))
+			}
+			return
+		}
+
+		shift.Name = strings.TrimSpace(req.Name)
+		shift.StartTime = req.StartTime
+		shift.EndTime = req.EndTime
+		shift.BreakMinutes = req.BreakMinutes
+		shift.RequestIP = r.RemoteAddr
+		shift.UpdatedAt = time.Now()
+
+		if err := sh.ShiftRepo.UpdateShift(shift); err != nil {
+			if strings.Contains(err.Error(), "duplicate key") {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "shift name already exists", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to update shift", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "shift updated successfully", toShiftResponse(shift), nil))
+	}
+}
+
+func (sh *ShiftHandler) DeleteShiftHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		id, err := uuid.Parse(r.URL.Query().Get("id"))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid shift ID", nil, nil))
+			return
+		}
+
+		if err := sh.ShiftRepo.DeleteShift(id); err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "shift not found", nil, nil))
+			case strings.Contains(err.Error(), "foreign key"):
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusConflict, "shift is used by a roster", nil, nil))
+			default:
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to delete shift", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "shift deleted successfully", nil, nil))
+	}
+}
+
+// AssignRosterHandler rosters an employee on a shift for every date of the range,
+// replacing the shift already rostered on a date
+func (sh *ShiftHandler) AssignRosterHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		var req RosterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid request", nil, nil))
+			return
+		}
+
+		employeeID, err1 := uuid.Parse(req.UserID)
+		shiftID, err2 := uuid.Parse(req.ShiftID)
+		if err1 != nil || err2 != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid user or shift ID", nil, nil))
+			return
+		}
+
+		startDate, err1 := time.Parse("2006-01-02", req.StartDate)
+		endDate, err2 := time.Parse("2006-01-02", req.EndDate)
+		if err1 != nil || err2 != nil || endDate.Before(startDate) || endDate.Sub(startDate) >= maxRosterDays*24*time.Hour {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid date range", nil, nil))
+			return
+		}
+
+		shift, err := sh.ShiftRepo.GetShift(shiftID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "shift not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get shift", nil, nil))
+			}
+			return
+		}
+
+		userID, err := uuid.Parse(middleware.GetUserID(r))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+			return
+		}
+
+		rosters := []model.Roster{}
+		for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
+			rosters = append(rosters, model.Roster{
+				ID:        uuid.New(),
+				UserID:    employeeID,
+				Date:      date,
+				ShiftID:   shift.ID,
+				CreatedBy: userID,
+				RequestIP: r.RemoteAddr,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			})
+		}
+
+		if err := sh.ShiftRepo.SaveRosters(rosters); err != nil {
+			if strings.Contains(err.Error(), "foreign key") {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "employee not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to assign roster", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusCreated, "roster assigned successfully", nil, nil))
+	}
+}
+
+func (sh *ShiftHandler) GetRostersHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		// employees only see their own roster, admins of every employee unless one is asked
+		userID := uuid.Nil
+		switch middleware.GetUserRole(r) {
+		case "admin":
+			if v := r.URL.Query().Get("userID"); v != "" {
+				parsed, err := uuid.Parse(v)
+				if err != nil {
+					json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid user ID", nil, nil))
+					return
+				}
+				userID = parsed
+			}
+		case "employee":
+			parsed, err := uuid.Parse(middleware.GetUserID(r))
+			if err != nil {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "invalid user ID", nil, nil))
+				return
+			}
+			userID = parsed
+		default:
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		start, end, err := dateRange(r)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, err.Error(), nil, nil))
+			return
+		}
+
+		rosters, err := sh.ShiftRepo.GetRosters(userID, start, end)
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to get rosters", nil, nil))
+			return
+		}
+
+		resp := []RosterResponse{}
+		for _, roster := range rosters {
+			item := RosterResponse{
+				ID:        roster.ID,
+				UserID:    roster.UserID,
+				Date:      roster.Date.Format("2006-01-02"),
+				CreatedAt: roster.CreatedAt,
+			}
+			if roster.Shift != nil {
+				item.Shift = toShiftResponse(roster.Shift)
+			}
+			resp = append(resp, item)
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "success get rosters", resp, nil))
+	}
+}
+
+func (sh *ShiftHandler) DeleteRosterHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusMethodNotAllowed, "method not allowed", nil, nil))
+			return
+		}
+
+		if middleware.GetUserRole(r) != "admin" {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusForbidden, "forbidden", nil, nil))
+			return
+		}
+
+		id, err := uuid.Parse(r.URL.Query().Get("id"))
+		if err != nil {
+			json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusBadRequest, "invalid roster ID", nil, nil))
+			return
+		}
+
+		if err := sh.ShiftRepo.DeleteRoster(id); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusNotFound, "roster not found", nil, nil))
+			} else {
+				json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusInternalServerError, "failed to delete roster", nil, nil))
+			}
+			return
+		}
+
+		json.NewEncoder(w).Encode(helper.WriteJSONResponse(w, http.StatusOK, "roster deleted successfully", nil, nil))
+	}
+}
+
+// validateShiftRequest checks the name, the times of day, and that the break fits in the shift
+func validateShiftRequest(req ShiftRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return errors.New("name is required")
+	}
+
+	schedule, err := service.WorkScheduleFromShift(&model.Shift{StartTime: req.StartTime, EndTime: req.EndTime})
+	if err != nil {
+		return errors.New("invalid start or end time")
+	}
+
+	length := schedule.End - schedule.Start
+	if schedule.CrossesMidnight() {
+		length += 24 * time.Hour
+	}
+	if req.BreakMinutes < 0 || time.Duration(req.BreakMinutes)*time.Minute >= length {
+		return errors.New("invalid break minutes")
+	}
+	return nil
+}
+
+func toShiftResponse(shift *model.Shift) ShiftResponse {
+	resp := ShiftResponse{
+		ID:           shift.ID,
+		Name:         shift.Name,
+		StartTime:    shift.StartTime,
+		EndTime:      shift.EndTime,
+		BreakMinutes: shift.BreakMinutes,
+	}
+	if schedule, err := service.WorkScheduleFromShift(shift); err == nil {
+		resp.StartTime = formatTimeOfDay(schedule.Start)
+		resp.EndTime = formatTimeOfDay(schedule.End)
+		resp.CrossesMidnight = schedule.CrossesMidnight()
+	}
+	return resp
+}
+
+func formatTimeOfDay(offset time.Duration) string {
+	return time.Time{}.Add(offset).Format("15:04")
+}
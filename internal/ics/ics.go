@@ -0,0 +1,170 @@
+// Package ics reads and writes the all-day events of an iCalendar (RFC 5545) file
+package ics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type Event struct {
+	UID      string
+	Date     time.Time
+	Summary  string
+	Category string
+}
+
+// Parse reads the VEVENTs of a calendar, an event spanning several days is returned once per day
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []Event{}
+	var current *Event
+	var end time.Time
+	for _, line := range lines {
+		name, params, value := splitLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Event{}
+			end = time.Time{}
+		case name == "END" && value == "VEVENT":
+			if current == nil || current.Date.IsZero() {
+				return nil, errors.New("event without DTSTART")
+			}
+			events = append(events, *current)
+			// DTEND of an all-day event is exclusive
+			for d := current.Date.AddDate(0, 0, 1); d.Before(end); d = d.AddDate(0, 0, 1) {
+				day := *current
+				day.Date = d
+				events = append(events, day)
+			}
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.UID = value
+		case name == "SUMMARY":
+			current.Summary = unescape(value)
+		case name == "CATEGORIES":
+			current.Category = strings.ToLower(unescape(strings.Split(value, ",")[0]))
+		case name == "DTSTART":
+			current.Date, err = parseDate(value, params)
+			if err != nil {
+				return nil, err
+			}
+		case name == "DTEND":
+			end, err = parseDate(value, params)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return events, nil
+}
+
+// Write renders the events as all-day VEVENTs of a VCALENDAR
+func Write(w io.Writer, prodID string, events []Event) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + prodID,
+		"CALSCALE:GREGORIAN",
+	}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, e := range events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+e.UID,
+			"DTSTAMP:"+stamp,
+			"DTSTART;VALUE=DATE:"+e.Date.Format("20060102"),
+			"DTEND;VALUE=DATE:"+e.Date.AddDate(0, 0, 1).Format("20060102"),
+			"SUMMARY:"+escape(e.Summary),
+		)
+		if e.Category != "" {
+			lines = append(lines, "CATEGORIES:"+escape(e.Category))
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, fold(line)+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unfold joins the continuation lines, which start with a space or a tab, to the previous line
+func unfold(r io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// fold splits a content line longer than 75 octets into continuation lines
+func fold(line string) string {
+	var b strings.Builder
+	for len(line) > 75 {
+		cut := 75
+		// do not split a multi-byte character
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+func splitLine(line string) (name, params, value string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return line, "", ""
+	}
+	name, value = line[:colon], line[colon+1:]
+	if semicolon := strings.Index(name, ";"); semicolon >= 0 {
+		name, params = name[:semicolon], name[semicolon+1:]
+	}
+	return strings.ToUpper(name), strings.ToUpper(params), value
+}
+
+func parseDate(value, params string) (time.Time, error) {
+	if strings.Contains(params, "VALUE=DATE") || len(value) == 8 {
+		return time.Parse("20060102", value)
+	}
+	t, err := time.Parse("20060102T150405", strings.TrimSuffix(value, "Z"))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+var unescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+func unescape(s string) string {
+	return unescaper.Replace(s)
+}
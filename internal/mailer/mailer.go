@@ -0,0 +1,161 @@
+// Package mailer sends email, the SMTP mailer works with any SMTP server including a local
+// sink such as MailHog during development
+package mailer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"time"
+)
+
+const (
+	defaultSMTPHost = "localhost"
+	defaultSMTPPort = "1025"
+	defaultSMTPFrom = "payroll@localhost"
+)
+
+var ErrInvalidAddress = errors.New("invalid email address")
+
+// Message is a plain text email with attachments
+type Message struct {
+	To          string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+// Mailer sends a message or returns why it could not be sent
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// SMTPMailer sends through an SMTP server, authenticating when a username is set
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{Host: host, Port: port, Username: username, Password: password, From: from}
+}
+
+// SMTPMailerFromEnv reads SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM,
+// falling back to the MailHog defaults of localhost:1025 without authentication
+func SMTPMailerFromEnv() Mailer {
+	return NewSMTPMailer(
+		envOr("SMTP_HOST", defaultSMTPHost),
+		envOr("SMTP_PORT", defaultSMTPPort),
+		os.Getenv("SMTP_USERNAME"),
+		os.Getenv("SMTP_PASSWORD"),
+		envOr("SMTP_FROM", defaultSMTPFrom),
+	)
+}
+
+func (sm *SMTPMailer) Send(msg *Message) error {
+	from, err := mail.ParseAddress(sm.From)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAddress, sm.From)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAddress, msg.To)
+	}
+
+	content, err := Compose(from, to, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if sm.Username != "" {
+		auth = smtp.PlainAuth("", sm.Username, sm.Password, sm.Host)
+	}
+	return smtp.SendMail(net.JoinHostPort(sm.Host, sm.Port), auth, from.Address, []string{to.Address}, content)
+}
+
+// Compose writes the message as a multipart MIME email, the attachments base64 encoded
+func Compose(from, to *mail.Address, msg *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprint(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qw := quotedprintable.NewWriter(part)
+	if _, err := qw.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err := qw.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range msg.Attachments {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(attachment.ContentType, map[string]string{"name": attachment.Name})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(part, attachment.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64 wraps the encoded content at 76 characters, the longest line email allows
+func writeBase64(w io.Writer, content []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(w, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := fmt.Fprintf(w, "%s\r\n", encoded)
+	return err
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
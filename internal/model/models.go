@@ -11,15 +11,42 @@ type User struct {
 	Username     string    `gorm:"uniqueIndex;not null"`
 	PasswordHash string    `gorm:"not null"`
 	Role         string    `gorm:"type:text;not null"`
-	Salary       int       `gorm:"not null"`
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	PTKPStatus   string    `gorm:"column:ptkp_status;not null;default:TK/0"`
+	NPWP         string    `gorm:"column:npwp"`
+	JKKRiskClass int       `gorm:"column:jkk_risk_class;not null;default:1"`
+	ManagerID    *uuid.UUID
+	Grade        string `gorm:"not null;default:staff"`
+	// the payslip PDFs are encrypted with a password derived from these, see
+	// CompanySettings.PayslipPasswordScheme
+	BirthDate      *time.Time `gorm:"type:date"`
+	EmployeeNumber string
+	PayslipPIN     string `gorm:"column:payslip_pin"`
+	// the published payslips are emailed to this address
+	Email     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// SalaryHistory is a salary of an employee in force from EffectiveFrom
+// until the next salary change of the same employee
+type SalaryHistory struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID        uuid.UUID
+	Salary        int
+	EffectiveFrom time.Time `gorm:"type:date"`
+	Reason        string
+	CreatedBy     uuid.UUID
+	RequestIP     string
+	CreatedAt     time.Time
 }
 
 type AttendancePeriod struct {
 	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
 	StartDate time.Time `gorm:"type:date"`
 	EndDate   time.Time `gorm:"type:date"`
+	// set when the payroll of the period is run, employees can no longer submit for its dates
+	LockedAt  *time.Time
+	LockedBy  *uuid.UUID
 	CreatedBy uuid.UUID
 	RequestIP string
 	CreatedAt time.Time
@@ -27,56 +54,364 @@ type AttendancePeriod struct {
 }
 
 type Attendance struct {
+	ID                uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID            uuid.UUID
+	Date              time.Time `gorm:"type:date"`
+	CheckInAt         *time.Time
+	CheckOutAt        *time.Time
+	WorkedMinutes     int
+	LateMinutes       int
+	EarlyLeaveMinutes int
+	CreatedBy         uuid.UUID
+	RequestIP         string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// Shift is a working schedule, StartTime and EndTime are times of day formatted 15:04:05
+type Shift struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Name         string
+	StartTime    string `gorm:"type:time"`
+	EndTime      string `gorm:"type:time"`
+	BreakMinutes int
+	CreatedBy    uuid.UUID
+	RequestIP    string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Roster assigns an employee to a shift on a date
+type Roster struct {
 	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
 	UserID    uuid.UUID
 	Date      time.Time `gorm:"type:date"`
+	ShiftID   uuid.UUID
+	Shift     *Shift `gorm:"foreignKey:ShiftID"`
 	CreatedBy uuid.UUID
 	RequestIP string
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
-type Overtime struct {
+const (
+	LeaveAnnual    = "ANNUAL"
+	LeaveSick      = "SICK"
+	LeaveMaternity = "MATERNITY"
+	LeaveUnpaid    = "UNPAID"
+)
+
+type LeaveType struct {
+	Code              string `gorm:"primaryKey"`
+	Name              string
+	Paid              bool
+	Accrues           bool
+	AnnualEntitlement int
+	MaxCarryOver      int
+	MaxDaysPerRequest *int
+}
+
+// LeaveBalance is the days of an accruing leave type an employee can take in a year
+type LeaveBalance struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID      uuid.UUID
+	LeaveType   string
+	Year        int
+	Entitled    int
+	CarriedOver int
+	Used        int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (b LeaveBalance) Remaining() int {
+	return b.Entitled + b.CarriedOver - b.Used
+}
+
+const (
+	LeavePending   = "pending"
+	LeaveApproved  = "approved"
+	LeaveRejected  = "rejected"
+	LeaveCancelled = "cancelled"
+)
+
+type LeaveRequest struct {
 	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
 	UserID    uuid.UUID
-	Date      time.Time `gorm:"type:date"`
-	Hours     int
-	CreatedBy uuid.UUID
-	RequestIP string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	LeaveType string
+	Type      *LeaveType `gorm:"foreignKey:LeaveType;references:Code"`
+	StartDate time.Time  `gorm:"type:date"`
+	EndDate   time.Time  `gorm:"type:date"`
+	Days      int
+	// the days after new year of a leave over the year end, taken from the next year's balance
+	NextYearDays int
+	Reason       string
+	Status       string
+	ReviewedBy   *uuid.UUID
+	ReviewedAt   *time.Time
+	ReviewNote   string
+	CreatedBy    uuid.UUID
+	RequestIP    string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
+const (
+	OvertimePending   = "pending"
+	OvertimeApproved  = "approved"
+	OvertimeRejected  = "rejected"
+	OvertimeWithdrawn = "withdrawn"
+)
+
+const (
+	DayTypeWorkday       = "workday"
+	DayTypeRestDay       = "rest_day"
+	DayTypePublicHoliday = "public_holiday"
+)
+
+// OvertimeRule pays the overtime hours of a day above FromHour up to ToHour at the
+// multiplier of the hourly rate, the last tier of a day type has no ToHour
+type OvertimeRule struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	DayType    string
+	FromHour   int
+	ToHour     *int
+	Multiplier float64
+	UpdatedBy  *uuid.UUID
+	UpdatedAt  time.Time
+}
+
+type Overtime struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	UserID     uuid.UUID
+	Date       time.Time `gorm:"type:date"`
+	Hours      float64
+	StartAt    *time.Time
+	EndAt      *time.Time
+	DayType    string
+	Status     string `gorm:"default:pending"`
+	ReviewedBy *uuid.UUID
+	ReviewedAt *time.Time
+	ReviewNote string
+	CreatedBy  uuid.UUID
+	RequestIP  string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type ReimbursementCategory struct {
+	Code string `gorm:"primaryKey"`
+	Name string
+}
+
+// ReimbursementLimit caps the claims of a category for the employees of a grade,
+// a nil limit is unlimited
+type ReimbursementLimit struct {
+	ID             uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Category       string
+	Grade          string
+	PerClaimLimit  *int
+	PerPeriodLimit *int
+	UpdatedBy      *uuid.UUID
+	UpdatedAt      time.Time
+}
+
+const (
+	ReimbursementPending  = "pending"
+	ReimbursementApproved = "approved"
+	ReimbursementRejected = "rejected"
+)
+
 type Reimbursement struct {
 	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
 	UserID      uuid.UUID
+	Category    string
 	Amount      int
 	Description string
-	CreatedBy   uuid.UUID
-	RequestIP   string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ExpenseDate time.Time
+	Status      string `gorm:"default:pending"`
+	// the part of the amount paid back, set when the claim is approved
+	ApprovedAmount *int
+	ReviewedBy     *uuid.UUID
+	ReviewedAt     *time.Time
+	ReviewNote     string
+	// the period the claim is paid in, set on approval and moved to the period of the
+	// payroll that paid it when an unpaid claim rolls over
+	PeriodID        *uuid.UUID
+	PaidInPayrollID *uuid.UUID
+	CreatedBy       uuid.UUID
+	RequestIP       string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Attachments     []ReimbursementAttachment
+}
+
+// ReimbursementAttachment is a receipt of a claim, the file is kept in the storage under
+// the storage key and its SHA-256 checksum keeps a receipt from being claimed twice while
+// it is active, a rejected claim releases its receipts
+type ReimbursementAttachment struct {
+	ID              uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ReimbursementID uuid.UUID
+	FileName        string
+	ContentType     string
+	Size            int64
+	Checksum        string
+	StorageKey      string
+	Active          bool `gorm:"default:true"`
+	CreatedAt       time.Time
 }
 
 type Payroll struct {
+	ID                  uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	PeriodID            uuid.UUID
+	Status              string
+	ProrationBasis      string
+	WorkingDays         int
+	ProrationDivisor    float64
+	HoursPerDay         float64
+	ApprovedBy          *uuid.UUID
+	ApprovedAt          *time.Time
+	PublishedAt         *time.Time
+	PaidAt              *time.Time
+	VoidReason          string
+	VoidedBy            *uuid.UUID
+	VoidedAt            *time.Time
+	SupersedesPayrollID *uuid.UUID
+	CreatedBy           uuid.UUID
+	RequestIP           string
+	CreatedAt           time.Time
+}
+
+const (
+	PayrollDraft      = "draft"
+	PayrollCalculated = "calculated"
+	PayrollApproved   = "approved"
+	PayrollPublished  = "published"
+	PayrollPaid       = "paid"
+	PayrollVoided     = "voided"
+)
+
+const (
+	ProrationWorkingDays  = "working_days"
+	ProrationCalendarDays = "calendar_days"
+	ProrationFixed        = "fixed"
+)
+
+// the payslip password is the birth date as ddmmyyyy followed by the employee number, or
+// the PIN the employee set
+const (
+	PayslipPasswordBirthdate = "birthdate_employee_number"
+	PayslipPasswordPIN       = "pin"
+)
+
+// CompanySettings holds the single row of company wide payroll configuration
+type CompanySettings struct {
+	ID             int `gorm:"primaryKey"`
+	HoursPerDay    float64
+	ProrationBasis string
+	FixedDivisor   float64
+	// the monthly salary is divided by this number of hours for the overtime hourly rate
+	OvertimeDivisor float64
+	// the most overtime hours an employee can submit for a day and for a week from monday
+	MaxOvertimeHoursPerDay  float64
+	MaxOvertimeHoursPerWeek float64
+	// deduct the minutes an employee checked in late beyond the grace minutes of each day
+	LatenessDeduction    bool
+	LatenessGraceMinutes int
+	// the company header of the payslips
+	CompanyName    string
+	CompanyAddress string
+	CompanyNPWP    string `gorm:"column:company_npwp"`
+	// what the password of the payslip PDFs is derived from
+	PayslipPasswordScheme string
+	UpdatedBy             *uuid.UUID
+	UpdatedAt             time.Time
+}
+
+func (CompanySettings) TableName() string {
+	return "company_settings"
+}
+
+const (
+	HolidayNational    = "national"
+	HolidayCutiBersama = "cuti_bersama"
+	HolidayCompany     = "company"
+)
+
+type Holiday struct {
 	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	PeriodID  uuid.UUID
+	Date      time.Time `gorm:"type:date"`
+	Name      string
+	Category  string
 	CreatedBy uuid.UUID
 	RequestIP string
 	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 type Payslip struct {
-	ID                 uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	PayrollID          uuid.UUID
-	UserID             uuid.UUID
-	BaseSalary         int
-	AttendanceDays     int
-	ProratedSalary     int
-	OvertimeHours      int
-	OvertimePay        int
-	ReimbursementTotal int
-	TakeHomePay        int
+	ID              uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	PayrollID       uuid.UUID
+	UserID          uuid.UUID
+	BaseSalary      int
+	AttendanceDays  int
+	PaidLeaveDays   int
+	UnpaidLeaveDays int
+	OvertimeHours   float64
+	GrossPay        int
+	TaxableIncome   int
+	TaxAmount       int
+	TotalDeductions int
+	NetPay          int
+	EmployerCost    int
+	// the payslip of the voided payroll of the same period this payslip replaces
+	SupersedesPayslipID *uuid.UUID
+	Items               []PayslipItem `gorm:"foreignKey:PayslipID"`
+}
+
+const (
+	PayslipItemEarning      = "earning"
+	PayslipItemDeduction    = "deduction"
+	PayslipItemEmployerCost = "employer_cost"
+)
+
+type PayslipItem struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	PayslipID uuid.UUID
+	Code      string
+	Name      string
+	Type      string
+	Quantity  float64
+	Amount    int
+	Taxable   bool
+	Sequence  int
+}
+
+const (
+	BPJSKesehatan = "KESEHATAN"
+	BPJSJHT       = "JHT"
+	BPJSJP        = "JP"
+	BPJSJKK       = "JKK"
+	BPJSJKM       = "JKM"
+)
+
+// BPJSRate is a version of the contribution rates (in percent) of a BPJS program,
+// in force from EffectiveFrom until a newer version of the same program replaces it
+type BPJSRate struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Program       string
+	RiskClass     *int
+	EmployerRate  float64
+	EmployeeRate  float64
+	SalaryCap     *int
+	EffectiveFrom time.Time `gorm:"type:date"`
+	CreatedBy     uuid.UUID
+	RequestIP     string
+	CreatedAt     time.Time
+}
+
+func (BPJSRate) TableName() string {
+	return "bpjs_rates"
 }
 
 type AuditLog struct {
@@ -87,11 +422,89 @@ type AuditLog struct {
 	PerformedBy uuid.UUID
 	RequestIP   string
 	RequestID   string
+	Reason      string
 	Timestamp   time.Time
 }
 
 type EmployeePayslipSummary struct {
-	UserID      uuid.UUID
-	Username    string
-	TakeHomePay int
+	UserID       uuid.UUID
+	Username     string
+	GrossPay     int
+	TaxAmount    int
+	NetPay       int
+	EmployerCost int
+}
+
+// PayslipTemplate is the payslip layout customised by HR
+type PayslipTemplate struct {
+	ID        int `gorm:"primaryKey"`
+	Body      string
+	UpdatedBy *uuid.UUID
+	UpdatedAt time.Time
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySent      = "sent"
+	DeliveryFailed    = "failed"
+	DeliveryCancelled = "cancelled"
+)
+
+// PayslipDelivery is the email of a payslip to its employee, queued when the payroll is
+// published and retried until it is sent or runs out of attempts
+type PayslipDelivery struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	PayrollID     uuid.UUID
+	UserID        uuid.UUID
+	Email         string
+	Status        string `gorm:"default:pending"`
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	SentAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// PayslipDeliveryStatus is a delivery with the employee it goes to
+type PayslipDeliveryStatus struct {
+	PayslipDelivery
+	Username string
+}
+
+// PayslipYearToDate sums the payslips of an employee released in the tax year up to and
+// including one payslip
+type PayslipYearToDate struct {
+	UserID          uuid.UUID
+	GrossPay        int
+	TaxAmount       int
+	TotalDeductions int
+	NetPay          int
+}
+
+// PayslipHistory is a released payslip of an employee with its period and the totals of the
+// tax year up to and including it
+type PayslipHistory struct {
+	UserID             uuid.UUID
+	PayrollID          uuid.UUID
+	PeriodID           uuid.UUID
+	StartDate          time.Time
+	EndDate            time.Time
+	PayrollStatus      string
+	PublishedAt        *time.Time
+	GrossPay           int
+	TaxAmount          int
+	TotalDeductions    int
+	NetPay             int
+	YTDGrossPay        int `gorm:"column:ytd_gross_pay"`
+	YTDTaxAmount       int `gorm:"column:ytd_tax_amount"`
+	YTDTotalDeductions int `gorm:"column:ytd_total_deductions"`
+	YTDNetPay          int `gorm:"column:ytd_net_pay"`
+}
+
+type TaxYearToDate struct {
+	UserID              uuid.UUID
+	TaxableIncome       int
+	TaxAmount           int
+	PensionContribution int
 }
@@ -0,0 +1,35 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// permissions allows printing and copying text but not changing the document
+const permissions = model.PermissionsPrint | model.PermissionExtract | model.PermissionExtractRev3
+
+func init() {
+	// the configuration is built here, pdfcpu must not read or create its config directory
+	api.DisableConfigDir()
+}
+
+// encrypt writes the rendered document encrypted with a 256 bit AES key, the standard security
+// handler revision 6. Without an owner password nobody can lift the restrictions
+func encrypt(plain []byte, w io.Writer, userPassword, ownerPassword string) error {
+	if ownerPassword == "" {
+		random := make([]byte, 16)
+		if _, err := rand.Read(random); err != nil {
+			return err
+		}
+		ownerPassword = fmt.Sprintf("%x", random)
+	}
+
+	conf := model.NewAESConfiguration(userPassword, ownerPassword, 256)
+	conf.Permissions = permissions
+	return api.Encrypt(bytes.NewReader(plain), w, conf)
+}
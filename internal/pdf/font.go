@@ -0,0 +1,59 @@
+package pdf
+
+// widths of the printable ASCII characters from the space to the tilde in thousandths of the
+// font size, from the Adobe font metrics of the standard fonts
+var widths = [2][95]int{
+	Regular: {
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	Bold: {
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// the characters of WinAnsiEncoding outside of Latin-1
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '™': 0x99,
+}
+
+// encode converts the text to WinAnsiEncoding, characters the fonts don't have become a question mark
+func encode(text string) []byte {
+	b := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 32 && r < 127, r >= 160 && r < 256:
+			b = append(b, byte(r))
+		case winAnsi[r] != 0:
+			b = append(b, winAnsi[r])
+		case r == '\t':
+			b = append(b, ' ')
+		default:
+			b = append(b, '?')
+		}
+	}
+	return b
+}
+
+// TextWidth measures the text in points, characters outside of ASCII are measured as a digit
+func TextWidth(text string, font Font, size float64) float64 {
+	total := 0
+	for _, c := range encode(text) {
+		if c >= 32 && c < 127 {
+			total += widths[font][c-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
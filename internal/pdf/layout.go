@@ -0,0 +1,150 @@
+package pdf
+
+import (
+	"strings"
+)
+
+const (
+	margin      = 50.0
+	columnWidth = 110.0
+)
+
+// Layout flows the lines of a simple markup onto A4 pages, starting a new page when one is full:
+//
+//	# Title                 a title
+//	## Heading              a section heading underlined by a rule
+//	| label | value         a row, the first cell on the left and the others aligned right in columns
+//	|* label | value        a row in bold
+//	---                     a rule
+//	~ note                  a paragraph in small gray print
+//	(empty line)            some space
+//	any other line          a paragraph wrapped to the width of the page
+func Layout(markup string) *Document {
+	l := &layout{doc: New()}
+	l.newPage()
+	for _, line := range strings.Split(strings.ReplaceAll(markup, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			l.space(8)
+		case strings.HasPrefix(trimmed, "## "):
+			l.heading(strings.TrimSpace(trimmed[3:]))
+		case strings.HasPrefix(trimmed, "# "):
+			l.title(strings.TrimSpace(trimmed[2:]))
+		case trimmed == "---":
+			l.rule()
+		case strings.HasPrefix(trimmed, "|*"):
+			l.row(cells(trimmed[2:]), Bold)
+		case strings.HasPrefix(trimmed, "|"):
+			l.row(cells(trimmed[1:]), Regular)
+		case strings.HasPrefix(trimmed, "~ "):
+			l.paragraph(strings.TrimSpace(trimmed[2:]), Regular, 8, 11, 0.4)
+		default:
+			l.paragraph(trimmed, Regular, 10, 14, 0)
+		}
+	}
+	return l.doc
+}
+
+type layout struct {
+	doc *Document
+	y   float64
+}
+
+func (l *layout) newPage() {
+	l.doc.AddPage()
+	l.y = PageHeight - margin
+}
+
+// advance moves down by the height of a line, on a new page when the line doesn't fit
+func (l *layout) advance(height float64) {
+	if l.y-height < margin {
+		l.newPage()
+	}
+	l.y -= height
+}
+
+func (l *layout) space(height float64) {
+	if l.y-height >= margin {
+		l.y -= height
+	}
+}
+
+func (l *layout) title(text string) {
+	l.advance(22)
+	l.doc.Text(margin, l.y, Bold, 16, text)
+	l.y -= 6
+}
+
+func (l *layout) heading(text string) {
+	l.advance(24)
+	l.doc.Text(margin, l.y, Bold, 11, text)
+	l.y -= 5
+	l.doc.Line(margin, l.y, PageWidth-margin, l.y, 0.5, 0.6)
+	l.y -= 4
+}
+
+func (l *layout) rule() {
+	l.advance(8)
+	l.doc.Line(margin, l.y+4, PageWidth-margin, l.y+4, 0.75, 0.3)
+}
+
+// row writes the first cell on the left and right aligns the others, the last one on the right
+// margin and each one before it a column further left
+func (l *layout) row(cells []string, font Font) {
+	const size = 10
+	l.advance(15)
+	right := PageWidth - margin
+	for i, cell := range cells {
+		if cell == "" {
+			continue
+		}
+		if i == 0 {
+			l.doc.Text(margin, l.y, font, size, cell)
+			continue
+		}
+		edge := right - float64(len(cells)-1-i)*columnWidth
+		l.doc.Text(edge-TextWidth(cell, font, size), l.y, font, size, cell)
+	}
+}
+
+func (l *layout) paragraph(text string, font Font, size, height, gray float64) {
+	for _, line := range wrap(text, font, size, PageWidth-2*margin) {
+		l.advance(height)
+		if gray > 0 {
+			l.doc.TextGray(margin, l.y, font, size, gray, line)
+		} else {
+			l.doc.Text(margin, l.y, font, size, line)
+		}
+	}
+}
+
+func cells(row string) []string {
+	parts := strings.Split(row, "|")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// wrap breaks the text between words into lines no wider than the width
+func wrap(text string, font Font, size, width float64) []string {
+	lines := []string{}
+	current := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if current != "" && TextWidth(candidate, font, size) > width {
+			lines = append(lines, current)
+			current = word
+			continue
+		}
+		current = candidate
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
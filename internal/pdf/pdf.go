@@ -0,0 +1,222 @@
+// Package pdf writes text documents as PDF 1.4 files with the standard Helvetica fonts, which
+// every PDF reader has, so no font is embedded
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// A4 in points
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+)
+
+// Document is a PDF built page by page, coordinates are in points from the bottom left corner
+type Document struct {
+	Title     string
+	Author    string
+	CreatedAt time.Time
+	pages     []*bytes.Buffer
+
+	userPassword  string
+	ownerPassword string
+	encrypted     bool
+}
+
+func New() *Document {
+	return &Document{CreatedAt: time.Now()}
+}
+
+// AddPage starts a new page, the next drawing goes on it
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text writes the text with its baseline starting at x, y
+func (d *Document) Text(x, y float64, font Font, size float64, text string) {
+	fmt.Fprintf(d.page(), "BT /F%d %s Tf %s %s Td %s Tj ET\n", font+1, num(size), num(x), num(y), literal(encode(text)))
+}
+
+// TextGray writes the text like Text in a shade of gray, 0 is black and 1 white
+func (d *Document) TextGray(x, y float64, font Font, size, gray float64, text string) {
+	fmt.Fprintf(d.page(), "%s g ", num(gray))
+	d.Text(x, y, font, size, text)
+	fmt.Fprint(d.page(), "0 g\n")
+}
+
+// Line strokes a line of the width in a shade of gray
+func (d *Document) Line(x1, y1, x2, y2, width, gray float64) {
+	fmt.Fprintf(d.page(), "%s G %s w %s %s m %s %s l S 0 G\n", num(gray), num(width), num(x1), num(y1), num(x2), num(y2))
+}
+
+// Rect fills a rectangle with its bottom left corner at x, y in a shade of gray
+func (d *Document) Rect(x, y, width, height, gray float64) {
+	fmt.Fprintf(d.page(), "%s g %s %s %s %s re f 0 g\n", num(gray), num(x), num(y), num(width), num(height))
+}
+
+// Encrypt protects the document with a password: readers ask for the user password before
+// showing it, and only the owner password lifts the restriction on changing it. An empty owner
+// password is replaced with a random one
+func (d *Document) Encrypt(userPassword, ownerPassword string) {
+	d.userPassword = userPassword
+	d.ownerPassword = ownerPassword
+	d.encrypted = true
+}
+
+// Bytes renders the document
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Write renders the document: the catalog, the page tree, the two fonts, the document info
+// and a page and its content stream for each page, followed by the cross-reference table. An
+// encrypted document is rendered first and then encrypted as a whole
+func (d *Document) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	const (
+		catalogObj = 1
+		pagesObj   = 2
+		regularObj = 3
+		boldObj    = 4
+		infoObj    = 5
+		firstPage  = 6
+	)
+
+	// AES-256 is revision 6 of the security handler, which belongs to PDF 2.0
+	version := "1.4"
+	if d.encrypted {
+		version = "2.0"
+	}
+
+	pw := &writer{}
+	pw.buf.WriteString("%PDF-" + version + "\n%\xe2\xe3\xcf\xd3\n")
+
+	pw.object(catalogObj, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+
+	kids := &bytes.Buffer{}
+	for i := range d.pages {
+		fmt.Fprintf(kids, "%d 0 R ", firstPage+2*i)
+	}
+	pw.object(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", bytes.TrimSpace(kids.Bytes()), len(d.pages)))
+	pw.object(regularObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	pw.object(boldObj, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	pw.object(infoObj, fmt.Sprintf("<< /Title %s /Author %s /Producer (payslip-generation-system) /CreationDate (D:%s) >>",
+		literal(encode(d.Title)), literal(encode(d.Author)), d.CreatedAt.UTC().Format("20060102150405Z")))
+
+	for i, content := range d.pages {
+		pageObj := firstPage + 2*i
+		pw.object(pageObj, fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> /Contents %d 0 R >>",
+			pagesObj, num(PageWidth), num(PageHeight), regularObj, boldObj, pageObj+1))
+		if err := pw.stream(pageObj+1, content.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	id := md5.Sum([]byte(fmt.Sprintf("%s|%s|%d", d.Title, d.CreatedAt.Format(time.RFC3339Nano), len(d.pages))))
+	pw.trailer(catalogObj, infoObj, fmt.Sprintf("%x", id))
+
+	if d.encrypted {
+		return encrypt(pw.buf.Bytes(), w, d.userPassword, d.ownerPassword)
+	}
+	_, err := w.Write(pw.buf.Bytes())
+	return err
+}
+
+// writer keeps the byte offset of every object for the cross-reference table
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (pw *writer) begin(n int) {
+	for len(pw.offsets) < n {
+		pw.offsets = append(pw.offsets, 0)
+	}
+	pw.offsets[n-1] = pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "%d 0 obj\n", n)
+}
+
+func (pw *writer) object(n int, body string) {
+	pw.begin(n)
+	fmt.Fprintf(&pw.buf, "%s\nendobj\n", body)
+}
+
+func (pw *writer) stream(n int, data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	pw.begin(n)
+	fmt.Fprintf(&pw.buf, "<< /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+	pw.buf.Write(compressed.Bytes())
+	pw.buf.WriteString("\nendstream\nendobj\n")
+	return nil
+}
+
+func (pw *writer) trailer(root, info int, id string) {
+	xref := pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for _, offset := range pw.offsets {
+		fmt.Fprintf(&pw.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pw.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R /ID [<%s> <%s>] >>\nstartxref\n%d\n%%%%EOF\n",
+		len(pw.offsets)+1, root, info, id, id, xref)
+}
+
+// num formats a coordinate with at most two decimals
+func num(v float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".")
+}
+
+// literal writes the bytes as a PDF string, escaping the delimiters and control characters
+func literal(b []byte) string {
+	var buf bytes.Buffer
+	buf.WriteByte('(')
+	for _, c := range b {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c < 32:
+			fmt.Fprintf(&buf, "\\%03o", c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
@@ -1,15 +1,40 @@
 package repository
 
 import (
+	"errors"
 	"payslip-generation-system/internal/model"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrSalaryAlreadyPaid = errors.New("salary change falls in a period already processed")
+
 type AdminRepository interface {
 	SaveAttendancePeriod(attendancePeriod *model.AttendancePeriod) error
+	GetAttendancePeriods() ([]model.AttendancePeriod, error)
+	GetAttendancePeriod(id uuid.UUID) (*model.AttendancePeriod, error)
+	GetAdjacentAttendancePeriods(period *model.AttendancePeriod) (previous, next *model.AttendancePeriod, err error)
+	UpdateAttendancePeriod(attendancePeriod *model.AttendancePeriod) error
+	DeleteAttendancePeriod(id uuid.UUID) error
+	HasPayroll(periodID uuid.UUID) (bool, error)
+	GetPeriodsWithPayroll() (map[uuid.UUID]bool, error)
+	GetAttendances(userID uuid.UUID, start, end time.Time) ([]model.Attendance, error)
 	GetPayslipSummary(payrollID uuid.UUID) ([]model.EmployeePayslipSummary, error)
+	UpdateTaxProfile(userID uuid.UUID, ptkpStatus, npwp string) error
+	UpdateJKKRiskClass(userID uuid.UUID, riskClass int) error
+	UpdatePersonalProfile(userID uuid.UUID, birthDate time.Time, employeeNumber, email string) error
+	SaveBPJSRate(rate *model.BPJSRate) error
+	GetBPJSRates() ([]model.BPJSRate, error)
+	IsEmployee(userID uuid.UUID) (bool, error)
+	SaveSalaryChange(salary *model.SalaryHistory) error
+	GetSalaryHistory(userID uuid.UUID) ([]model.SalaryHistory, error)
+	GetCompanySettings() (*model.CompanySettings, error)
+	UpdateCompanySettings(settings *model.CompanySettings) error
+	GetOvertimeRules() ([]model.OvertimeRule, error)
+	ReplaceOvertimeRules(dayType string, rules []model.OvertimeRule) error
 }
 
 type AdminRepositoryImpl struct {
@@ -24,13 +49,235 @@ func (ar *AdminRepositoryImpl) SaveAttendancePeriod(attendancePeriod *model.Atte
 	return ar.db.Create(&attendancePeriod).Error
 }
 
+func (ar *AdminRepositoryImpl) GetAttendancePeriods() ([]model.AttendancePeriod, error) {
+	var result []model.AttendancePeriod
+	err := ar.db.Order("start_date").Find(&result).Error
+	return result, err
+}
+
+func (ar *AdminRepositoryImpl) GetAttendancePeriod(id uuid.UUID) (*model.AttendancePeriod, error) {
+	var period model.AttendancePeriod
+	if err := ar.db.Where("id = ?", id).First(&period).Error; err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// GetAdjacentAttendancePeriods returns the closest periods before and after the given one, nil when there is none
+func (ar *AdminRepositoryImpl) GetAdjacentAttendancePeriods(period *model.AttendancePeriod) (*model.AttendancePeriod, *model.AttendancePeriod, error) {
+	var previous, next []model.AttendancePeriod
+	err := ar.db.
+		Where("end_date < ? AND id <> ?", period.StartDate, period.ID).
+		Order("end_date DESC").Limit(1).
+		Find(&previous).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	err = ar.db.
+		Where("start_date > ? AND id <> ?", period.EndDate, period.ID).
+		Order("start_date").Limit(1).
+		Find(&next).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var prev, nxt *model.AttendancePeriod
+	if len(previous) > 0 {
+		prev = &previous[0]
+	}
+	if len(next) > 0 {
+		nxt = &next[0]
+	}
+	return prev, nxt, nil
+}
+
+func (ar *AdminRepositoryImpl) UpdateAttendancePeriod(attendancePeriod *model.AttendancePeriod) error {
+	return ar.db.Save(&attendancePeriod).Error
+}
+
+func (ar *AdminRepositoryImpl) DeleteAttendancePeriod(id uuid.UUID) error {
+	result := ar.db.Where("id = ?", id).Delete(&model.AttendancePeriod{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// HasPayroll checks if any payroll was run for the period, voided ones included
+func (ar *AdminRepositoryImpl) HasPayroll(periodID uuid.UUID) (bool, error) {
+	var count int64
+	err := ar.db.Model(&model.Payroll{}).Where("period_id = ?", periodID).Count(&count).Error
+	return count > 0, err
+}
+
+// GetPeriodsWithPayroll returns the IDs of the periods any payroll was run for, voided ones included
+func (ar *AdminRepositoryImpl) GetPeriodsWithPayroll() (map[uuid.UUID]bool, error) {
+	var periodIDs []uuid.UUID
+	if err := ar.db.Model(&model.Payroll{}).Distinct("period_id").Pluck("period_id", &periodIDs).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[uuid.UUID]bool, len(periodIDs))
+	for _, id := range periodIDs {
+		result[id] = true
+	}
+	return result, nil
+}
+
+// GetAttendances returns the attendances between the dates, of every employee when the user ID is nil
+func (ar *AdminRepositoryImpl) GetAttendances(userID uuid.UUID, start, end time.Time) ([]model.Attendance, error) {
+	var result []model.Attendance
+	query := ar.db.Where("date BETWEEN ? AND ?", start, end)
+	if userID != uuid.Nil {
+		query = query.Where("user_id = ?", userID)
+	}
+	err := query.Order("date, user_id").Find(&result).Error
+	return result, err
+}
+
 func (ar *AdminRepositoryImpl) GetPayslipSummary(payrollID uuid.UUID) ([]model.EmployeePayslipSummary, error) {
 	var results []model.EmployeePayslipSummary
 	err := ar.db.Raw(`
-		SELECT p.user_id, u.username, p.take_home_pay
+		SELECT p.user_id, u.username, p.gross_pay, p.tax_amount, p.net_pay, p.employer_cost
 		FROM payslips p
 		JOIN users u ON p.user_id = u.id
 		WHERE p.payroll_id = ?`, payrollID).Scan(&results).Error
 
 	return results, err
 }
+
+func (ar *AdminRepositoryImpl) UpdateTaxProfile(userID uuid.UUID, ptkpStatus, npwp string) error {
+	result := ar.db.Model(&model.User{}).
+		Where("id = ? AND role = ?", userID, "employee").
+		Updates(map[string]interface{}{
+			"ptkp_status": ptkpStatus,
+			"npwp":        npwp,
+			"updated_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdatePersonalProfile keeps the email of the employee when none is given
+func (ar *AdminRepositoryImpl) UpdatePersonalProfile(userID uuid.UUID, birthDate time.Time, employeeNumber, email string) error {
+	updates := map[string]interface{}{
+		"birth_date":      birthDate,
+		"employee_number": employeeNumber,
+		"updated_at":      time.Now(),
+	}
+	if email != "" {
+		updates["email"] = email
+	}
+	result := ar.db.Model(&model.User{}).
+		Where("id = ? AND role = ?", userID, "employee").
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (ar *AdminRepositoryImpl) UpdateJKKRiskClass(userID uuid.UUID, riskClass int) error {
+	result := ar.db.Model(&model.User{}).
+		Where("id = ? AND role = ?", userID, "employee").
+		Updates(map[string]interface{}{
+			"jkk_risk_class": riskClass,
+			"updated_at":     time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (ar *AdminRepositoryImpl) SaveBPJSRate(rate *model.BPJSRate) error {
+	return ar.db.Create(&rate).Error
+}
+
+func (ar *AdminRepositoryImpl) GetBPJSRates() ([]model.BPJSRate, error) {
+	var rates []model.BPJSRate
+	err := ar.db.Order("program, risk_class, effective_from DESC").Find(&rates).Error
+	return rates, err
+}
+
+func (ar *AdminRepositoryImpl) IsEmployee(userID uuid.UUID) (bool, error) {
+	var count int64
+	err := ar.db.Model(&model.User{}).Where("id = ? AND role = ?", userID, "employee").Count(&count).Error
+	return count > 0, err
+}
+
+// SaveSalaryChange saves the salary unless a payroll was run for a period ending on or after
+// its effective date, salaries already paid out must not change retroactively. The periods are
+// share locked like the employee submissions, so a payroll run locking them waits for the save
+// or the other way around
+func (ar *AdminRepositoryImpl) SaveSalaryChange(salary *model.SalaryHistory) error {
+	return ar.db.Transaction(func(tx *gorm.DB) error {
+		var periods []model.AttendancePeriod
+		err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+			Where("end_date >= ?::date", salary.EffectiveFrom).
+			Find(&periods).Error
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		err = tx.Model(&model.Payroll{}).
+			Joins("JOIN attendance_periods p ON p.id = payrolls.period_id").
+			Where("p.end_date >= ?::date AND payrolls.status <> ?", salary.EffectiveFrom, model.PayrollVoided).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrSalaryAlreadyPaid
+		}
+		return tx.Create(&salary).Error
+	})
+}
+
+func (ar *AdminRepositoryImpl) GetSalaryHistory(userID uuid.UUID) ([]model.SalaryHistory, error) {
+	var result []model.SalaryHistory
+	err := ar.db.Where("user_id = ?", userID).Order("effective_from").Find(&result).Error
+	return result, err
+}
+
+func (ar *AdminRepositoryImpl) GetCompanySettings() (*model.CompanySettings, error) {
+	var settings model.CompanySettings
+	if err := ar.db.First(&settings).Error; err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (ar *AdminRepositoryImpl) UpdateCompanySettings(settings *model.CompanySettings) error {
+	return ar.db.Save(&settings).Error
+}
+
+func (ar *AdminRepositoryImpl) GetOvertimeRules() ([]model.OvertimeRule, error) {
+	var result []model.OvertimeRule
+	err := ar.db.Order("day_type, from_hour").Find(&result).Error
+	return result, err
+}
+
+// ReplaceOvertimeRules swaps the tiers of the day type for the given ones in one transaction
+func (ar *AdminRepositoryImpl) ReplaceOvertimeRules(dayType string, rules []model.OvertimeRule) error {
+	return ar.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("day_type = ?", dayType).Delete(&model.OvertimeRule{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&rules).Error
+	})
+}
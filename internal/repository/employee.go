@@ -1,17 +1,36 @@
 package repository
 
 import (
+	"errors"
 	"payslip-generation-system/internal/model"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrPeriodLocked = errors.New("attendance period is locked")
+
 type EmployeeRepository interface {
 	SaveAttendance(attendance *model.Attendance) error
-	SaveOvertime(overtime *model.Overtime) error
+	GetOpenAttendance(userID uuid.UUID, since time.Time) (*model.Attendance, error)
+	GetAttendances(userID uuid.UUID, start, end time.Time) ([]model.Attendance, error)
+	UpdateAttendance(attendance *model.Attendance) error
+	SaveOvertime(overtime *model.Overtime, start, end time.Time, check func(submitted []model.Overtime) error) error
+	GetCompanySettings() (*model.CompanySettings, error)
 	SaveReimbursement(reimbursement *model.Reimbursement) error
+	IsReceiptClaimed(checksums []string) (bool, error)
+	GetReimbursementLimit(userID uuid.UUID, category string) (*model.ReimbursementLimit, error)
+	GetClaimPeriod(date time.Time) (time.Time, time.Time, error)
+	GetClaimedAmount(userID uuid.UUID, category string, start, end time.Time) (int, error)
 	GetPayslip(userID, payrollID uuid.UUID) (*model.Payslip, error)
+	GetPayslipHistory(userID uuid.UUID, offset, limit int) ([]model.PayslipHistory, int64, error)
+	GetPayslipYearToDate(userID, payrollID uuid.UUID) (*model.PayslipHistory, error)
+	UpdatePayslipPIN(userID uuid.UUID, pin string) error
+	IsHoliday(date time.Time) (bool, error)
+	GetRoster(userID uuid.UUID, date time.Time) (*model.Roster, error)
+	IsShiftWorker(userID uuid.UUID, date time.Time) (bool, error)
 }
 
 type EmployeeRepositoryImpl struct {
@@ -23,21 +42,217 @@ func NewEmployeeRepository(db *gorm.DB) EmployeeRepository {
 }
 
 func (er *EmployeeRepositoryImpl) SaveAttendance(attendance *model.Attendance) error {
-	return er.db.Create(&attendance).Error
+	return er.writeUnlocked(attendance.Date, func(tx *gorm.DB) error {
+		return tx.Create(&attendance).Error
+	})
+}
+
+// GetOpenAttendance returns the latest attendance since the date the employee has not checked out of
+func (er *EmployeeRepositoryImpl) GetOpenAttendance(userID uuid.UUID, since time.Time) (*model.Attendance, error) {
+	var attendance model.Attendance
+	err := er.db.
+		Where("user_id = ? AND date >= ? AND check_out_at IS NULL", userID, since).
+		Order("date DESC").
+		First(&attendance).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attendance, nil
+}
+
+func (er *EmployeeRepositoryImpl) GetAttendances(userID uuid.UUID, start, end time.Time) ([]model.Attendance, error) {
+	var result []model.Attendance
+	err := er.db.Where("user_id = ? AND date BETWEEN ? AND ?", userID, start, end).Order("date").Find(&result).Error
+	return result, err
+}
+
+func (er *EmployeeRepositoryImpl) UpdateAttendance(attendance *model.Attendance) error {
+	return er.writeUnlocked(attendance.Date, func(tx *gorm.DB) error {
+		return tx.Save(&attendance).Error
+	})
+}
+
+// SaveOvertime saves the overtime once check accepts it against the overtime the employee
+// submitted from start to end. The employee's row is locked first, so the submissions of one
+// employee are checked and saved one after the other
+func (er *EmployeeRepositoryImpl) SaveOvertime(overtime *model.Overtime, start, end time.Time, check func(submitted []model.Overtime) error) error {
+	return er.writeUnlocked(overtime.Date, func(tx *gorm.DB) error {
+		var user model.User
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", overtime.UserID).First(&user).Error
+		if err != nil {
+			return err
+		}
+
+		var submitted []model.Overtime
+		err = tx.Where("user_id = ? AND date BETWEEN ? AND ?", overtime.UserID, start, end).Order("date").Find(&submitted).Error
+		if err != nil {
+			return err
+		}
+		if err := check(submitted); err != nil {
+			return err
+		}
+		return tx.Create(overtime).Error
+	})
 }
 
-func (er *EmployeeRepositoryImpl) SaveOvertime(overtime *model.Overtime) error {
-	return er.db.Create(&overtime).Error
+func (er *EmployeeRepositoryImpl) GetCompanySettings() (*model.CompanySettings, error) {
+	var settings model.CompanySettings
+	if err := er.db.First(&settings).Error; err != nil {
+		return nil, err
+	}
+	return &settings, nil
 }
 
+// SaveReimbursement saves the claim with its receipts. Unlike attendance and overtime it isn't
+// refused for the date of a locked period, the claim is only assigned a period on approval and
+// that is always an open one, so it can't change a payroll already run
 func (er *EmployeeRepositoryImpl) SaveReimbursement(reimbursement *model.Reimbursement) error {
 	return er.db.Create(&reimbursement).Error
 }
 
+// IsReceiptClaimed reports whether a receipt with one of the checksums is attached to a claim
+// already, the receipts of rejected claims can be claimed again
+func (er *EmployeeRepositoryImpl) IsReceiptClaimed(checksums []string) (bool, error) {
+	var count int64
+	err := er.db.Table("reimbursement_attachments a").
+		Joins("JOIN reimbursements r ON r.id = a.reimbursement_id").
+		Where("a.checksum IN ? AND r.status <> ?", checksums, model.ReimbursementRejected).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (er *EmployeeRepositoryImpl) GetReimbursementLimit(userID uuid.UUID, category string) (*model.ReimbursementLimit, error) {
+	return getReimbursementLimit(er.db, userID, category)
+}
+
+func (er *EmployeeRepositoryImpl) GetClaimPeriod(date time.Time) (time.Time, time.Time, error) {
+	return getClaimPeriod(er.db, date)
+}
+
+// GetClaimedAmount sums the pending and approved claims of the category between the dates
+func (er *EmployeeRepositoryImpl) GetClaimedAmount(userID uuid.UUID, category string, start, end time.Time) (int, error) {
+	return getClaimedAmount(er.db, userID, category, start, end, false, uuid.Nil)
+}
+
+// writeUnlocked runs the write unless the date falls in a locked attendance period, the period
+// rows are share locked so a payroll run locking them waits for the write or the other way around
+func (er *EmployeeRepositoryImpl) writeUnlocked(date time.Time, write func(tx *gorm.DB) error) error {
+	return er.db.Transaction(func(tx *gorm.DB) error {
+		var periods []model.AttendancePeriod
+		err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+			Where("?::date BETWEEN start_date AND end_date", date).
+			Find(&periods).Error
+		if err != nil {
+			return err
+		}
+		for _, p := range periods {
+			if p.LockedAt != nil {
+				return ErrPeriodLocked
+			}
+		}
+		return write(tx)
+	})
+}
+
+// isPeriodLocked checks if a locked attendance period overlaps the dates
+func isPeriodLocked(db *gorm.DB, start, end time.Time) (bool, error) {
+	var count int64
+	err := db.Model(&model.AttendancePeriod{}).
+		Where("start_date <= ?::date AND end_date >= ?::date AND locked_at IS NOT NULL", end, start).
+		Count(&count).Error
+	return count > 0, err
+}
+
 func (er *EmployeeRepositoryImpl) GetPayslip(userID, payrollID uuid.UUID) (*model.Payslip, error) {
 	var result model.Payslip
-	if err := er.db.Where("payroll_id = ? AND user_id = ?", payrollID, userID).Find(&result).Error; err != nil {
+	// employees only see the payslips of payrolls released to them
+	err := er.db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("sequence") }).
+		Joins("JOIN payrolls r ON r.id = payslips.payroll_id").
+		Where("payslips.payroll_id = ? AND payslips.user_id = ?", payrollID, userID).
+		Where("r.status IN ?", []string{model.PayrollPublished, model.PayrollPaid}).
+		First(&result).Error
+	if err != nil {
 		return nil, err
 	}
 	return &result, nil
 }
+
+// GetPayslipHistory returns a page of the employee's released payslips, the latest period
+// first, and how many there are
+func (er *EmployeeRepositoryImpl) GetPayslipHistory(userID uuid.UUID, offset, limit int) ([]model.PayslipHistory, int64, error) {
+	released := []string{model.PayrollPublished, model.PayrollPaid}
+
+	var total int64
+	err := er.db.Table("payslips s").
+		Joins("JOIN payrolls r ON s.payroll_id = r.id").
+		Where("s.user_id = ? AND r.status IN ?", userID, released).
+		Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var history []model.PayslipHistory
+	err = er.db.Raw(`SELECT * FROM (`+payslipYearToDate+`) h ORDER BY h.end_date DESC LIMIT ? OFFSET ?`,
+		[]uuid.UUID{userID}, released, uuid.Nil, limit, offset).Scan(&history).Error
+	return history, total, err
+}
+
+// GetPayslipYearToDate returns the released payslip of the payroll with the totals of the tax
+// year up to and including it
+func (er *EmployeeRepositoryImpl) GetPayslipYearToDate(userID, payrollID uuid.UUID) (*model.PayslipHistory, error) {
+	var history model.PayslipHistory
+	result := er.db.Raw(`SELECT * FROM (`+payslipYearToDate+`) h WHERE h.payroll_id = ?`,
+		[]uuid.UUID{userID}, []string{model.PayrollPublished, model.PayrollPaid}, uuid.Nil, payrollID).Scan(&history)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &history, nil
+}
+
+func (er *EmployeeRepositoryImpl) UpdatePayslipPIN(userID uuid.UUID, pin string) error {
+	result := er.db.Model(&model.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"payslip_pin": pin,
+			"updated_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (er *EmployeeRepositoryImpl) IsHoliday(date time.Time) (bool, error) {
+	var count int64
+	err := er.db.Model(&model.Holiday{}).Where("date = ?", date).Count(&count).Error
+	return count > 0, err
+}
+
+// GetRoster returns the shift the employee is rostered on for the date
+func (er *EmployeeRepositoryImpl) GetRoster(userID uuid.UUID, date time.Time) (*model.Roster, error) {
+	var roster model.Roster
+	if err := er.db.Preload("Shift").Where("user_id = ? AND date = ?", userID, date).First(&roster).Error; err != nil {
+		return nil, err
+	}
+	return &roster, nil
+}
+
+// IsShiftWorker reports whether the employee is rostered on any date of the attendance period of
+// the date, or of its month when no period covers it, a shift worker is off on the dates without
+// a shift
+func (er *EmployeeRepositoryImpl) IsShiftWorker(userID uuid.UUID, date time.Time) (bool, error) {
+	start, end, err := getClaimPeriod(er.db, date)
+	if err != nil {
+		return false, err
+	}
+	var count int64
+	err = er.db.Model(&model.Roster{}).Where("user_id = ? AND date BETWEEN ? AND ?", userID, start, end).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"payslip-generation-system/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type HolidayRepository interface {
+	SaveHoliday(holiday *model.Holiday) error
+	GetHoliday(id uuid.UUID) (*model.Holiday, error)
+	GetHolidays(start, end time.Time) ([]model.Holiday, error)
+	UpdateHoliday(holiday *model.Holiday) error
+	DeleteHoliday(id uuid.UUID) error
+	ImportHolidays(holidays []model.Holiday) error
+}
+
+type HolidayRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewHolidayRepository(db *gorm.DB) HolidayRepository {
+	return &HolidayRepositoryImpl{db: db}
+}
+
+func (hr *HolidayRepositoryImpl) SaveHoliday(holiday *model.Holiday) error {
+	return hr.db.Create(&holiday).Error
+}
+
+func (hr *HolidayRepositoryImpl) GetHoliday(id uuid.UUID) (*model.Holiday, error) {
+	var holiday model.Holiday
+	if err := hr.db.Where("id = ?", id).First(&holiday).Error; err != nil {
+		return nil, err
+	}
+	return &holiday, nil
+}
+
+func (hr *HolidayRepositoryImpl) GetHolidays(start, end time.Time) ([]model.Holiday, error) {
+	var result []model.Holiday
+	err := hr.db.Where("date BETWEEN ? AND ?", start, end).Order("date").Find(&result).Error
+	return result, err
+}
+
+func (hr *HolidayRepositoryImpl) UpdateHoliday(holiday *model.Holiday) error {
+	return hr.db.Save(&holiday).Error
+}
+
+func (hr *HolidayRepositoryImpl) DeleteHoliday(id uuid.UUID) error {
+	result := hr.db.Where("id = ?", id).Delete(&model.Holiday{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ImportHolidays inserts the holidays, replacing the name and category of a date already in the calendar
+func (hr *HolidayRepositoryImpl) ImportHolidays(holidays []model.Holiday) error {
+	if len(holidays) == 0 {
+		return nil
+	}
+	return hr.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "category", "updated_at"}),
+	}).Create(&holidays).Error
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"payslip-generation-system/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LeaveRepository interface {
+	GetLeaveTypes() ([]model.LeaveType, error)
+	GetLeaveType(code string) (*model.LeaveType, error)
+	GetUser(userID uuid.UUID) (*model.User, error)
+	UpdateManager(userID uuid.UUID, managerID *uuid.UUID) error
+	GetHolidays(start, end time.Time) ([]model.Holiday, error)
+	IsPeriodLocked(start, end time.Time) (bool, error)
+	GetRosterDates(userID uuid.UUID, start, end time.Time) ([]time.Time, error)
+	HasOverlappingLeave(userID uuid.UUID, start, end time.Time) (bool, error)
+	GetLeaveBalance(userID uuid.UUID, leaveType string, year int) (*model.LeaveBalance, error)
+	GetLeaveBalances(userID uuid.UUID, year int) ([]model.LeaveBalance, error)
+	UpdateLeaveBalance(balance *model.LeaveBalance) error
+	AccrueLeave(year int) (int64, error)
+	SaveLeaveRequest(request *model.LeaveRequest) error
+	GetLeaveRequest(id uuid.UUID) (*model.LeaveRequest, error)
+	GetLeaveRequests(userID uuid.UUID) ([]model.LeaveRequest, error)
+	GetPendingLeaveRequests(managerID *uuid.UUID) ([]model.LeaveRequest, error)
+	UpdateLeaveRequest(request *model.LeaveRequest) error
+}
+
+type LeaveRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewLeaveRepository(db *gorm.DB) LeaveRepository {
+	return &LeaveRepositoryImpl{db: db}
+}
+
+func (lr *LeaveRepositoryImpl) GetLeaveTypes() ([]model.LeaveType, error) {
+	var result []model.LeaveType
+	err := lr.db.Order("code").Find(&result).Error
+	return result, err
+}
+
+func (lr *LeaveRepositoryImpl) GetLeaveType(code string) (*model.LeaveType, error) {
+	var leaveType model.LeaveType
+	if err := lr.db.Where("code = ?", code).First(&leaveType).Error; err != nil {
+		return nil, err
+	}
+	return &leaveType, nil
+}
+
+func (lr *LeaveRepositoryImpl) GetUser(userID uuid.UUID) (*model.User, error) {
+	return getUser(lr.db, userID)
+}
+
+func (lr *LeaveRepositoryImpl) UpdateManager(userID uuid.UUID, managerID *uuid.UUID) error {
+	result := lr.db.Model(&model.User{}).
+		Where("id = ? AND role = ?", userID, "employee").
+		Updates(map[string]interface{}{
+			"manager_id": managerID,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (lr *LeaveRepositoryImpl) GetHolidays(start, end time.Time) ([]model.Holiday, error) {
+	var result []model.Holiday
+	err := lr.db.Where("date BETWEEN ? AND ?", start, end).Order("date").Find(&result).Error
+	return result, err
+}
+
+// IsPeriodLocked checks if a locked attendance period overlaps the dates
+func (lr *LeaveRepositoryImpl) IsPeriodLocked(start, end time.Time) (bool, error) {
+	return isPeriodLocked(lr.db, start, end)
+}
+
+// GetRosterDates returns the dates the employee is rostered on in the attendance periods of the
+// dates, or their months when no period covers them. None for an employee who isn't rostered
+func (lr *LeaveRepositoryImpl) GetRosterDates(userID uuid.UUID, start, end time.Time) ([]time.Time, error) {
+	from, _, err := getClaimPeriod(lr.db, start)
+	if err != nil {
+		return nil, err
+	}
+	_, to, err := getClaimPeriod(lr.db, end)
+	if err != nil {
+		return nil, err
+	}
+	var result []time.Time
+	err = lr.db.Model(&model.Roster{}).
+		Where("user_id = ? AND date BETWEEN ? AND ?", userID, from, to).
+		Order("date").
+		Pluck("date", &result).Error
+	return result, err
+}
+
+// HasOverlappingLeave checks if the employee has a pending or approved leave on any of the dates
+func (lr *LeaveRepositoryImpl) HasOverlappingLeave(userID uuid.UUID, start, end time.Time) (bool, error) {
+	var count int64
+	err := lr.db.Model(&model.LeaveRequest{}).
+		Where("user_id = ? AND start_date <= ? AND end_date >= ?", userID, end, start).
+		Where("status IN ?", []string{model.LeavePending, model.LeaveApproved}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetLeaveBalance locks the balance so concurrent approvals can't take the same days twice
+func (lr *LeaveRepositoryImpl) GetLeaveBalance(userID uuid.UUID, leaveType string, year int) (*model.LeaveBalance, error) {
+	var balance model.LeaveBalance
+	err := lr.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND leave_type = ? AND year = ?", userID, leaveType, year).
+		First(&balance).Error
+	if err != nil {
+		return nil, err
+	}
+	return &balance, nil
+}
+
+func (lr *LeaveRepositoryImpl) GetLeaveBalances(userID uuid.UUID, year int) ([]model.LeaveBalance, error) {
+	var result []model.LeaveBalance
+	err := lr.db.Where("user_id = ? AND year = ?", userID, year).Order("leave_type").Find(&result).Error
+	return result, err
+}
+
+func (lr *LeaveRepositoryImpl) UpdateLeaveBalance(balance *model.LeaveBalance) error {
+	return lr.db.Model(balance).Updates(map[string]interface{}{
+		"used":       balance.Used,
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// AccrueLeave opens the balances of the year for every employee and accruing leave type, carrying
+// over the days left of the year before up to the type's limit. An employee who started during the
+// year, on their first salary, is entitled to the months from the start month on. Balances already
+// opened are kept, employees who haven't started by the end of the year get none
+func (lr *LeaveRepositoryImpl) AccrueLeave(year int) (int64, error) {
+	result := lr.db.Exec(`
+		INSERT INTO leave_balances (id, user_id, leave_type, year, entitled, carried_over, used, created_at, updated_at)
+		SELECT uuid_generate_v4(), u.id, t.code, ?,
+		       CASE WHEN s.start_date < make_date(?, 1, 1) THEN t.annual_entitlement
+		            ELSE t.annual_entitlement * (13 - EXTRACT(MONTH FROM s.start_date)::int) / 12 END,
+		       LEAST(t.max_carry_over, GREATEST(COALESCE(p.entitled + p.carried_over - p.used, 0), 0)),
+		       0, now(), now()
+		FROM users u
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(MIN(h.effective_from), u.created_at::date) AS start_date
+			FROM salary_histories h WHERE h.user_id = u.id
+		) s
+		CROSS JOIN leave_types t
+		LEFT JOIN leave_balances p ON p.user_id = u.id AND p.leave_type = t.code AND p.year = ?
+		WHERE u.role = 'employee' AND t.accrues AND s.start_date <= make_date(?, 12, 31)
+		ON CONFLICT (user_id, leave_type, year) DO NOTHING
+	`, year, year, year-1, year)
+	return result.RowsAffected, result.Error
+}
+
+func (lr *LeaveRepositoryImpl) SaveLeaveRequest(request *model.LeaveRequest) error {
+	return lr.db.Omit("Type").Create(&request).Error
+}
+
+// GetLeaveRequest locks the request so it is reviewed or cancelled only once
+func (lr *LeaveRepositoryImpl) GetLeaveRequest(id uuid.UUID) (*model.LeaveRequest, error) {
+	var request model.LeaveRequest
+	err := lr.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (lr *LeaveRepositoryImpl) GetLeaveRequests(userID uuid.UUID) ([]model.LeaveRequest, error) {
+	var result []model.LeaveRequest
+	err := lr.db.Where("user_id = ?", userID).Order("start_date DESC").Find(&result).Error
+	return result, err
+}
+
+// GetPendingLeaveRequests returns the requests waiting for the manager, or the ones of
+// employees without a manager when the manager ID is nil
+func (lr *LeaveRepositoryImpl) GetPendingLeaveRequests(managerID *uuid.UUID) ([]model.LeaveRequest, error) {
+	var result []model.LeaveRequest
+	query := lr.db.
+		Joins("JOIN users u ON u.id = leave_requests.user_id").
+		Where("leave_requests.status = ?", model.LeavePending)
+	if managerID != nil {
+		query = query.Where("u.manager_id = ?", *managerID)
+	} else {
+		query = query.Where("u.manager_id IS NULL")
+	}
+	err := query.Order("leave_requests.start_date").Find(&result).Error
+	return result, err
+}
+
+func (lr *LeaveRepositoryImpl) UpdateLeaveRequest(request *model.LeaveRequest) error {
+	return lr.db.Omit("Type").Save(&request).Error
+}
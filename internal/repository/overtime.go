@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"payslip-generation-system/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OvertimeRepository interface {
+	GetUser(userID uuid.UUID) (*model.User, error)
+	GetOvertime(id uuid.UUID) (*model.Overtime, error)
+	GetOvertimes(userID uuid.UUID, status string) ([]model.Overtime, error)
+	GetPendingOvertimes(managerID *uuid.UUID) ([]model.Overtime, error)
+	UpdateOvertime(overtime *model.Overtime) error
+	IsPeriodLocked(date time.Time) (bool, error)
+}
+
+type OvertimeRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewOvertimeRepository(db *gorm.DB) OvertimeRepository {
+	return &OvertimeRepositoryImpl{db: db}
+}
+
+func (or *OvertimeRepositoryImpl) GetUser(userID uuid.UUID) (*model.User, error) {
+	return getUser(or.db, userID)
+}
+
+// GetOvertime locks the overtime so it is reviewed or withdrawn only once
+func (or *OvertimeRepositoryImpl) GetOvertime(id uuid.UUID) (*model.Overtime, error) {
+	var overtime model.Overtime
+	err := or.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&overtime).Error
+	if err != nil {
+		return nil, err
+	}
+	return &overtime, nil
+}
+
+// GetOvertimes returns the overtime of the employee, of every status when the status is empty
+func (or *OvertimeRepositoryImpl) GetOvertimes(userID uuid.UUID, status string) ([]model.Overtime, error) {
+	var result []model.Overtime
+	query := or.db.Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("date DESC").Find(&result).Error
+	return result, err
+}
+
+// GetPendingOvertimes returns the overtime waiting for the manager, or every pending
+// overtime when the manager ID is nil
+func (or *OvertimeRepositoryImpl) GetPendingOvertimes(managerID *uuid.UUID) ([]model.Overtime, error) {
+	var result []model.Overtime
+	query := or.db.Where("overtimes.status = ?", model.OvertimePending)
+	if managerID != nil {
+		query = query.
+			Joins("JOIN users u ON u.id = overtimes.user_id").
+			Where("u.manager_id = ?", *managerID)
+	}
+	err := query.Order("overtimes.date").Find(&result).Error
+	return result, err
+}
+
+func (or *OvertimeRepositoryImpl) UpdateOvertime(overtime *model.Overtime) error {
+	return or.db.Model(overtime).Updates(map[string]interface{}{
+		"status":      overtime.Status,
+		"reviewed_by": overtime.ReviewedBy,
+		"reviewed_at": overtime.ReviewedAt,
+		"review_note": overtime.ReviewNote,
+		"updated_at":  overtime.UpdatedAt,
+	}).Error
+}
+
+// IsPeriodLocked checks if the date falls in a locked attendance period
+func (or *OvertimeRepositoryImpl) IsPeriodLocked(date time.Time) (bool, error) {
+	return isPeriodLocked(or.db, date, date)
+}
@@ -6,18 +6,35 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PayrollRepository interface {
-	FindAttendancePeriod(periodID uuid.UUID) (bool, error)
+	GetAttendancePeriod(periodID uuid.UUID) (*model.AttendancePeriod, error)
 	IsPayrollRun(periodID uuid.UUID) (bool, error)
+	GetPayroll(payrollID uuid.UUID) (*model.Payroll, error)
+	GetLastVoidedPayroll(periodID uuid.UUID) (*model.Payroll, error)
+	GetPayslips(payrollID uuid.UUID) ([]model.Payslip, error)
 	GetAttendances(periodID uuid.UUID) ([]model.Attendance, error)
 	GetOvertimes(periodID uuid.UUID) ([]model.Overtime, error)
 	GetReimbursements(periodID uuid.UUID) ([]model.Reimbursement, error)
-	GetUserSalary(userIDs []uuid.UUID) ([]model.User, error)
+	MarkReimbursementsPaid(ids []uuid.UUID, payrollID, periodID uuid.UUID) error
+	ReleaseReimbursements(payrollID uuid.UUID) error
+	GetApprovedLeaves(start, end time.Time) ([]model.LeaveRequest, error)
+	GetRosters(start, end time.Time) ([]model.Roster, error)
+	GetUsers(userIDs []uuid.UUID) ([]model.User, error)
+	GetSalaryHistories(userIDs []uuid.UUID, until time.Time) ([]model.SalaryHistory, error)
+	GetTaxYearToDate(userIDs []uuid.UUID, period *model.AttendancePeriod) ([]model.TaxYearToDate, error)
+	GetBPJSRates(asOf time.Time) ([]model.BPJSRate, error)
+	GetCompanySettings() (*model.CompanySettings, error)
+	GetHolidays(start, end time.Time) ([]model.Holiday, error)
+	GetOvertimeRules() ([]model.OvertimeRule, error)
 	CreateAuditLog(log *model.AuditLog) error
 	CreatePayroll(payroll *model.Payroll) error
 	CreatePayslip(payslip *model.Payslip) error
+	UpdatePayrollStatus(payroll *model.Payroll) error
+	LockAttendancePeriod(periodID, lockedBy uuid.UUID) error
+	UnlockAttendancePeriod(periodID uuid.UUID) error
 }
 
 type PayrollRepositoryImpl struct {
@@ -28,18 +45,51 @@ func NewPayrollRepository(db *gorm.DB) PayrollRepository {
 	return &PayrollRepositoryImpl{db: db}
 }
 
-func (pr *PayrollRepositoryImpl) FindAttendancePeriod(periodID uuid.UUID) (bool, error) {
-	var found int64
-	err := pr.db.Model(&model.Payroll{}).Where("period_id = ?", periodID).Count(&found).Error
-	return found > 0, err
+func (pr *PayrollRepositoryImpl) GetAttendancePeriod(periodID uuid.UUID) (*model.AttendancePeriod, error) {
+	var period model.AttendancePeriod
+	if err := pr.db.Where("id = ?", periodID).First(&period).Error; err != nil {
+		return nil, err
+	}
+	return &period, nil
 }
 
 func (pr *PayrollRepositoryImpl) IsPayrollRun(periodID uuid.UUID) (bool, error) {
 	var count int64
-	err := pr.db.Model(&model.Payroll{}).Where("period_id = ?", periodID).Count(&count).Error
+	err := pr.db.Model(&model.Payroll{}).
+		Where("period_id = ? AND status <> ?", periodID, model.PayrollVoided).
+		Count(&count).Error
 	return count > 0, err
 }
 
+// GetPayroll locks the payroll row so concurrent changes of its status wait for each other
+func (pr *PayrollRepositoryImpl) GetPayroll(payrollID uuid.UUID) (*model.Payroll, error) {
+	var payroll model.Payroll
+	err := pr.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", payrollID).First(&payroll).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payroll, nil
+}
+
+// GetLastVoidedPayroll returns the most recently voided payroll of the period, the one a new run supersedes
+func (pr *PayrollRepositoryImpl) GetLastVoidedPayroll(periodID uuid.UUID) (*model.Payroll, error) {
+	var payroll model.Payroll
+	err := pr.db.
+		Where("period_id = ? AND status = ?", periodID, model.PayrollVoided).
+		Order("voided_at DESC").
+		First(&payroll).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payroll, nil
+}
+
+func (pr *PayrollRepositoryImpl) GetPayslips(payrollID uuid.UUID) ([]model.Payslip, error) {
+	var result []model.Payslip
+	err := pr.db.Where("payroll_id = ?", payrollID).Find(&result).Error
+	return result, err
+}
+
 func (pr *PayrollRepositoryImpl) GetAttendances(periodID uuid.UUID) ([]model.Attendance, error) {
 	var result []model.Attendance
 	err := pr.db.Raw(`
@@ -50,27 +100,71 @@ func (pr *PayrollRepositoryImpl) GetAttendances(periodID uuid.UUID) ([]model.Att
 	return result, err
 }
 
+// GetOvertimes returns the approved overtime of the period, pending and rejected overtime is not paid
 func (pr *PayrollRepositoryImpl) GetOvertimes(periodID uuid.UUID) ([]model.Overtime, error) {
 	var result []model.Overtime
 	err := pr.db.Raw(`
 		SELECT o.* FROM overtimes o
 		JOIN attendance_periods p ON o.date BETWEEN p.start_date AND p.end_date
-		WHERE p.id = ?
-	`, periodID).Scan(&result).Error
+		WHERE p.id = ? AND o.status = ?
+	`, periodID, model.OvertimeApproved).Scan(&result).Error
 	return result, err
 }
 
+// GetReimbursements returns the approved claims not paid yet that are assigned to the period,
+// to an earlier period or to no period at all, so a claim missed by its period's run rolls over
 func (pr *PayrollRepositoryImpl) GetReimbursements(periodID uuid.UUID) ([]model.Reimbursement, error) {
 	var result []model.Reimbursement
 	err := pr.db.Raw(`
 		SELECT r.* FROM reimbursements r
-		JOIN attendance_periods p ON r.created_at::date BETWEEN p.start_date AND p.end_date
-		WHERE p.id = ?
-	`, periodID).Scan(&result).Error
+		JOIN attendance_periods p ON p.id = ?
+		LEFT JOIN attendance_periods a ON a.id = r.period_id
+		WHERE r.status = ?
+		  AND r.paid_in_payroll_id IS NULL
+		  AND r.expense_date <= p.end_date
+		  AND (r.period_id IS NULL OR a.start_date <= p.start_date)
+	`, periodID, model.ReimbursementApproved).Scan(&result).Error
+	return result, err
+}
+
+// MarkReimbursementsPaid records the payroll that paid the claims and moves them to its period
+func (pr *PayrollRepositoryImpl) MarkReimbursementsPaid(ids []uuid.UUID, payrollID, periodID uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return pr.db.Model(&model.Reimbursement{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"paid_in_payroll_id": payrollID,
+		"period_id":          periodID,
+		"updated_at":         time.Now(),
+	}).Error
+}
+
+// ReleaseReimbursements makes the claims paid by a voided payroll unpaid again, they are
+// paid by the next run of its period
+func (pr *PayrollRepositoryImpl) ReleaseReimbursements(payrollID uuid.UUID) error {
+	return pr.db.Model(&model.Reimbursement{}).Where("paid_in_payroll_id = ?", payrollID).Updates(map[string]interface{}{
+		"paid_in_payroll_id": nil,
+		"updated_at":         time.Now(),
+	}).Error
+}
+
+// GetApprovedLeaves returns the approved leave overlapping the dates with its leave type
+func (pr *PayrollRepositoryImpl) GetApprovedLeaves(start, end time.Time) ([]model.LeaveRequest, error) {
+	var result []model.LeaveRequest
+	err := pr.db.Preload("Type").
+		Where("status = ? AND start_date <= ? AND end_date >= ?", model.LeaveApproved, end, start).
+		Find(&result).Error
+	return result, err
+}
+
+// GetRosters returns the shifts every employee is rostered on between the dates
+func (pr *PayrollRepositoryImpl) GetRosters(start, end time.Time) ([]model.Roster, error) {
+	var result []model.Roster
+	err := pr.db.Where("date BETWEEN ? AND ?", start, end).Order("user_id, date").Find(&result).Error
 	return result, err
 }
 
-func (pr *PayrollRepositoryImpl) GetUserSalary(userIDs []uuid.UUID) ([]model.User, error) {
+func (pr *PayrollRepositoryImpl) GetUsers(userIDs []uuid.UUID) ([]model.User, error) {
 	var users []model.User
 	if err := pr.db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
 		return nil, err
@@ -79,6 +173,72 @@ func (pr *PayrollRepositoryImpl) GetUserSalary(userIDs []uuid.UUID) ([]model.Use
 	return users, nil
 }
 
+// GetSalaryHistories returns the salaries of the users that took effect up to the given date, oldest first
+func (pr *PayrollRepositoryImpl) GetSalaryHistories(userIDs []uuid.UUID, until time.Time) ([]model.SalaryHistory, error) {
+	var result []model.SalaryHistory
+	err := pr.db.
+		Where("user_id IN ? AND effective_from <= ?", userIDs, until).
+		Order("user_id, effective_from").
+		Find(&result).Error
+	return result, err
+}
+
+// GetTaxYearToDate sums the taxable income, withheld tax and pension contributions
+// of the payrolls run earlier in the same tax year as the given period
+func (pr *PayrollRepositoryImpl) GetTaxYearToDate(userIDs []uuid.UUID, period *model.AttendancePeriod) ([]model.TaxYearToDate, error) {
+	var result []model.TaxYearToDate
+	err := pr.db.Raw(`
+		SELECT s.user_id,
+		       SUM(s.taxable_income) AS taxable_income,
+		       SUM(s.tax_amount) AS tax_amount,
+		       COALESCE(SUM((
+		         SELECT SUM(i.amount) FROM payslip_items i
+		         WHERE i.payslip_id = s.id AND i.code IN ('BPJS_JHT_EE', 'BPJS_JP_EE')
+		       )), 0) AS pension_contribution
+		FROM payslips s
+		JOIN payrolls r ON s.payroll_id = r.id
+		JOIN attendance_periods p ON r.period_id = p.id
+		WHERE s.user_id IN ?
+		  AND r.status <> 'voided'
+		  AND EXTRACT(YEAR FROM p.end_date) = ?
+		  AND p.end_date < ?
+		GROUP BY s.user_id
+	`, userIDs, period.EndDate.Year(), period.StartDate).Scan(&result).Error
+	return result, err
+}
+
+// GetBPJSRates returns the latest version of each BPJS rate in force at the given date
+func (pr *PayrollRepositoryImpl) GetBPJSRates(asOf time.Time) ([]model.BPJSRate, error) {
+	var result []model.BPJSRate
+	err := pr.db.Raw(`
+		SELECT DISTINCT ON (program, risk_class) *
+		FROM bpjs_rates
+		WHERE effective_from <= ?
+		ORDER BY program, risk_class, effective_from DESC
+	`, asOf).Scan(&result).Error
+	return result, err
+}
+
+func (pr *PayrollRepositoryImpl) GetCompanySettings() (*model.CompanySettings, error) {
+	var settings model.CompanySettings
+	if err := pr.db.First(&settings).Error; err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (pr *PayrollRepositoryImpl) GetHolidays(start, end time.Time) ([]model.Holiday, error) {
+	var result []model.Holiday
+	err := pr.db.Where("date BETWEEN ? AND ?", start, end).Order("date").Find(&result).Error
+	return result, err
+}
+
+func (pr *PayrollRepositoryImpl) GetOvertimeRules() ([]model.OvertimeRule, error) {
+	var result []model.OvertimeRule
+	err := pr.db.Order("day_type, from_hour").Find(&result).Error
+	return result, err
+}
+
 func (pr *PayrollRepositoryImpl) CreateAuditLog(log *model.AuditLog) error {
 	return pr.db.Create(&log).Error
 }
@@ -91,3 +251,30 @@ func (pr *PayrollRepositoryImpl) CreatePayroll(payroll *model.Payroll) error {
 func (pr *PayrollRepositoryImpl) CreatePayslip(payslip *model.Payslip) error {
 	return pr.db.Create(&payslip).Error
 }
+
+func (pr *PayrollRepositoryImpl) UpdatePayrollStatus(payroll *model.Payroll) error {
+	return pr.db.Model(payroll).Updates(map[string]interface{}{
+		"status":       payroll.Status,
+		"approved_by":  payroll.ApprovedBy,
+		"approved_at":  payroll.ApprovedAt,
+		"published_at": payroll.PublishedAt,
+		"paid_at":      payroll.PaidAt,
+		"void_reason":  payroll.VoidReason,
+		"voided_by":    payroll.VoidedBy,
+		"voided_at":    payroll.VoidedAt,
+	}).Error
+}
+
+func (pr *PayrollRepositoryImpl) LockAttendancePeriod(periodID, lockedBy uuid.UUID) error {
+	return pr.db.Model(&model.AttendancePeriod{}).Where("id = ?", periodID).Updates(map[string]interface{}{
+		"locked_at": time.Now(),
+		"locked_by": lockedBy,
+	}).Error
+}
+
+func (pr *PayrollRepositoryImpl) UnlockAttendancePeriod(periodID uuid.UUID) error {
+	return pr.db.Model(&model.AttendancePeriod{}).Where("id = ?", periodID).Updates(map[string]interface{}{
+		"locked_at": nil,
+		"locked_by": nil,
+	}).Error
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"payslip-generation-system/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PayslipRepository interface {
+	GetPayroll(payrollID uuid.UUID) (*model.Payroll, error)
+	GetAttendancePeriod(periodID uuid.UUID) (*model.AttendancePeriod, error)
+	GetReleasedPayslip(userID, payrollID uuid.UUID) (*model.Payslip, error)
+	GetPayslips(payrollID uuid.UUID) ([]model.Payslip, error)
+	GetUsers(userIDs []uuid.UUID) ([]model.User, error)
+	GetYearToDate(userIDs []uuid.UUID, payrollID uuid.UUID) ([]model.PayslipYearToDate, error)
+	GetCompanySettings() (*model.CompanySettings, error)
+	GetTemplate() (*model.PayslipTemplate, error)
+	SaveTemplate(template *model.PayslipTemplate) error
+	DeleteTemplate() error
+}
+
+type PayslipRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPayslipRepository(db *gorm.DB) PayslipRepository {
+	return &PayslipRepositoryImpl{db: db}
+}
+
+func (psr *PayslipRepositoryImpl) GetPayroll(payrollID uuid.UUID) (*model.Payroll, error) {
+	var payroll model.Payroll
+	if err := psr.db.Where("id = ?", payrollID).First(&payroll).Error; err != nil {
+		return nil, err
+	}
+	return &payroll, nil
+}
+
+func (psr *PayslipRepositoryImpl) GetAttendancePeriod(periodID uuid.UUID) (*model.AttendancePeriod, error) {
+	var period model.AttendancePeriod
+	if err := psr.db.Where("id = ?", periodID).First(&period).Error; err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// GetReleasedPayslip returns the payslip of the employee if the payroll was published to them
+func (psr *PayslipRepositoryImpl) GetReleasedPayslip(userID, payrollID uuid.UUID) (*model.Payslip, error) {
+	var result model.Payslip
+	err := psr.db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("sequence") }).
+		Joins("JOIN payrolls r ON r.id = payslips.payroll_id").
+		Where("payslips.payroll_id = ? AND payslips.user_id = ?", payrollID, userID).
+		Where("r.status IN ?", []string{model.PayrollPublished, model.PayrollPaid}).
+		First(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (psr *PayslipRepositoryImpl) GetPayslips(payrollID uuid.UUID) ([]model.Payslip, error) {
+	var result []model.Payslip
+	err := psr.db.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("sequence") }).
+		Where("payroll_id = ?", payrollID).
+		Find(&result).Error
+	return result, err
+}
+
+func (psr *PayslipRepositoryImpl) GetUsers(userIDs []uuid.UUID) ([]model.User, error) {
+	var users []model.User
+	err := psr.db.Where("id IN ?", userIDs).Find(&users).Error
+	return users, err
+}
+
+// payslipYearToDate selects the payslips released to the employees, and the ones of the payroll
+// of the third parameter even before it is released, with running totals of their tax year: the
+// payslips of the earlier periods of the year plus the payslip itself
+const payslipYearToDate = `
+	SELECT s.user_id, s.payroll_id, r.period_id, p.start_date, p.end_date,
+	       r.status AS payroll_status, r.published_at,
+	       s.gross_pay, s.tax_amount, s.total_deductions, s.net_pay,
+	       SUM(s.gross_pay) OVER ytd AS ytd_gross_pay,
+	       SUM(s.tax_amount) OVER ytd AS ytd_tax_amount,
+	       SUM(s.total_deductions) OVER ytd AS ytd_total_deductions,
+	       SUM(s.net_pay) OVER ytd AS ytd_net_pay
+	FROM payslips s
+	JOIN payrolls r ON s.payroll_id = r.id
+	JOIN attendance_periods p ON r.period_id = p.id
+	WHERE s.user_id IN ? AND (r.status IN ? OR r.id = ?)
+	WINDOW ytd AS (PARTITION BY s.user_id, EXTRACT(YEAR FROM p.end_date) ORDER BY p.end_date ROWS UNBOUNDED PRECEDING)`
+
+// GetYearToDate returns the totals of the tax year of each employee up to and including their
+// payslip of the payroll
+func (psr *PayslipRepositoryImpl) GetYearToDate(userIDs []uuid.UUID, payrollID uuid.UUID) ([]model.PayslipYearToDate, error) {
+	var result []model.PayslipYearToDate
+	err := psr.db.Raw(`
+		SELECT h.user_id,
+		       h.ytd_gross_pay AS gross_pay,
+		       h.ytd_tax_amount AS tax_amount,
+		       h.ytd_total_deductions AS total_deductions,
+		       h.ytd_net_pay AS net_pay
+		FROM (`+payslipYearToDate+`) h
+		WHERE h.payroll_id = ?
+	`, userIDs, []string{model.PayrollPublished, model.PayrollPaid}, payrollID, payrollID).Scan(&result).Error
+	return result, err
+}
+
+func (psr *PayslipRepositoryImpl) GetCompanySettings() (*model.CompanySettings, error) {
+	var settings model.CompanySettings
+	if err := psr.db.First(&settings).Error; err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (psr *PayslipRepositoryImpl) GetTemplate() (*model.PayslipTemplate, error) {
+	var template model.PayslipTemplate
+	if err := psr.db.First(&template).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (psr *PayslipRepositoryImpl) SaveTemplate(template *model.PayslipTemplate) error {
+	template.ID = 1
+	return psr.db.Save(template).Error
+}
+
+// DeleteTemplate goes back to the built-in template
+func (psr *PayslipRepositoryImpl) DeleteTemplate() error {
+	return psr.db.Where("id = ?", 1).Delete(&model.PayslipTemplate{}).Error
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"payslip-generation-system/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PayslipDeliveryRepository interface {
+	EnqueuePayroll(payrollID uuid.UUID) error
+	CancelPayroll(payrollID uuid.UUID) error
+	ClaimDue(now time.Time, limit int, lease time.Duration) ([]model.PayslipDelivery, error)
+	UpdateDelivery(delivery *model.PayslipDelivery) error
+	GetDeliveries(payrollID uuid.UUID, status string) ([]model.PayslipDeliveryStatus, error)
+}
+
+type PayslipDeliveryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewPayslipDeliveryRepository(db *gorm.DB) PayslipDeliveryRepository {
+	return &PayslipDeliveryRepositoryImpl{db: db}
+}
+
+// EnqueuePayroll queues the payslip email of every employee of the payroll, an employee
+// without an email address fails right away so the admin sees it
+func (pdr *PayslipDeliveryRepositoryImpl) EnqueuePayroll(payrollID uuid.UUID) error {
+	return pdr.db.Exec(`
+		INSERT INTO payslip_deliveries (payroll_id, user_id, email, status, last_error)
+		SELECT p.payroll_id, p.user_id, u.email,
+			CASE WHEN u.email = '' THEN ? ELSE ? END,
+			CASE WHEN u.email = '' THEN 'employee has no email address' ELSE '' END
+		FROM payslips p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.payroll_id = ?
+		ON CONFLICT (payroll_id, user_id) DO NOTHING`,
+		model.DeliveryFailed, model.DeliveryPending, payrollID).Error
+}
+
+// CancelPayroll stops the emails of a voided payroll that have not gone out yet
+func (pdr *PayslipDeliveryRepositoryImpl) CancelPayroll(payrollID uuid.UUID) error {
+	return pdr.db.Model(&model.PayslipDelivery{}).
+		Where("payroll_id = ? AND status = ?", payrollID, model.DeliveryPending).
+		Updates(map[string]interface{}{
+			"status":     model.DeliveryCancelled,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// ClaimDue takes the pending deliveries due by now and moves their next attempt a lease
+// ahead, so another worker skips them while they are sent and a crashed worker's deliveries
+// are picked up again once the lease runs out
+func (pdr *PayslipDeliveryRepositoryImpl) ClaimDue(now time.Time, limit int, lease time.Duration) ([]model.PayslipDelivery, error) {
+	var deliveries []model.PayslipDelivery
+	err := pdr.db.Raw(`
+		UPDATE payslip_deliveries SET next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM payslip_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(lease), now, model.DeliveryPending, now, limit).Scan(&deliveries).Error
+	return deliveries, err
+}
+
+// UpdateDelivery records an attempt, a delivery cancelled meanwhile stays cancelled
+func (pdr *PayslipDeliveryRepositoryImpl) UpdateDelivery(delivery *model.PayslipDelivery) error {
+	return pdr.db.Model(&model.PayslipDelivery{}).
+		Where("id = ? AND status = ?", delivery.ID, model.DeliveryPending).
+		Updates(map[string]interface{}{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"last_error":      delivery.LastError,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"sent_at":         delivery.SentAt,
+			"updated_at":      time.Now(),
+		}).Error
+}
+
+func (pdr *PayslipDeliveryRepositoryImpl) GetDeliveries(payrollID uuid.UUID, status string) ([]model.PayslipDeliveryStatus, error) {
+	var deliveries []model.PayslipDeliveryStatus
+	query := pdr.db.Table("payslip_deliveries d").
+		Select("d.*, u.username").
+		Joins("JOIN users u ON u.id = d.user_id").
+		Where("d.payroll_id = ?", payrollID)
+	if status != "" {
+		query = query.Where("d.status = ?", status)
+	}
+	err := query.Order("u.username").Scan(&deliveries).Error
+	return deliveries, err
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"errors"
+	"payslip-generation-system/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReimbursementRepository interface {
+	GetCategories() ([]model.ReimbursementCategory, error)
+	GetLimits() ([]model.ReimbursementLimit, error)
+	SaveLimit(limit *model.ReimbursementLimit) error
+	GetUser(userID uuid.UUID) (*model.User, error)
+	UpdateGrade(userID uuid.UUID, grade string) error
+	GetReimbursement(id uuid.UUID) (*model.Reimbursement, error)
+	GetReimbursements(userID uuid.UUID, status string) ([]model.Reimbursement, error)
+	GetPendingReimbursements(managerID *uuid.UUID) ([]model.Reimbursement, error)
+	UpdateReimbursement(reimbursement *model.Reimbursement) error
+	ReleaseAttachments(reimbursementID uuid.UUID) error
+	GetAttachment(id uuid.UUID) (*model.ReimbursementAttachment, error)
+	GetReimbursementOwner(reimbursementID uuid.UUID) (*model.User, error)
+	GetReimbursementLimit(userID uuid.UUID, category string) (*model.ReimbursementLimit, error)
+	GetClaimPeriod(date time.Time) (time.Time, time.Time, error)
+	GetClaimedAmount(userID uuid.UUID, category string, start, end time.Time, approvedOnly bool, excludeID uuid.UUID) (int, error)
+	GetOpenPeriod(from time.Time) (*model.AttendancePeriod, error)
+}
+
+type ReimbursementRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewReimbursementRepository(db *gorm.DB) ReimbursementRepository {
+	return &ReimbursementRepositoryImpl{db: db}
+}
+
+func (rr *ReimbursementRepositoryImpl) GetCategories() ([]model.ReimbursementCategory, error) {
+	var result []model.ReimbursementCategory
+	err := rr.db.Order("code").Find(&result).Error
+	return result, err
+}
+
+func (rr *ReimbursementRepositoryImpl) GetLimits() ([]model.ReimbursementLimit, error) {
+	var result []model.ReimbursementLimit
+	err := rr.db.Order("grade, category").Find(&result).Error
+	return result, err
+}
+
+// SaveLimit creates the limit of the category and grade or replaces the existing one
+func (rr *ReimbursementRepositoryImpl) SaveLimit(limit *model.ReimbursementLimit) error {
+	return rr.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "category"}, {Name: "grade"}},
+		DoUpdates: clause.AssignmentColumns([]string{"per_claim_limit", "per_period_limit", "updated_by", "updated_at"}),
+	}).Create(limit).Error
+}
+
+func (rr *ReimbursementRepositoryImpl) GetUser(userID uuid.UUID) (*model.User, error) {
+	return getUser(rr.db, userID)
+}
+
+func (rr *ReimbursementRepositoryImpl) UpdateGrade(userID uuid.UUID, grade string) error {
+	result := rr.db.Model(&model.User{}).
+		Where("id = ? AND role = ?", userID, "employee").
+		Updates(map[string]interface{}{
+			"grade":      grade,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetReimbursement locks the claim so it is reviewed only once
+func (rr *ReimbursementRepositoryImpl) GetReimbursement(id uuid.UUID) (*model.Reimbursement, error) {
+	var reimbursement model.Reimbursement
+	err := rr.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&reimbursement).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reimbursement, nil
+}
+
+// GetReimbursements returns the claims of the employee, of every status when the status is empty
+func (rr *ReimbursementRepositoryImpl) GetReimbursements(userID uuid.UUID, status string) ([]model.Reimbursement, error) {
+	var result []model.Reimbursement
+	query := rr.db.Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Preload("Attachments").Order("created_at DESC").Find(&result).Error
+	return result, err
+}
+
+// GetPendingReimbursements returns the claims waiting for the manager, or every pending
+// claim when the manager ID is nil
+func (rr *ReimbursementRepositoryImpl) GetPendingReimbursements(managerID *uuid.UUID) ([]model.Reimbursement, error) {
+	var result []model.Reimbursement
+	query := rr.db.Where("reimbursements.status = ?", model.ReimbursementPending)
+	if managerID != nil {
+		query = query.
+			Joins("JOIN users u ON u.id = reimbursements.user_id").
+			Where("u.manager_id = ?", *managerID)
+	}
+	err := query.Preload("Attachments").Order("reimbursements.created_at").Find(&result).Error
+	return result, err
+}
+
+func (rr *ReimbursementRepositoryImpl) UpdateReimbursement(reimbursement *model.Reimbursement) error {
+	return rr.db.Model(reimbursement).Updates(map[string]interface{}{
+		"status":          reimbursement.Status,
+		"approved_amount": reimbursement.ApprovedAmount,
+		"reviewed_by":     reimbursement.ReviewedBy,
+		"reviewed_at":     reimbursement.ReviewedAt,
+		"review_note":     reimbursement.ReviewNote,
+		"period_id":       reimbursement.PeriodID,
+		"updated_at":      reimbursement.UpdatedAt,
+	}).Error
+}
+
+// ReleaseAttachments deactivates the receipts of a claim so they can be attached to another claim
+func (rr *ReimbursementRepositoryImpl) ReleaseAttachments(reimbursementID uuid.UUID) error {
+	return rr.db.Model(&model.ReimbursementAttachment{}).
+		Where("reimbursement_id = ?", reimbursementID).
+		Update("active", false).Error
+}
+
+func (rr *ReimbursementRepositoryImpl) GetAttachment(id uuid.UUID) (*model.ReimbursementAttachment, error) {
+	var attachment model.ReimbursementAttachment
+	if err := rr.db.Where("id = ?", id).First(&attachment).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// GetReimbursementOwner returns the employee who submitted the claim
+func (rr *ReimbursementRepositoryImpl) GetReimbursementOwner(reimbursementID uuid.UUID) (*model.User, error) {
+	var user model.User
+	err := rr.db.
+		Joins("JOIN reimbursements r ON r.user_id = users.id").
+		Where("r.id = ?", reimbursementID).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetReimbursementLimit returns the limit of the category for the grade of the employee
+func (rr *ReimbursementRepositoryImpl) GetReimbursementLimit(userID uuid.UUID, category string) (*model.ReimbursementLimit, error) {
+	return getReimbursementLimit(rr.db, userID, category)
+}
+
+// GetClaimPeriod returns the attendance period covering the expense date, or its calendar
+// month when no period covers it yet
+func (rr *ReimbursementRepositoryImpl) GetClaimPeriod(date time.Time) (time.Time, time.Time, error) {
+	return getClaimPeriod(rr.db, date)
+}
+
+// GetClaimedAmount sums the claims of the category between the dates, the approved amount of
+// approved claims and the claimed amount of pending ones unless only approved claims are asked
+func (rr *ReimbursementRepositoryImpl) GetClaimedAmount(userID uuid.UUID, category string, start, end time.Time, approvedOnly bool, excludeID uuid.UUID) (int, error) {
+	return getClaimedAmount(rr.db, userID, category, start, end, approvedOnly, excludeID)
+}
+
+// GetOpenPeriod returns the earliest period ending on or after the date that is not locked
+// and has no payroll in force, the period a claim approved now is paid in
+func (rr *ReimbursementRepositoryImpl) GetOpenPeriod(from time.Time) (*model.AttendancePeriod, error) {
+	var period model.AttendancePeriod
+	err := rr.db.
+		Where("end_date >= ?::date AND locked_at IS NULL", from).
+		Where("NOT EXISTS (SELECT 1 FROM payrolls pr WHERE pr.period_id = attendance_periods.id AND pr.status <> ?)", model.PayrollVoided).
+		Order("start_date").
+		First(&period).Error
+	if err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+func getReimbursementLimit(db *gorm.DB, userID uuid.UUID, category string) (*model.ReimbursementLimit, error) {
+	var limit model.ReimbursementLimit
+	err := db.
+		Joins("JOIN users u ON u.grade = reimbursement_limits.grade").
+		Where("u.id = ? AND reimbursement_limits.category = ?", userID, category).
+		First(&limit).Error
+	if err != nil {
+		return nil, err
+	}
+	return &limit, nil
+}
+
+func getClaimPeriod(db *gorm.DB, date time.Time) (time.Time, time.Time, error) {
+	var period model.AttendancePeriod
+	err := db.Where("?::date BETWEEN start_date AND end_date", date).First(&period).Error
+	if err == nil {
+		return period.StartDate, period.EndDate, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, time.Time{}, err
+	}
+	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, -1), nil
+}
+
+func getClaimedAmount(db *gorm.DB, userID uuid.UUID, category string, start, end time.Time, approvedOnly bool, excludeID uuid.UUID) (int, error) {
+	statuses := []string{model.ReimbursementPending, model.ReimbursementApproved}
+	if approvedOnly {
+		statuses = []string{model.ReimbursementApproved}
+	}
+	var total int
+	err := db.Model(&model.Reimbursement{}).
+		Select("COALESCE(SUM(COALESCE(approved_amount, amount)), 0)").
+		Where("user_id = ? AND category = ? AND id <> ?", userID, category, excludeID).
+		Where("expense_date BETWEEN ? AND ?", start, end).
+		Where("status IN ?", statuses).
+		Scan(&total).Error
+	return total, err
+}
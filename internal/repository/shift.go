@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"payslip-generation-system/internal/model"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShiftRepository interface {
+	SaveShift(shift *model.Shift) error
+	GetShift(id uuid.UUID) (*model.Shift, error)
+	GetShifts() ([]model.Shift, error)
+	UpdateShift(shift *model.Shift) error
+	DeleteShift(id uuid.UUID) error
+	SaveRosters(rosters []model.Roster) error
+	GetRosters(userID uuid.UUID, start, end time.Time) ([]model.Roster, error)
+	DeleteRoster(id uuid.UUID) error
+}
+
+type ShiftRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewShiftRepository(db *gorm.DB) ShiftRepository {
+	return &ShiftRepositoryImpl{db: db}
+}
+
+func (sr *ShiftRepositoryImpl) SaveShift(shift *model.Shift) error {
+	return sr.db.Create(&shift).Error
+}
+
+func (sr *ShiftRepositoryImpl) GetShift(id uuid.UUID) (*model.Shift, error) {
+	var shift model.Shift
+	if err := sr.db.Where("id = ?", id).First(&shift).Error; err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+func (sr *ShiftRepositoryImpl) GetShifts() ([]model.Shift, error) {
+	var result []model.Shift
+	err := sr.db.Order("start_time, name").Find(&result).Error
+	return result, err
+}
+
+func (sr *ShiftRepositoryImpl) UpdateShift(shift *model.Shift) error {
+	return sr.db.Save(&shift).Error
+}
+
+func (sr *ShiftRepositoryImpl) DeleteShift(id uuid.UUID) error {
+	result := sr.db.Where("id = ?", id).Delete(&model.Shift{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SaveRosters assigns the shifts, replacing the shift an employee was already rostered on for a date
+func (sr *ShiftRepositoryImpl) SaveRosters(rosters []model.Roster) error {
+	if len(rosters) == 0 {
+		return nil
+	}
+	return sr.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"shift_id", "created_by", "request_ip", "updated_at"}),
+	}).Omit("Shift").Create(&rosters).Error
+}
+
+// GetRosters returns the rosters between the dates with their shift, of every employee when the user ID is nil
+func (sr *ShiftRepositoryImpl) GetRosters(userID uuid.UUID, start, end time.Time) ([]model.Roster, error) {
+	var result []model.Roster
+	query := sr.db.Preload("Shift").Where("date BETWEEN ? AND ?", start, end)
+	if userID != uuid.Nil {
+		query = query.Where("user_id = ?", userID)
+	}
+	err := query.Order("date, user_id").Find(&result).Error
+	return result, err
+}
+
+func (sr *ShiftRepositoryImpl) DeleteRoster(id uuid.UUID) error {
+	result := sr.db.Where("id = ?", id).Delete(&model.Roster{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+)
+
+// Repositories are bound to the transaction of a unit of work
+type Repositories struct {
+	Payroll       PayrollRepository
+	Leave         LeaveRepository
+	Overtime      OvertimeRepository
+	Reimbursement ReimbursementRepository
+	Delivery      PayslipDeliveryRepository
+}
+
+// UnitOfWork runs repository operations in a single database transaction,
+// which is committed when fn returns nil and rolled back otherwise
+type UnitOfWork interface {
+	Do(fn func(repos *Repositories) error) error
+}
+
+type UnitOfWorkImpl struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &UnitOfWorkImpl{db: db}
+}
+
+func (u *UnitOfWorkImpl) Do(fn func(repos *Repositories) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&Repositories{
+			Payroll:       NewPayrollRepository(tx),
+			Leave:         NewLeaveRepository(tx),
+			Overtime:      NewOvertimeRepository(tx),
+			Reimbursement: NewReimbursementRepository(tx),
+			Delivery:      NewPayslipDeliveryRepository(tx),
+		})
+	})
+}
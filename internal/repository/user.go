@@ -3,6 +3,7 @@ package repository
 import (
 	"payslip-generation-system/internal/model"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -25,3 +26,11 @@ func (ur *UserRepositoryImpl) FindByUsername(username string) (*model.User, erro
 	}
 	return &user, nil
 }
+
+func getUser(db *gorm.DB, userID uuid.UUID) (*model.User, error) {
+	var user model.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"payslip-generation-system/internal/model"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultWorkHourStart = 9
+	defaultWorkHourEnd   = 17
+)
+
+// WorkSchedule is the working hours of a day, lateness and early leave are measured against it.
+// Start and End are offsets from midnight, an End at or before Start ends on the next day
+type WorkSchedule struct {
+	Start        time.Duration
+	End          time.Duration
+	BreakMinutes int
+}
+
+// WorkScheduleFromEnv reads the working hours from WORK_HOUR_START and WORK_HOUR_END, falling back to 9 to 17
+func WorkScheduleFromEnv() WorkSchedule {
+	start, end := defaultWorkHourStart, defaultWorkHourEnd
+	if v, err := strconv.Atoi(os.Getenv("WORK_HOUR_START")); err == nil {
+		start = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("WORK_HOUR_END")); err == nil {
+		end = v
+	}
+	return WorkSchedule{Start: time.Duration(start) * time.Hour, End: time.Duration(end) * time.Hour}
+}
+
+// WorkScheduleFromShift builds the working hours of a rostered shift
+func WorkScheduleFromShift(shift *model.Shift) (WorkSchedule, error) {
+	start, err := ParseTimeOfDay(shift.StartTime)
+	if err != nil {
+		return WorkSchedule{}, err
+	}
+	end, err := ParseTimeOfDay(shift.EndTime)
+	if err != nil {
+		return WorkSchedule{}, err
+	}
+	return WorkSchedule{Start: start, End: end, BreakMinutes: shift.BreakMinutes}, nil
+}
+
+// ParseTimeOfDay reads a 15:04 or 15:04:05 time as the offset from midnight
+func ParseTimeOfDay(value string) (time.Duration, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time of day %q", value)
+}
+
+// CrossesMidnight checks if the working day ends on the day after it starts
+func (s WorkSchedule) CrossesMidnight() bool {
+	return s.End <= s.Start
+}
+
+// Midnight returns the start of the date in the location, the attendance date of a time is its
+// local date
+func Midnight(date time.Time, loc *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc)
+}
+
+// StartOn returns when the working day of the date starts, in the location of the given time
+func (s WorkSchedule) StartOn(date time.Time, loc *time.Location) time.Time {
+	return Midnight(date, loc).Add(s.Start)
+}
+
+// EndOn returns when the working day of the date ends, in the location of the given time
+func (s WorkSchedule) EndOn(date time.Time, loc *time.Location) time.Time {
+	end := Midnight(date, loc).Add(s.End)
+	if s.CrossesMidnight() {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// LateMinutes counts the whole minutes checked in after the start of the working day of the date
+func (s WorkSchedule) LateMinutes(date, checkIn time.Time) int {
+	late := checkIn.Sub(s.StartOn(date, checkIn.Location()))
+	if late <= 0 {
+		return 0
+	}
+	return int(late / time.Minute)
+}
+
+// EarlyLeaveMinutes counts the whole minutes checked out before the end of the working day of the date
+func (s WorkSchedule) EarlyLeaveMinutes(date, checkOut time.Time) int {
+	early := s.EndOn(date, checkOut.Location()).Sub(checkOut)
+	if early <= 0 {
+		return 0
+	}
+	return int(early / time.Minute)
+}
+
+// WorkedMinutes counts the whole minutes between check in and check out, less the break
+func (s WorkSchedule) WorkedMinutes(checkIn, checkOut time.Time) int {
+	if !checkOut.After(checkIn) {
+		return 0
+	}
+	worked := int(checkOut.Sub(checkIn)/time.Minute) - s.BreakMinutes
+	if worked < 0 {
+		return 0
+	}
+	return worked
+}
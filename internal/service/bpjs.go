@@ -0,0 +1,84 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"payslip-generation-system/internal/model"
+)
+
+// codes of the employee pension contributions, deductible from the annual gross income
+var pensionContributionCodes = []string{"BPJS_JHT_EE", "BPJS_JP_EE"}
+
+// bpjsProgram describes how a program's contributions land on the payslip
+type bpjsProgram struct {
+	Program string
+	Name    string
+	// employer contributions to health and accident/death insurance are taxable benefits
+	TaxableBenefit bool
+}
+
+var bpjsPrograms = []bpjsProgram{
+	{model.BPJSKesehatan, "BPJS Kesehatan", true},
+	{model.BPJSJHT, "BPJS JHT", false},
+	{model.BPJSJP, "BPJS JP", false},
+	{model.BPJSJKK, "BPJS JKK", true},
+	{model.BPJSJKM, "BPJS JKM", true},
+}
+
+// BPJSContribution calculates a contribution on the salary, capped to the rate's salary cap
+func BPJSContribution(salary int, rate float64, salaryCap *int) int {
+	if salaryCap != nil && salary > *salaryCap {
+		salary = *salaryCap
+	}
+	return int(math.Round(float64(salary) * rate / 100))
+}
+
+// BPJSComponent deducts the employee share of the BPJS Kesehatan and Ketenagakerjaan
+// programs and records the employer share as employer cost
+type BPJSComponent struct{}
+
+func (c *BPJSComponent) Code() string  { return "BPJS" }
+func (c *BPJSComponent) Sequence() int { return SequenceInsurance }
+
+func (c *BPJSComponent) Evaluate(ctx *PayContext) ([]model.PayslipItem, error) {
+	items := []model.PayslipItem{}
+	for _, program := range bpjsPrograms {
+		rate := findBPJSRate(ctx.BPJSRates, program.Program, ctx.JKKRiskClass)
+		if rate == nil {
+			return nil, fmt.Errorf("no %s rate in force at %s", program.Name, ctx.PeriodEnd.Format("2006-01-02"))
+		}
+
+		if employee := BPJSContribution(ctx.BaseSalary, rate.EmployeeRate, rate.SalaryCap); employee > 0 {
+			items = append(items, model.PayslipItem{
+				Code:   "BPJS_" + program.Program + "_EE",
+				Name:   program.Name,
+				Type:   model.PayslipItemDeduction,
+				Amount: employee,
+			})
+		}
+
+		if employer := BPJSContribution(ctx.BaseSalary, rate.EmployerRate, rate.SalaryCap); employer > 0 {
+			items = append(items, model.PayslipItem{
+				Code:    "BPJS_" + program.Program + "_ER",
+				Name:    program.Name + " (employer)",
+				Type:    model.PayslipItemEmployerCost,
+				Amount:  employer,
+				Taxable: program.TaxableBenefit,
+			})
+		}
+	}
+	return items, nil
+}
+
+func findBPJSRate(rates []model.BPJSRate, program string, riskClass int) *model.BPJSRate {
+	for i, rate := range rates {
+		if rate.Program != program {
+			continue
+		}
+		if program == model.BPJSJKK && (rate.RiskClass == nil || *rate.RiskClass != riskClass) {
+			continue
+		}
+		return &rates[i]
+	}
+	return nil
+}
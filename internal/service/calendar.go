@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"payslip-generation-system/internal/model"
+	"time"
+)
+
+// WorkCalendar tells which days of an attendance period are working days
+// and by how many days a monthly salary is divided when prorating it
+type WorkCalendar struct {
+	Start        time.Time
+	End          time.Time
+	Basis        string
+	FixedDivisor float64
+	HoursPerDay  float64
+	holidays     map[string]bool
+}
+
+func NewWorkCalendar(period *model.AttendancePeriod, settings *model.CompanySettings, holidays []model.Holiday) *WorkCalendar {
+	calendar := &WorkCalendar{
+		Start:        period.StartDate,
+		End:          period.EndDate,
+		Basis:        settings.ProrationBasis,
+		FixedDivisor: settings.FixedDivisor,
+		HoursPerDay:  settings.HoursPerDay,
+		holidays:     map[string]bool{},
+	}
+	for _, h := range holidays {
+		calendar.holidays[h.Date.Format("2006-01-02")] = true
+	}
+	return calendar
+}
+
+func (c *WorkCalendar) IsHoliday(date time.Time) bool {
+	return c.holidays[date.Format("2006-01-02")]
+}
+
+// IsWorkingDay reports if the date is a weekday that is not a holiday
+func (c *WorkCalendar) IsWorkingDay(date time.Time) bool {
+	weekday := date.Weekday()
+	if weekday == time.Saturday || weekday == time.Sunday {
+		return false
+	}
+	return !c.IsHoliday(date)
+}
+
+// RosterDays is the set of dates a shift worker is rostered on, empty for an employee who
+// works the weekdays
+type RosterDays map[string]bool
+
+func NewRosterDays(dates []time.Time) RosterDays {
+	roster := RosterDays{}
+	for _, date := range dates {
+		roster[date.Format("2006-01-02")] = true
+	}
+	return roster
+}
+
+// IsWorkingDayOf reports if the date is a working day of the employee with the roster, the
+// rostered dates that are not holidays for a shift worker and the weekdays for the others
+func (c *WorkCalendar) IsWorkingDayOf(date time.Time, roster RosterDays) bool {
+	if len(roster) == 0 {
+		return c.IsWorkingDay(date)
+	}
+	return roster[date.Format("2006-01-02")] && !c.IsHoliday(date)
+}
+
+// Days lists every date of the period
+func (c *WorkCalendar) Days() []time.Time {
+	days := []time.Time{}
+	for d := c.Start; !d.After(c.End); d = d.AddDate(0, 0, 1) {
+		days = append(days, d)
+	}
+	return days
+}
+
+func (c *WorkCalendar) WorkingDays() int {
+	count := 0
+	for _, d := range c.Days() {
+		if c.IsWorkingDay(d) {
+			count++
+		}
+	}
+	return count
+}
+
+// Divisor is the number of days a monthly salary is divided by for the configured basis
+func (c *WorkCalendar) Divisor() (float64, error) {
+	var divisor float64
+	switch c.Basis {
+	case model.ProrationWorkingDays:
+		divisor = float64(c.WorkingDays())
+	case model.ProrationCalendarDays:
+		divisor = float64(len(c.Days()))
+	case model.ProrationFixed:
+		divisor = c.FixedDivisor
+	default:
+		return 0, errors.New("unknown proration basis " + c.Basis)
+	}
+
+	if divisor <= 0 {
+		return 0, errors.New("attendance period has no working days")
+	}
+	return divisor, nil
+}
+
+// IsPaidDay reports if a day without attendance is still paid, which on the calendar
+// days basis are the days off of the employee with the roster and the holidays
+func (c *WorkCalendar) IsPaidDay(date time.Time, roster RosterDays) bool {
+	return c.Basis == model.ProrationCalendarDays && !c.IsWorkingDayOf(date, roster)
+}
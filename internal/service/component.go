@@ -0,0 +1,390 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"payslip-generation-system/internal/model"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// evaluation order of the pay components, lower sequence is evaluated first
+// so deductions can rely on the earnings calculated before them
+const (
+	SequenceEarning   = 100
+	SequenceAllowance = 200
+	SequenceBonus     = 300
+	SequenceInsurance = 400
+	SequenceTax       = 500
+	SequenceLoan      = 600
+)
+
+// PayContext holds the inputs of one employee and the line items produced so far
+type PayContext struct {
+	UserID             uuid.UUID
+	BaseSalary         int
+	AttendanceDays     int
+	AttendanceDates    []time.Time
+	PaidLeaveDates     []time.Time
+	Roster             RosterDays
+	LateDays           []LateDay
+	LatenessDeduction  bool
+	Calendar           *WorkCalendar
+	Salaries           []model.SalaryHistory
+	Overtimes          []model.Overtime
+	OvertimeHours      float64
+	OvertimeRules      []model.OvertimeRule
+	OvertimeDivisor    float64
+	ReimbursementTotal int
+	PeriodEnd          time.Time
+	PTKPStatus         string
+	HasNPWP            bool
+	PriorTaxableIncome int
+	PriorTaxWithheld   int
+	PriorPension       int
+	TaxAmount          int
+	JKKRiskClass       int
+	BPJSRates          []model.BPJSRate
+	Items              []model.PayslipItem
+}
+
+// LateDay is the minutes an employee checked in late on a date, beyond the grace minutes
+type LateDay struct {
+	Date    time.Time
+	Minutes int
+}
+
+// salaryIndexOn finds the salary in force on the given date, -1 when the employee had no salary yet
+func (c *PayContext) salaryIndexOn(date time.Time) int {
+	index := -1
+	for i, salary := range c.Salaries {
+		if salary.EffectiveFrom.After(date) {
+			break
+		}
+		index = i
+	}
+	return index
+}
+
+// SalaryOn returns the monthly salary in force on the given date
+func (c *PayContext) SalaryOn(date time.Time) int {
+	if i := c.salaryIndexOn(date); i >= 0 {
+		return c.Salaries[i].Salary
+	}
+	return 0
+}
+
+// Total sums the amount of every line item of the given type
+func (c *PayContext) Total(itemType string) int {
+	total := 0
+	for _, item := range c.Items {
+		if item.Type == itemType {
+			total += item.Amount
+		}
+	}
+	return total
+}
+
+// TaxableIncome sums the earnings and employer paid benefits subject to income tax, less the
+// taxable deductions which take back part of a taxable earning
+func (c *PayContext) TaxableIncome() int {
+	total := 0
+	for _, item := range c.Items {
+		if !item.Taxable {
+			continue
+		}
+		switch item.Type {
+		case model.PayslipItemEarning, model.PayslipItemEmployerCost:
+			total += item.Amount
+		case model.PayslipItemDeduction:
+			total -= item.Amount
+		}
+	}
+	return total
+}
+
+// HourlyRateOn divides the salary in force on the date into the pay of one hour, the same
+// rate for the hours of overtime paid and the minutes of lateness deducted that day
+func (c *PayContext) HourlyRateOn(date time.Time) (float64, error) {
+	if c.OvertimeDivisor <= 0 {
+		return 0, errors.New("overtime divisor is not configured")
+	}
+	return float64(c.SalaryOn(date)) / c.OvertimeDivisor, nil
+}
+
+// PensionContribution sums the employee pension contributions deducted on this payslip
+func (c *PayContext) PensionContribution() int {
+	total := 0
+	for _, item := range c.Items {
+		for _, code := range pensionContributionCodes {
+			if item.Code == code {
+				total += item.Amount
+			}
+		}
+	}
+	return total
+}
+
+// PayComponent is a single earning or deduction rule of the payroll
+type PayComponent interface {
+	Code() string
+	Sequence() int
+	Evaluate(ctx *PayContext) ([]model.PayslipItem, error)
+}
+
+type PayEngine struct {
+	components []PayComponent
+}
+
+func NewPayEngine(components ...PayComponent) *PayEngine {
+	engine := &PayEngine{}
+	for _, c := range components {
+		engine.Register(c)
+	}
+	return engine
+}
+
+// NewDefaultPayEngine registers the components every payroll run uses
+func NewDefaultPayEngine() *PayEngine {
+	return NewPayEngine(
+		&BaseSalaryComponent{},
+		&OvertimeComponent{},
+		&ReimbursementComponent{},
+		&LatenessComponent{},
+		&BPJSComponent{},
+		&PPh21Component{},
+	)
+}
+
+// NewReimbursementPayEngine registers only the reimbursement, for the payslip of an employee
+// who neither attended nor took paid leave in the period but has claims to be paid, who
+// earns no salary so owes no BPJS or PPh 21
+func NewReimbursementPayEngine() *PayEngine {
+	return NewPayEngine(&ReimbursementComponent{})
+}
+
+func (e *PayEngine) Register(component PayComponent) {
+	e.components = append(e.components, component)
+	sort.SliceStable(e.components, func(i, j int) bool {
+		return e.components[i].Sequence() < e.components[j].Sequence()
+	})
+}
+
+// Evaluate runs every registered component in sequence and appends their line items to the context
+func (e *PayEngine) Evaluate(ctx *PayContext) error {
+	for _, component := range e.components {
+		items, err := component.Evaluate(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if item.Code == "" {
+				item.Code = component.Code()
+			}
+			item.Sequence = len(ctx.Items) + 1
+			ctx.Items = append(ctx.Items, item)
+		}
+	}
+	return nil
+}
+
+// BaseSalaryComponent prorates the salary in force on each attended day, so a salary
+// change in the middle of the period is only paid from its effective date
+type BaseSalaryComponent struct{}
+
+func (c *BaseSalaryComponent) Code() string  { return "BASIC_SALARY" }
+func (c *BaseSalaryComponent) Sequence() int { return SequenceEarning }
+
+func (c *BaseSalaryComponent) Evaluate(ctx *PayContext) ([]model.PayslipItem, error) {
+	divisor, err := ctx.Calendar.Divisor()
+	if err != nil {
+		return nil, err
+	}
+
+	attended := map[string]bool{}
+	for _, date := range ctx.AttendanceDates {
+		attended[date.Format("2006-01-02")] = true
+	}
+	onPaidLeave := map[string]bool{}
+	for _, date := range ctx.PaidLeaveDates {
+		onPaidLeave[date.Format("2006-01-02")] = true
+	}
+
+	// count the paid days of each salary in force during the period, the working days of a
+	// paid leave count as attended while unpaid leave is left unpaid like any other absence
+	days := map[int]int{}
+	segments := []int{}
+	for _, date := range ctx.Calendar.Days() {
+		key := date.Format("2006-01-02")
+		paidLeave := onPaidLeave[key] && ctx.Calendar.IsWorkingDayOf(date, ctx.Roster)
+		if !attended[key] && !paidLeave && !ctx.Calendar.IsPaidDay(date, ctx.Roster) {
+			continue
+		}
+
+		i := ctx.salaryIndexOn(date)
+		if i < 0 {
+			continue
+		}
+		if _, ok := days[i]; !ok {
+			segments = append(segments, i)
+		}
+		days[i]++
+	}
+	sort.Ints(segments)
+
+	// a fixed divisor can be exceeded by the paid days, then the month's pay is shared by the
+	// salaries in force over the paid days so the period never pays more than a month
+	paidDays := 0
+	for _, i := range segments {
+		paidDays += days[i]
+	}
+	if float64(paidDays) > divisor {
+		divisor = float64(paidDays)
+	}
+
+	items := []model.PayslipItem{}
+	for _, i := range segments {
+		salary := ctx.Salaries[i]
+		name := "Basic salary"
+		if len(segments) > 1 {
+			name = fmt.Sprintf("Basic salary from %s", salary.EffectiveFrom.Format("2006-01-02"))
+		}
+
+		amount := int(math.Round(float64(salary.Salary) * float64(days[i]) / divisor))
+		items = append(items, model.PayslipItem{
+			Name:     name,
+			Type:     model.PayslipItemEarning,
+			Quantity: float64(days[i]),
+			Amount:   amount,
+			Taxable:  true,
+		})
+	}
+	return items, nil
+}
+
+// OvertimeComponent pays the overtime hours of each day on the tiers of the day type, at
+// the multiplier of the hourly rate of 1/173 (the configured divisor) of the monthly salary
+type OvertimeComponent struct{}
+
+func (c *OvertimeComponent) Code() string  { return "OVERTIME" }
+func (c *OvertimeComponent) Sequence() int { return SequenceEarning }
+
+func (c *OvertimeComponent) Evaluate(ctx *PayContext) ([]model.PayslipItem, error) {
+	if len(ctx.Overtimes) == 0 {
+		return nil, nil
+	}
+
+	rulesByDayType := map[string][]model.OvertimeRule{}
+	for _, rule := range ctx.OvertimeRules {
+		rulesByDayType[rule.DayType] = append(rulesByDayType[rule.DayType], rule)
+	}
+
+	// the tiers apply to the hours of a day, so the overtime of the same day is added up first
+	type day struct {
+		date    string
+		dayType string
+	}
+	hoursByDay := map[day]float64{}
+	dates := map[string]time.Time{}
+	for _, o := range ctx.Overtimes {
+		key := o.Date.Format("2006-01-02")
+		hoursByDay[day{key, o.DayType}] += o.Hours
+		dates[key] = o.Date
+	}
+
+	// each day is paid at the hourly rate of the salary in force that day
+	hoursByRule := map[string][]float64{}
+	amountByRule := map[string][]float64{}
+	for d, hours := range hoursByDay {
+		rules := rulesByDayType[d.dayType]
+		if len(rules) == 0 {
+			return nil, fmt.Errorf("no overtime rules for day type %s", d.dayType)
+		}
+		hourlyRate, err := ctx.HourlyRateOn(dates[d.date])
+		if err != nil {
+			return nil, err
+		}
+		if hoursByRule[d.dayType] == nil {
+			hoursByRule[d.dayType] = make([]float64, len(rules))
+			amountByRule[d.dayType] = make([]float64, len(rules))
+		}
+		for i, h := range SplitOvertimeHours(rules, hours) {
+			hoursByRule[d.dayType][i] += h
+			amountByRule[d.dayType][i] += rules[i].Multiplier * hourlyRate * h
+		}
+	}
+
+	items := []model.PayslipItem{}
+	for _, dayType := range []string{model.DayTypeWorkday, model.DayTypeRestDay, model.DayTypePublicHoliday} {
+		for i, hours := range hoursByRule[dayType] {
+			if hours == 0 {
+				continue
+			}
+			rule := rulesByDayType[dayType][i]
+			items = append(items, model.PayslipItem{
+				Code:     fmt.Sprintf("OVERTIME_%s_%d", strings.ToUpper(dayType), i+1),
+				Name:     fmt.Sprintf("Overtime %s %gx", strings.ReplaceAll(dayType, "_", " "), rule.Multiplier),
+				Type:     model.PayslipItemEarning,
+				Quantity: hours,
+				Amount:   int(math.Round(amountByRule[dayType][i])),
+				Taxable:  true,
+			})
+		}
+	}
+	return items, nil
+}
+
+// LatenessComponent deducts the minutes checked in late at the per minute rate of the
+// salary in force that day, when the company deducts for lateness. The deduction lowers
+// the taxable income
+type LatenessComponent struct{}
+
+func (c *LatenessComponent) Code() string  { return "LATENESS" }
+func (c *LatenessComponent) Sequence() int { return SequenceEarning }
+
+func (c *LatenessComponent) Evaluate(ctx *PayContext) ([]model.PayslipItem, error) {
+	if !ctx.LatenessDeduction || len(ctx.LateDays) == 0 {
+		return nil, nil
+	}
+
+	minutes, amount := 0, 0.0
+	for _, late := range ctx.LateDays {
+		hourlyRate, err := ctx.HourlyRateOn(late.Date)
+		if err != nil {
+			return nil, err
+		}
+		minutes += late.Minutes
+		amount += hourlyRate / 60 * float64(late.Minutes)
+	}
+
+	return []model.PayslipItem{{
+		Name:     "Lateness",
+		Type:     model.PayslipItemDeduction,
+		Quantity: float64(minutes),
+		Amount:   int(math.Round(amount)),
+		Taxable:  true,
+	}}, nil
+}
+
+// ReimbursementComponent pays back the reimbursements submitted in the period
+type ReimbursementComponent struct{}
+
+func (c *ReimbursementComponent) Code() string  { return "REIMBURSEMENT" }
+func (c *ReimbursementComponent) Sequence() int { return SequenceAllowance }
+
+func (c *ReimbursementComponent) Evaluate(ctx *PayContext) ([]model.PayslipItem, error) {
+	if ctx.ReimbursementTotal == 0 {
+		return nil, nil
+	}
+
+	return []model.PayslipItem{{
+		Name:   "Reimbursement",
+		Type:   model.PayslipItemEarning,
+		Amount: ctx.ReimbursementTotal,
+	}}, nil
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"errors"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLeaveTypeNotFound        = errors.New("leave type not found")
+	ErrLeaveRequestNotFound     = errors.New("leave request not found")
+	ErrInvalidLeaveDates        = errors.New("leave must start before it ends and end by the next year")
+	ErrNoLeaveWorkingDays       = errors.New("leave has no working days")
+	ErrLeaveTooLong             = errors.New("leave is longer than the leave type allows")
+	ErrLeaveOverlap             = errors.New("leave overlaps another leave request")
+	ErrNoLeaveBalance           = errors.New("no leave balance for the year")
+	ErrInsufficientLeaveBalance = errors.New("insufficient leave balance")
+	ErrLeaveNotPending          = errors.New("leave request is no longer pending")
+	ErrLeaveNotCancellable      = errors.New("leave request can only be cancelled before it starts")
+	ErrNotLeaveApprover         = errors.New("only the employee's manager can review this leave request")
+)
+
+type LeaveService interface {
+	RequestLeave(userID uuid.UUID, leaveType string, start, end time.Time, reason, ip string) (*model.LeaveRequest, error)
+	ReviewLeave(requestID, reviewerID uuid.UUID, reviewerRole string, approve bool, note string) (*model.LeaveRequest, error)
+	CancelLeave(requestID, userID uuid.UUID) (*model.LeaveRequest, error)
+}
+
+type LeaveServiceImpl struct {
+	LeaveRepo  repository.LeaveRepository
+	UnitOfWork repository.UnitOfWork
+}
+
+func NewLeaveService(repo repository.LeaveRepository, uow repository.UnitOfWork) LeaveService {
+	return &LeaveServiceImpl{LeaveRepo: repo, UnitOfWork: uow}
+}
+
+// CountLeaveDays counts the working days of the leave, holidays are not taken from the balance.
+// An employee with roster dates works the dates they're rostered on, the others the weekdays
+func CountLeaveDays(start, end time.Time, holidays []model.Holiday, rostered []time.Time) int {
+	period := &model.AttendancePeriod{StartDate: start, EndDate: end}
+	calendar := NewWorkCalendar(period, &model.CompanySettings{}, holidays)
+	return countWorkingDays(calendar, NewRosterDays(rostered), calendar.Days())
+}
+
+// leaveYear is the part of a leave taken from the balance of one year
+type leaveYear struct {
+	Year int
+	Days int
+}
+
+// leaveYears splits the days of the leave over the years it falls in, a leave over the year end
+// takes its days after new year from the next year's balance
+func leaveYears(request *model.LeaveRequest) []leaveYear {
+	years := []leaveYear{}
+	if days := request.Days - request.NextYearDays; days > 0 {
+		years = append(years, leaveYear{Year: request.StartDate.Year(), Days: days})
+	}
+	if request.NextYearDays > 0 {
+		years = append(years, leaveYear{Year: request.EndDate.Year(), Days: request.NextYearDays})
+	}
+	return years
+}
+
+func (s *LeaveServiceImpl) RequestLeave(userID uuid.UUID, leaveType string, start, end time.Time, reason, ip string) (*model.LeaveRequest, error) {
+	if end.Before(start) || end.Year() > start.Year()+1 {
+		return nil, ErrInvalidLeaveDates
+	}
+
+	lt, err := s.LeaveRepo.GetLeaveType(leaveType)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLeaveTypeNotFound
+		}
+		return nil, err
+	}
+
+	holidays, err := s.LeaveRepo.GetHolidays(start, end)
+	if err != nil {
+		return nil, err
+	}
+	rostered, err := s.LeaveRepo.GetRosterDates(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	days := CountLeaveDays(start, end, holidays, rostered)
+	nextYearDays := 0
+	if end.Year() != start.Year() {
+		newYear := time.Date(end.Year(), time.January, 1, 0, 0, 0, 0, end.Location())
+		nextYearDays = CountLeaveDays(newYear, end, holidays, rostered)
+	}
+	if days == 0 {
+		return nil, ErrNoLeaveWorkingDays
+	}
+	if lt.MaxDaysPerRequest != nil && days > *lt.MaxDaysPerRequest {
+		return nil, ErrLeaveTooLong
+	}
+
+	// the days paid out by a payroll can't be changed anymore
+	locked, err := s.LeaveRepo.IsPeriodLocked(start, end)
+	if err != nil {
+		return nil, err
+	}
+	if locked {
+		return nil, repository.ErrPeriodLocked
+	}
+
+	overlap, err := s.LeaveRepo.HasOverlappingLeave(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if overlap {
+		return nil, ErrLeaveOverlap
+	}
+
+	request := &model.LeaveRequest{
+		ID:           uuid.New(),
+		UserID:       userID,
+		LeaveType:    lt.Code,
+		StartDate:    start,
+		EndDate:      end,
+		Days:         days,
+		NextYearDays: nextYearDays,
+		Reason:       reason,
+		Status:       model.LeavePending,
+		CreatedBy:    userID,
+		RequestIP:    ip,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+
+	// the balances are checked again on approval, when the days are taken
+	if lt.Accrues {
+		for _, part := range leaveYears(request) {
+			balance, err := s.LeaveRepo.GetLeaveBalance(userID, lt.Code, part.Year)
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, ErrNoLeaveBalance
+				}
+				return nil, err
+			}
+			if balance.Remaining() < part.Days {
+				return nil, ErrInsufficientLeaveBalance
+			}
+		}
+	}
+
+	if err := s.LeaveRepo.SaveLeaveRequest(request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// ReviewLeave approves or rejects a pending request. The employee's manager reviews it,
+// admins review the requests of employees without a manager
+func (s *LeaveServiceImpl) ReviewLeave(requestID, reviewerID uuid.UUID, reviewerRole string, approve bool, note string) (*model.LeaveRequest, error) {
+	var request *model.LeaveRequest
+	err := s.UnitOfWork.Do(func(repos *repository.Repositories) error {
+		var err error
+		request, err = repos.Leave.GetLeaveRequest(requestID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrLeaveRequestNotFound
+			}
+			return err
+		}
+		if request.Status != model.LeavePending {
+			return ErrLeaveNotPending
+		}
+
+		employee, err := repos.Leave.GetUser(request.UserID)
+		if err != nil {
+			return err
+		}
+		isManager := employee.ManagerID != nil && *employee.ManagerID == reviewerID
+		isFallbackAdmin := employee.ManagerID == nil && reviewerRole == "admin"
+		if !isManager && !isFallbackAdmin {
+			return ErrNotLeaveApprover
+		}
+
+		now := time.Now()
+		request.ReviewedBy = &reviewerID
+		request.ReviewedAt = &now
+		request.ReviewNote = note
+		request.UpdatedAt = now
+
+		if !approve {
+			request.Status = model.LeaveRejected
+			return repos.Leave.UpdateLeaveRequest(request)
+		}
+
+		locked, err := repos.Leave.IsPeriodLocked(request.StartDate, request.EndDate)
+		if err != nil {
+			return err
+		}
+		if locked {
+			return repository.ErrPeriodLocked
+		}
+
+		if err := takeLeaveDays(repos.Leave, request, 1); err != nil {
+			return err
+		}
+
+		request.Status = model.LeaveApproved
+		return repos.Leave.UpdateLeaveRequest(request)
+	})
+	return request, err
+}
+
+// CancelLeave withdraws the employee's own request, an approved leave gives its days back to the balance
+func (s *LeaveServiceImpl) CancelLeave(requestID, userID uuid.UUID) (*model.LeaveRequest, error) {
+	var request *model.LeaveRequest
+	err := s.UnitOfWork.Do(func(repos *repository.Repositories) error {
+		var err error
+		request, err = repos.Leave.GetLeaveRequest(requestID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrLeaveRequestNotFound
+			}
+			return err
+		}
+		if request.UserID != userID {
+			return ErrLeaveRequestNotFound
+		}
+
+		switch request.Status {
+		case model.LeavePending:
+		case model.LeaveApproved:
+			if !request.StartDate.After(time.Now()) {
+				return ErrLeaveNotCancellable
+			}
+			if err := takeLeaveDays(repos.Leave, request, -1); err != nil {
+				return err
+			}
+		default:
+			return ErrLeaveNotPending
+		}
+
+		request.Status = model.LeaveCancelled
+		request.UpdatedAt = time.Now()
+		return repos.Leave.UpdateLeaveRequest(request)
+	})
+	return request, err
+}
+
+// takeLeaveDays moves the days of an accruing leave type out of the employee's balances, or back
+// in with a negative sign
+func takeLeaveDays(repo repository.LeaveRepository, request *model.LeaveRequest, sign int) error {
+	lt, err := repo.GetLeaveType(request.LeaveType)
+	if err != nil {
+		return err
+	}
+	if !lt.Accrues {
+		return nil
+	}
+
+	for _, part := range leaveYears(request) {
+		balance, err := repo.GetLeaveBalance(request.UserID, lt.Code, part.Year)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoLeaveBalance
+			}
+			return err
+		}
+		days := sign * part.Days
+		if balance.Remaining() < days {
+			return ErrInsufficientLeaveBalance
+		}
+
+		balance.Used += days
+		if err := repo.UpdateLeaveBalance(balance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
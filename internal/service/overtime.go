@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOvertimeNotFound     = errors.New("overtime not found")
+	ErrOvertimeNotPending   = errors.New("overtime is no longer pending")
+	ErrNotOvertimeReviewer  = errors.New("only the employee's manager or an admin can review this overtime")
+	ErrOvertimeOverlap      = errors.New("overtime overlaps overtime already submitted")
+	ErrInvalidOvertimeTimes = errors.New("overtime start and end time must be HH:MM")
+	ErrInvalidOvertimeRules = errors.New("overtime rules must be contiguous tiers from hour 0 with an open last tier")
+)
+
+type OvertimeService interface {
+	ReviewOvertime(overtimeID, reviewerID uuid.UUID, reviewerRole string, approve bool, note string) (*model.Overtime, error)
+	WithdrawOvertime(overtimeID, userID uuid.UUID) (*model.Overtime, error)
+}
+
+type OvertimeServiceImpl struct {
+	UnitOfWork repository.UnitOfWork
+}
+
+func NewOvertimeService(uow repository.UnitOfWork) OvertimeService {
+	return &OvertimeServiceImpl{UnitOfWork: uow}
+}
+
+// ReviewOvertime approves or rejects pending overtime, only approved overtime is paid by the payroll
+func (s *OvertimeServiceImpl) ReviewOvertime(overtimeID, reviewerID uuid.UUID, reviewerRole string, approve bool, note string) (*model.Overtime, error) {
+	var overtime *model.Overtime
+	err := s.UnitOfWork.Do(func(repos *repository.Repositories) error {
+		var err error
+		overtime, err = repos.Overtime.GetOvertime(overtimeID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOvertimeNotFound
+			}
+			return err
+		}
+		if overtime.Status != model.OvertimePending {
+			return ErrOvertimeNotPending
+		}
+
+		if reviewerRole != "admin" {
+			employee, err := repos.Overtime.GetUser(overtime.UserID)
+			if err != nil {
+				return err
+			}
+			if employee.ManagerID == nil || *employee.ManagerID != reviewerID {
+				return ErrNotOvertimeReviewer
+			}
+		}
+
+		// the payroll of a locked period was run without this overtime
+		if approve {
+			locked, err := repos.Overtime.IsPeriodLocked(overtime.Date)
+			if err != nil {
+				return err
+			}
+			if locked {
+				return repository.ErrPeriodLocked
+			}
+		}
+
+		now := time.Now()
+		overtime.Status = model.OvertimeRejected
+		if approve {
+			overtime.Status = model.OvertimeApproved
+		}
+		overtime.ReviewedBy = &reviewerID
+		overtime.ReviewedAt = &now
+		overtime.ReviewNote = note
+		overtime.UpdatedAt = now
+		return repos.Overtime.UpdateOvertime(overtime)
+	})
+	return overtime, err
+}
+
+// WithdrawOvertime lets the employee take back their own overtime while it is pending
+func (s *OvertimeServiceImpl) WithdrawOvertime(overtimeID, userID uuid.UUID) (*model.Overtime, error) {
+	var overtime *model.Overtime
+	err := s.UnitOfWork.Do(func(repos *repository.Repositories) error {
+		var err error
+		overtime, err = repos.Overtime.GetOvertime(overtimeID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOvertimeNotFound
+			}
+			return err
+		}
+		if overtime.UserID != userID {
+			return ErrOvertimeNotFound
+		}
+		if overtime.Status != model.OvertimePending {
+			return ErrOvertimeNotPending
+		}
+
+		overtime.Status = model.OvertimeWithdrawn
+		overtime.UpdatedAt = time.Now()
+		return repos.Overtime.UpdateOvertime(overtime)
+	})
+	return overtime, err
+}
+
+// IsValidDayType reports if the day type is one overtime can be worked on
+func IsValidDayType(dayType string) bool {
+	switch dayType {
+	case model.DayTypeWorkday, model.DayTypeRestDay, model.DayTypePublicHoliday:
+		return true
+	}
+	return false
+}
+
+// ValidateOvertimeRules checks the tiers of one day type, sorted by their first hour, start at
+// hour 0, follow each other without gaps and end with a tier without an upper hour
+func ValidateOvertimeRules(rules []model.OvertimeRule) error {
+	if len(rules) == 0 {
+		return ErrInvalidOvertimeRules
+	}
+	next := 0
+	for i, rule := range rules {
+		if rule.FromHour != next || rule.Multiplier <= 0 {
+			return ErrInvalidOvertimeRules
+		}
+		last := i == len(rules)-1
+		if last != (rule.ToHour == nil) {
+			return ErrInvalidOvertimeRules
+		}
+		if !last {
+			if *rule.ToHour <= rule.FromHour {
+				return ErrInvalidOvertimeRules
+			}
+			next = *rule.ToHour
+		}
+	}
+	return nil
+}
+
+// SplitOvertimeHours spreads the overtime hours of one day over the tiers of its day type,
+// the result holds the hours paid at each rule in the order of the rules
+func SplitOvertimeHours(rules []model.OvertimeRule, hours float64) []float64 {
+	split := make([]float64, len(rules))
+	for i, rule := range rules {
+		if hours <= float64(rule.FromHour) {
+			break
+		}
+		upper := hours
+		if rule.ToHour != nil && float64(*rule.ToHour) < upper {
+			upper = float64(*rule.ToHour)
+		}
+		split[i] = upper - float64(rule.FromHour)
+	}
+	return split
+}
+
+// OvertimeLimitError reports the daily or weekly overtime maximum a submission goes over
+type OvertimeLimitError struct {
+	Period    string
+	Max       float64
+	Submitted float64
+}
+
+func (e *OvertimeLimitError) Error() string {
+	return fmt.Sprintf("overtime exceeds the %s maximum of %g hours, %g hours are already submitted", e.Period, e.Max, e.Submitted)
+}
+
+// OvertimeWindow returns when the overtime of the date starts and ends, an end time at or
+// before the start time ends on the next day
+func OvertimeWindow(date time.Time, startTime, endTime string, loc *time.Location) (time.Time, time.Time, error) {
+	start, err1 := ParseTimeOfDay(startTime)
+	end, err2 := ParseTimeOfDay(endTime)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, time.Time{}, ErrInvalidOvertimeTimes
+	}
+	if end <= start {
+		end += 24 * time.Hour
+	}
+	day := Midnight(date, loc)
+	return day.Add(start), day.Add(end), nil
+}
+
+// OvertimeHours converts the time between start and end into hours rounded to the minute
+func OvertimeHours(start, end time.Time) float64 {
+	minutes := math.Round(end.Sub(start).Minutes())
+	return math.Round(minutes/60*100) / 100
+}
+
+// WeekOf returns the monday and sunday of the week of the date
+func WeekOf(date time.Time) (time.Time, time.Time) {
+	offset := (int(date.Weekday()) + 6) % 7
+	monday := date.AddDate(0, 0, -offset)
+	return monday, monday.AddDate(0, 0, 6)
+}
+
+// CheckOvertimeLimits checks the overtime against the pending and approved overtime the employee
+// already submitted in its week, it can't overlap them nor go over the daily and weekly maximum
+func CheckOvertimeLimits(existing []model.Overtime, overtime *model.Overtime, maxPerDay, maxPerWeek float64) error {
+	day, week := 0.0, 0.0
+	for _, o := range existing {
+		if o.Status != model.OvertimePending && o.Status != model.OvertimeApproved {
+			continue
+		}
+		if o.StartAt != nil && o.EndAt != nil && overtime.StartAt != nil && overtime.EndAt != nil &&
+			o.StartAt.Before(*overtime.EndAt) && overtime.StartAt.Before(*o.EndAt) {
+			return ErrOvertimeOverlap
+		}
+		if o.Date.Equal(overtime.Date) {
+			day += o.Hours
+		}
+		week += o.Hours
+	}
+
+	if maxPerDay > 0 && day+overtime.Hours > maxPerDay {
+		return &OvertimeLimitError{Period: "daily", Max: maxPerDay, Submitted: day}
+	}
+	if maxPerWeek > 0 && week+overtime.Hours > maxPerWeek {
+		return &OvertimeLimitError{Period: "weekly", Max: maxPerWeek, Submitted: week}
+	}
+	return nil
+}
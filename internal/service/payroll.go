@@ -4,32 +4,68 @@ import (
 	"errors"
 	"payslip-generation-system/internal/model"
 	"payslip-generation-system/internal/repository"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type PayrollService interface {
 	ProcessPayroll(periodID, createdBy uuid.UUID, ip, requestID string) error
+	PreviewPayroll(periodID uuid.UUID) (*PayrollPreview, error)
+	ReversePayroll(payrollID, voidedBy uuid.UUID, reason, ip, requestID string) error
+	TransitionPayroll(payrollID, performedBy uuid.UUID, status, ip, requestID string) error
+	UnlockPeriod(periodID, unlockedBy uuid.UUID, reason, ip, requestID string) error
 }
 
+// PayrollPreview is the outcome of a payroll calculation that was not saved
+type PayrollPreview struct {
+	Payroll  *model.Payroll
+	Payslips []*model.Payslip
+	Totals   PayrollTotals
+	// usernames and warnings of the payslips keyed by employee
+	Usernames map[uuid.UUID]string
+	Warnings  map[uuid.UUID][]string
+}
+
+type PayrollTotals struct {
+	Employees       int
+	GrossPay        int
+	TaxAmount       int
+	TotalDeductions int
+	NetPay          int
+	EmployerCost    int
+}
+
+var (
+	ErrPeriodNotFound          = errors.New("attendance period not found")
+	ErrPayrollAlreadyProcessed = errors.New("payroll already processed for this period")
+	ErrPayrollNotFound         = errors.New("payroll not found")
+	ErrPayrollAlreadyVoided    = errors.New("payroll already voided")
+	ErrPeriodNotLocked         = errors.New("attendance period is not locked")
+)
+
 type PayrollServiceImpl struct {
 	PayrollRepo repository.PayrollRepository
+	UnitOfWork  repository.UnitOfWork
+	Engine      *PayEngine
 }
 
-func NewPayrollService(repo repository.PayrollRepository) PayrollService {
-	return &PayrollServiceImpl{PayrollRepo: repo}
+func NewPayrollService(repo repository.PayrollRepository, uow repository.UnitOfWork) PayrollService {
+	return &PayrollServiceImpl{PayrollRepo: repo, UnitOfWork: uow, Engine: NewDefaultPayEngine()}
 }
 
 func (s *PayrollServiceImpl) ProcessPayroll(periodID, createdBy uuid.UUID, ip, requestID string) error {
 	// check if attendance period is exist
-	found, err := s.PayrollRepo.FindAttendancePeriod(periodID)
+	period, err := s.PayrollRepo.GetAttendancePeriod(periodID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPeriodNotFound
+		}
 		return err
 	}
-	if !found {
-		return errors.New("attendance period not found")
-	}
 
 	// check if payroll already processed
 	exists, err := s.PayrollRepo.IsPayrollRun(periodID)
@@ -37,49 +73,312 @@ func (s *PayrollServiceImpl) ProcessPayroll(periodID, createdBy uuid.UUID, ip, r
 		return err
 	}
 	if exists {
-		return errors.New("payroll already processed for this period")
+		return ErrPayrollAlreadyProcessed
 	}
 
-	// get all employees attendance in given period
-	attendances, err := s.PayrollRepo.GetAttendances(periodID)
+	// prepare the payroll, it is only saved together with its payslips
+	payroll := &model.Payroll{
+		ID:        uuid.New(),
+		PeriodID:  periodID,
+		Status:    model.PayrollDraft,
+		CreatedBy: createdBy,
+		RequestIP: ip,
+		CreatedAt: time.Now(),
+	}
+
+	// logging the process for audit purpose
+	audit := model.AuditLog{
+		ID:          uuid.New(),
+		TableName:   "payrolls",
+		RecordID:    payroll.ID,
+		Action:      "CREATE",
+		PerformedBy: createdBy,
+		RequestIP:   ip,
+		RequestID:   requestID,
+		Timestamp:   time.Now(),
+	}
+
+	// lock the period, calculate and persist the payroll, its payslips and the audit log all or nothing
+	return s.UnitOfWork.Do(func(repos *repository.Repositories) error {
+		// locking first waits for the employee submissions in flight, so the calculation sees all of them
+		if err := repos.Payroll.LockAttendancePeriod(periodID, createdBy); err != nil {
+			return err
+		}
+
+		payslips, reimbursementIDs, err := s.calculatePayroll(repos.Payroll, period, payroll)
+		if err != nil {
+			return err
+		}
+
+		// a re-run of a reversed period keeps a reference to the payroll and payslips it replaces
+		if err := linkSupersededPayroll(repos.Payroll, payroll, payslips); err != nil {
+			return err
+		}
+
+		if err := repos.Payroll.CreatePayroll(payroll); err != nil {
+			// another run of the same period committed first
+			if strings.Contains(err.Error(), "duplicate key") {
+				return ErrPayrollAlreadyProcessed
+			}
+			return err
+		}
+
+		for _, p := range payslips {
+			if err := repos.Payroll.CreatePayslip(p); err != nil {
+				return err
+			}
+		}
+
+		// the draft is calculated once all of its payslips are saved
+		payroll.Status = model.PayrollCalculated
+		if err := repos.Payroll.UpdatePayrollStatus(payroll); err != nil {
+			return err
+		}
+
+		if err := repos.Payroll.MarkReimbursementsPaid(reimbursementIDs, payroll.ID, periodID); err != nil {
+			return err
+		}
+
+		return repos.Payroll.CreateAuditLog(&audit)
+	})
+}
+
+// ReversePayroll voids a payroll so its period can be run again, the payroll and its
+// payslips are kept in history with the reason of the reversal
+func (s *PayrollServiceImpl) ReversePayroll(payrollID, voidedBy uuid.UUID, reason, ip, requestID string) error {
+	return s.changePayrollStatus(payrollID, voidedBy, model.PayrollVoided, reason, ip, requestID)
+}
+
+// UnlockPeriod lets the employees submit for the dates of a period again, the lock is
+// set back by the next payroll run of the period
+func (s *PayrollServiceImpl) UnlockPeriod(periodID, unlockedBy uuid.UUID, reason, ip, requestID string) error {
+	return s.UnitOfWork.Do(func(repos *repository.Repositories) error {
+		period, err := repos.Payroll.GetAttendancePeriod(periodID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPeriodNotFound
+			}
+			return err
+		}
+		if period.LockedAt == nil {
+			return ErrPeriodNotLocked
+		}
+
+		if err := repos.Payroll.UnlockAttendancePeriod(periodID); err != nil {
+			return err
+		}
+
+		return repos.Payroll.CreateAuditLog(&model.AuditLog{
+			ID:          uuid.New(),
+			TableName:   "attendance_periods",
+			RecordID:    periodID,
+			Action:      "UPDATE",
+			PerformedBy: unlockedBy,
+			RequestIP:   ip,
+			RequestID:   requestID,
+			Reason:      reason,
+			Timestamp:   time.Now(),
+		})
+	})
+}
+
+// linkSupersededPayroll points the payroll and the payslips of each employee to the
+// ones of the last voided payroll of the same period, if any
+func linkSupersededPayroll(repo repository.PayrollRepository, payroll *model.Payroll, payslips []*model.Payslip) error {
+	voided, err := repo.GetLastVoidedPayroll(payroll.PeriodID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
 		return err
 	}
+	payroll.SupersedesPayrollID = &voided.ID
 
-	// get all employees overtime hours in given period
-	overtimes, err := s.PayrollRepo.GetOvertimes(periodID)
+	previous, err := repo.GetPayslips(voided.ID)
 	if err != nil {
 		return err
 	}
+	previousMap := map[uuid.UUID]uuid.UUID{}
+	for _, p := range previous {
+		previousMap[p.UserID] = p.ID
+	}
+	for _, p := range payslips {
+		if id, ok := previousMap[p.UserID]; ok {
+			p.SupersedesPayslipID = &id
+		}
+	}
+	return nil
+}
+
+// PreviewPayroll calculates the payslips of a period like ProcessPayroll without saving anything
+func (s *PayrollServiceImpl) PreviewPayroll(periodID uuid.UUID) (*PayrollPreview, error) {
+	period, err := s.PayrollRepo.GetAttendancePeriod(periodID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPeriodNotFound
+		}
+		return nil, err
+	}
+
+	payroll := &model.Payroll{PeriodID: periodID}
+	payslips, _, err := s.calculatePayroll(s.PayrollRepo, period, payroll)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &PayrollPreview{
+		Payroll:   payroll,
+		Payslips:  payslips,
+		Usernames: map[uuid.UUID]string{},
+		Warnings:  map[uuid.UUID][]string{},
+	}
+
+	userIDs := []uuid.UUID{}
+	for _, p := range payslips {
+		userIDs = append(userIDs, p.UserID)
+	}
+	users, err := s.PayrollRepo.GetUsers(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		preview.Usernames[u.ID] = u.Username
+	}
+	sort.Slice(payslips, func(i, j int) bool {
+		return preview.Usernames[payslips[i].UserID] < preview.Usernames[payslips[j].UserID]
+	})
+
+	for _, p := range payslips {
+		preview.Totals.Employees++
+		preview.Totals.GrossPay += p.GrossPay
+		preview.Totals.TaxAmount += p.TaxAmount
+		preview.Totals.TotalDeductions += p.TotalDeductions
+		preview.Totals.NetPay += p.NetPay
+		preview.Totals.EmployerCost += p.EmployerCost
+
+		if warnings := payslipWarnings(payroll, p); len(warnings) > 0 {
+			preview.Warnings[p.UserID] = warnings
+		}
+	}
+	return preview, nil
+}
+
+// payslipWarnings flags the numbers of a payslip finance should look at before running the payroll
+func payslipWarnings(payroll *model.Payroll, p *model.Payslip) []string {
+	warnings := []string{}
+	if p.BaseSalary == 0 {
+		warnings = append(warnings, "no salary in force at the end of the period")
+	}
+	if p.AttendanceDays > payroll.WorkingDays {
+		warnings = append(warnings, "attended more days than the working days of the period")
+	}
+	if p.NetPay < 0 {
+		warnings = append(warnings, "net pay is negative")
+	}
+	return warnings
+}
+
+// calculatePayroll evaluates the payslip of every employee of the period and records
+// the calendar used on the payroll, nothing is saved. It also returns the reimbursements
+// the payslips pay
+func (s *PayrollServiceImpl) calculatePayroll(repo repository.PayrollRepository, period *model.AttendancePeriod, payroll *model.Payroll) ([]*model.Payslip, []uuid.UUID, error) {
+	periodID := period.ID
+
+	// get all employees attendance in given period
+	attendances, err := repo.GetAttendances(periodID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// get all employees overtime hours in given period
+	overtimes, err := repo.GetOvertimes(periodID)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	// get all reimbursement of employee in given period
-	reimbursements, err := s.PayrollRepo.GetReimbursements(periodID)
+	reimbursements, err := repo.GetReimbursements(periodID)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	// get the approved leave overlapping the period
+	leaves, err := repo.GetApprovedLeaves(period.StartDate, period.EndDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// get the shifts rostered in the period, a shift worker works only the rostered dates
+	rosters, err := repo.GetRosters(period.StartDate, period.EndDate)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// aggregate data
-	attendanceMap := map[uuid.UUID]int{}
-	overtimeMap := map[uuid.UUID]int{}
+	paidLeaveMap := map[uuid.UUID][]time.Time{}
+	unpaidLeaveMap := map[uuid.UUID][]time.Time{}
+	attendanceMap := map[uuid.UUID][]time.Time{}
+	overtimeMap := map[uuid.UUID][]model.Overtime{}
 	reimbursementMap := map[uuid.UUID]int{}
-	baseSalaryMap := map[uuid.UUID]int{}
+	userMap := map[uuid.UUID]model.User{}
+	salaryMap := map[uuid.UUID][]model.SalaryHistory{}
+	taxYearToDateMap := map[uuid.UUID]model.TaxYearToDate{}
+	rosterMap := map[uuid.UUID][]time.Time{}
 	uniqueUserIDs := map[uuid.UUID]bool{}
 
+	// mapping the rostered dates of employee
+	for _, r := range rosters {
+		rosterMap[r.UserID] = append(rosterMap[r.UserID], r.Date)
+	}
+
 	// mapping the attendance of employee
 	for _, a := range attendances {
-		attendanceMap[a.UserID]++
+		attendanceMap[a.UserID] = append(attendanceMap[a.UserID], a.Date)
 		uniqueUserIDs[a.UserID] = true
 	}
 
+	// mapping the leave days of employee inside the period, an employee on paid leave
+	// for the whole period still gets a payslip
+	for _, l := range leaves {
+		paid := l.Type != nil && l.Type.Paid
+		for date := l.StartDate; !date.After(l.EndDate); date = date.AddDate(0, 0, 1) {
+			if date.Before(period.StartDate) || date.After(period.EndDate) {
+				continue
+			}
+			if paid {
+				paidLeaveMap[l.UserID] = append(paidLeaveMap[l.UserID], date)
+			} else {
+				unpaidLeaveMap[l.UserID] = append(unpaidLeaveMap[l.UserID], date)
+			}
+		}
+		if _, ok := attendanceMap[l.UserID]; !ok && paid {
+			attendanceMap[l.UserID] = nil
+			uniqueUserIDs[l.UserID] = true
+		}
+	}
+
 	// mapping the overtime of employee
 	for _, o := range overtimes {
-		overtimeMap[o.UserID] += o.Hours
+		overtimeMap[o.UserID] = append(overtimeMap[o.UserID], o)
 		uniqueUserIDs[o.UserID] = true
 	}
 
-	// mapping the reimbursement of employee
+	// mapping the reimbursement of employee, an employee with a claim to pay gets a payslip
+	// even without attendance in the period
+	reimbursementIDs := []uuid.UUID{}
+	reimbursementOnly := map[uuid.UUID]bool{}
 	for _, r := range reimbursements {
-		reimbursementMap[r.UserID] += r.Amount
+		// a partly approved claim pays only the approved amount
+		amount := r.Amount
+		if r.ApprovedAmount != nil {
+			amount = *r.ApprovedAmount
+		}
+		reimbursementMap[r.UserID] += amount
+		reimbursementIDs = append(reimbursementIDs, r.ID)
+		if _, ok := attendanceMap[r.UserID]; !ok {
+			attendanceMap[r.UserID] = nil
+			reimbursementOnly[r.UserID] = true
+		}
 		uniqueUserIDs[r.UserID] = true
 	}
 
@@ -89,68 +388,148 @@ func (s *PayrollServiceImpl) ProcessPayroll(periodID, createdBy uuid.UUID, ip, r
 		userIDs = append(userIDs, id)
 	}
 
-	// get base salary of each employee
-	users, err := s.PayrollRepo.GetUserSalary(userIDs)
+	// get the tax and BPJS profile of each employee
+	users, err := repo.GetUsers(userIDs)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	// mapping the base salary
+	// mapping the tax profile
 	for _, u := range users {
-		baseSalaryMap[u.ID] = u.Salary
+		userMap[u.ID] = u
 	}
 
-	// input the payroll
-	payroll := &model.Payroll{
-		ID:        uuid.New(),
-		PeriodID:  periodID,
-		CreatedBy: createdBy,
-		RequestIP: ip,
-		CreatedAt: time.Now(),
+	// get the salaries in force up to the end of the period
+	salaries, err := repo.GetSalaryHistories(userIDs, period.EndDate)
+	if err != nil {
+		return nil, nil, err
 	}
-	if err := s.PayrollRepo.CreatePayroll(payroll); err != nil {
-		return err
+	for _, h := range salaries {
+		salaryMap[h.UserID] = append(salaryMap[h.UserID], h)
 	}
 
-	// calculate overtime, reimburse, and prorated salary to input payslip of each employee
-	for userID, days := range attendanceMap {
-		salary := baseSalaryMap[userID]
-		overtimeHrs := overtimeMap[userID]
-		reimburse := reimbursementMap[userID]
+	// get the taxable income and tax withheld earlier this year for the december reconciliation
+	yearToDate, err := repo.GetTaxYearToDate(userIDs, period)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, ytd := range yearToDate {
+		taxYearToDateMap[ytd.UserID] = ytd
+	}
 
-		// assuming 20 working days per month
-		prorated := (salary * days) / 20
-		hourlyRate := salary / (20 * 8)
-		overtimePay := 2 * hourlyRate * overtimeHrs
-		total := prorated + overtimePay + reimburse
+	// build the working day calendar of the period
+	settings, err := repo.GetCompanySettings()
+	if err != nil {
+		return nil, nil, err
+	}
+	holidays, err := repo.GetHolidays(period.StartDate, period.EndDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	calendar := NewWorkCalendar(period, settings, holidays)
+	divisor, err := calendar.Divisor()
+	if err != nil {
+		return nil, nil, err
+	}
 
-		p := &model.Payslip{
-			ID:                 uuid.New(),
-			PayrollID:          payroll.ID,
-			UserID:             userID,
-			BaseSalary:         salary,
-			AttendanceDays:     days,
-			ProratedSalary:     prorated,
-			OvertimeHours:      overtimeHrs,
-			OvertimePay:        overtimePay,
-			ReimbursementTotal: reimburse,
-			TakeHomePay:        total,
+	// get the overtime tiers of each day type
+	overtimeRules, err := repo.GetOvertimeRules()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// get the BPJS contribution rates in force at the end of the period
+	bpjsRates, err := repo.GetBPJSRates(period.EndDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payroll.ProrationBasis = calendar.Basis
+	payroll.WorkingDays = calendar.WorkingDays()
+	payroll.ProrationDivisor = divisor
+	payroll.HoursPerDay = calendar.HoursPerDay
+
+	// the minutes late beyond the grace minutes of each day
+	lateMap := map[uuid.UUID][]LateDay{}
+	for _, a := range attendances {
+		if late := a.LateMinutes - settings.LatenessGraceMinutes; late > 0 {
+			lateMap[a.UserID] = append(lateMap[a.UserID], LateDay{Date: a.Date, Minutes: late})
 		}
-		s.PayrollRepo.CreatePayslip(p)
 	}
 
-	// logging the process for audit purpose
-	audit := model.AuditLog{
-		ID:          uuid.New(),
-		TableName:   "payrolls",
-		RecordID:    payroll.ID,
-		Action:      "CREATE",
-		PerformedBy: createdBy,
-		RequestIP:   ip,
-		RequestID:   requestID,
-		Timestamp:   time.Now(),
+	// evaluate the pay components of each employee to input their payslip
+	reimbursementEngine := NewReimbursementPayEngine()
+	payslips := []*model.Payslip{}
+	for userID, dates := range attendanceMap {
+		user := userMap[userID]
+		ctx := &PayContext{
+			UserID:             userID,
+			AttendanceDays:     len(dates),
+			AttendanceDates:    dates,
+			PaidLeaveDates:     paidLeaveMap[userID],
+			Roster:             NewRosterDays(rosterMap[userID]),
+			LateDays:           lateMap[userID],
+			LatenessDeduction:  settings.LatenessDeduction,
+			Calendar:           calendar,
+			OvertimeRules:      overtimeRules,
+			OvertimeDivisor:    settings.OvertimeDivisor,
+			Salaries:           salaryMap[userID],
+			Overtimes:          overtimeMap[userID],
+			ReimbursementTotal: reimbursementMap[userID],
+			PeriodEnd:          period.EndDate,
+			PTKPStatus:         user.PTKPStatus,
+			HasNPWP:            user.NPWP != "",
+			PriorTaxableIncome: taxYearToDateMap[userID].TaxableIncome,
+			PriorTaxWithheld:   taxYearToDateMap[userID].TaxAmount,
+			PriorPension:       taxYearToDateMap[userID].PensionContribution,
+			JKKRiskClass:       user.JKKRiskClass,
+			BPJSRates:          bpjsRates,
+		}
+		ctx.BaseSalary = ctx.SalaryOn(period.EndDate)
+		for _, o := range ctx.Overtimes {
+			ctx.OvertimeHours += o.Hours
+		}
+		engine := s.Engine
+		if reimbursementOnly[userID] {
+			engine = reimbursementEngine
+		}
+		if err := engine.Evaluate(ctx); err != nil {
+			return nil, nil, err
+		}
+
+		gross := ctx.Total(model.PayslipItemEarning)
+		deductions := ctx.Total(model.PayslipItemDeduction)
+
+		p := &model.Payslip{
+			ID:              uuid.New(),
+			PayrollID:       payroll.ID,
+			UserID:          userID,
+			BaseSalary:      ctx.BaseSalary,
+			AttendanceDays:  ctx.AttendanceDays,
+			PaidLeaveDays:   countWorkingDays(calendar, ctx.Roster, ctx.PaidLeaveDates),
+			UnpaidLeaveDays: countWorkingDays(calendar, ctx.Roster, unpaidLeaveMap[userID]),
+			OvertimeHours:   ctx.OvertimeHours,
+			GrossPay:        gross,
+			TaxableIncome:   ctx.TaxableIncome(),
+			TaxAmount:       ctx.TaxAmount,
+			TotalDeductions: deductions,
+			NetPay:          gross - deductions,
+			EmployerCost:    ctx.Total(model.PayslipItemEmployerCost),
+			Items:           ctx.Items,
+		}
+		payslips = append(payslips, p)
 	}
-	_ = s.PayrollRepo.CreateAuditLog(&audit).Error
 
-	return nil
+	return payslips, reimbursementIDs, nil
+}
+
+// countWorkingDays counts the dates that are working days of the employee with the roster
+func countWorkingDays(calendar *WorkCalendar, roster RosterDays, dates []time.Time) int {
+	count := 0
+	for _, date := range dates {
+		if calendar.IsWorkingDayOf(date, roster) {
+			count++
+		}
+	}
+	return count
 }
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPayrollStatus     = errors.New("invalid payroll status")
+	ErrInvalidPayrollTransition = errors.New("payroll status transition not allowed")
+	ErrPayrollSelfApproval      = errors.New("payroll must be approved by another admin than the one who ran it")
+)
+
+// payrollTransitions lists the statuses a payroll can move to from each status, a run
+// moves its draft to calculated once the payslips are saved and a payroll is only
+// visible to the employees once published. A wrong calculation is voided and run again
+var payrollTransitions = map[string][]string{
+	model.PayrollDraft:      {model.PayrollCalculated, model.PayrollVoided},
+	model.PayrollCalculated: {model.PayrollApproved, model.PayrollVoided},
+	model.PayrollApproved:   {model.PayrollPublished, model.PayrollVoided},
+	model.PayrollPublished:  {model.PayrollPaid, model.PayrollVoided},
+	model.PayrollPaid:       {model.PayrollVoided},
+}
+
+// CanTransitionPayroll checks if a payroll in the given status can move to the next one
+func CanTransitionPayroll(from, to string) bool {
+	for _, status := range payrollTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidPayrollStatus(status string) bool {
+	switch status {
+	case model.PayrollDraft, model.PayrollCalculated, model.PayrollApproved,
+		model.PayrollPublished, model.PayrollPaid, model.PayrollVoided:
+		return true
+	}
+	return false
+}
+
+// TransitionPayroll moves a payroll to the next status of its lifecycle, voiding
+// goes through ReversePayroll which requires a reason and only a run calculates
+func (s *PayrollServiceImpl) TransitionPayroll(payrollID, performedBy uuid.UUID, status, ip, requestID string) error {
+	if !IsValidPayrollStatus(status) || status == model.PayrollVoided || status == model.PayrollCalculated {
+		return ErrInvalidPayrollStatus
+	}
+	return s.changePayrollStatus(payrollID, performedBy, status, "", ip, requestID)
+}
+
+func (s *PayrollServiceImpl) changePayrollStatus(payrollID, performedBy uuid.UUID, status, reason, ip, requestID string) error {
+	return s.UnitOfWork.Do(func(repos *repository.Repositories) error {
+		payroll, err := repos.Payroll.GetPayroll(payrollID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPayrollNotFound
+			}
+			return err
+		}
+		if payroll.Status == model.PayrollVoided {
+			return ErrPayrollAlreadyVoided
+		}
+		if !CanTransitionPayroll(payroll.Status, status) {
+			return fmt.Errorf("%w: %s to %s", ErrInvalidPayrollTransition, payroll.Status, status)
+		}
+
+		now := time.Now()
+		switch status {
+		case model.PayrollApproved:
+			// four-eyes principle, the admin who ran the calculation can not approve it
+			if payroll.CreatedBy == performedBy {
+				return ErrPayrollSelfApproval
+			}
+			payroll.ApprovedBy = &performedBy
+			payroll.ApprovedAt = &now
+		case model.PayrollPublished:
+			payroll.PublishedAt = &now
+		case model.PayrollPaid:
+			payroll.PaidAt = &now
+		case model.PayrollVoided:
+			payroll.VoidReason = reason
+			payroll.VoidedBy = &performedBy
+			payroll.VoidedAt = &now
+		}
+
+		action := "UPDATE"
+		if status == model.PayrollVoided {
+			action = "VOID"
+		} else {
+			reason = fmt.Sprintf("status changed from %s to %s", payroll.Status, status)
+		}
+		payroll.Status = status
+
+		if err := repos.Payroll.UpdatePayrollStatus(payroll); err != nil {
+			return err
+		}
+
+		switch status {
+		case model.PayrollPublished:
+			// the payslips are emailed by the delivery worker once this commits
+			if err := repos.Delivery.EnqueuePayroll(payroll.ID); err != nil {
+				return err
+			}
+		case model.PayrollVoided:
+			// the claims paid by a voided payroll are paid again by the next run
+			if err := repos.Payroll.ReleaseReimbursements(payroll.ID); err != nil {
+				return err
+			}
+			if err := repos.Delivery.CancelPayroll(payroll.ID); err != nil {
+				return err
+			}
+		}
+
+		return repos.Payroll.CreateAuditLog(&model.AuditLog{
+			ID:          uuid.New(),
+			TableName:   "payrolls",
+			RecordID:    payroll.ID,
+			Action:      action,
+			PerformedBy: performedBy,
+			RequestIP:   ip,
+			RequestID:   requestID,
+			Reason:      reason,
+			Timestamp:   now,
+		})
+	})
+}
@@ -0,0 +1,405 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/pdf"
+	"payslip-generation-system/internal/repository"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultPayslipTemplate lays the payslip out until HR saves a template of their own
+//
+//go:embed templates/payslip.tmpl
+var DefaultPayslipTemplate string
+
+var (
+	ErrPayslipNotFound        = errors.New("payslip not found")
+	ErrInvalidPayslipTemplate = errors.New("invalid payslip template")
+)
+
+// PayslipData is what a payslip template is executed with
+type PayslipData struct {
+	Company         PayslipCompany
+	Employee        PayslipEmployee
+	Period          PayslipPeriod
+	PayrollID       uuid.UUID
+	WorkingDays     int
+	BaseSalary      int
+	AttendanceDays  int
+	PaidLeaveDays   int
+	UnpaidLeaveDays int
+	OvertimeHours   float64
+	Earnings        []PayslipLine
+	Deductions      []PayslipLine
+	EmployerCosts   []PayslipLine
+	GrossPay        int
+	TaxAmount       int
+	TotalDeductions int
+	NetPay          int
+	EmployerCost    int
+	// the totals of the tax year up to and including this payslip
+	YearToDate  PayslipTotals
+	GeneratedAt time.Time
+}
+
+type PayslipCompany struct {
+	Name    string
+	Address string
+	NPWP    string
+}
+
+type PayslipEmployee struct {
+	ID         uuid.UUID
+	Username   string
+	Grade      string
+	PTKPStatus string
+	NPWP       string
+}
+
+type PayslipPeriod struct {
+	Start time.Time
+	End   time.Time
+}
+
+type PayslipLine struct {
+	Code     string
+	Name     string
+	Quantity float64
+	Amount   int
+}
+
+type PayslipTotals struct {
+	GrossPay        int
+	TaxAmount       int
+	TotalDeductions int
+	NetPay          int
+}
+
+// PayslipFile is a rendered payslip, or a zip of payslips, with the name to download it as
+type PayslipFile struct {
+	Name        string
+	ContentType string
+	Content     []byte
+}
+
+var payslipFuncs = template.FuncMap{
+	"rupiah":    FormatRupiah,
+	"terbilang": Terbilang,
+	"date":      func(t time.Time) string { return t.Format("2 January 2006") },
+	"hours":     func(h float64) string { return strconv.FormatFloat(h, 'f', -1, 64) },
+}
+
+// ParsePayslipTemplate parses a payslip template, the layout markup is described by pdf.Layout
+func ParsePayslipTemplate(body string) (*template.Template, error) {
+	return template.New("payslip").Funcs(payslipFuncs).Option("missingkey=error").Parse(body)
+}
+
+// RenderPayslip executes the template with the payslip and lays the result out as a PDF,
+// encrypted with the password unless it is empty
+func RenderPayslip(tmpl *template.Template, data *PayslipData, password string) ([]byte, error) {
+	var markup bytes.Buffer
+	if err := tmpl.Execute(&markup, data); err != nil {
+		return nil, err
+	}
+
+	doc := pdf.Layout(markup.String())
+	doc.Title = fmt.Sprintf("Payslip %s %s", data.Employee.Username, data.Period.End.Format("January 2006"))
+	doc.Author = data.Company.Name
+	doc.CreatedAt = data.GeneratedAt
+	if password != "" {
+		doc.Encrypt(password, "")
+	}
+	return doc.Bytes()
+}
+
+type PayslipService interface {
+	EmployeePayslip(userID, payrollID uuid.UUID) (*PayslipFile, error)
+	PayrollPayslips(payrollID uuid.UUID) (*PayslipFile, error)
+	ValidateTemplate(body string) error
+}
+
+type PayslipServiceImpl struct {
+	PayslipRepo repository.PayslipRepository
+}
+
+func NewPayslipService(repo repository.PayslipRepository) PayslipService {
+	return &PayslipServiceImpl{PayslipRepo: repo}
+}
+
+// EmployeePayslip renders the payslip of the employee of a payroll published to them, encrypted
+// with their payslip password
+func (s *PayslipServiceImpl) EmployeePayslip(userID, payrollID uuid.UUID) (*PayslipFile, error) {
+	payslip, err := s.PayslipRepo.GetReleasedPayslip(userID, payrollID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPayslipNotFound
+		}
+		return nil, err
+	}
+
+	payroll, period, err := s.payrollPeriod(payrollID)
+	if err != nil {
+		return nil, err
+	}
+	files, skipped, err := s.render(payroll, period, []model.Payslip{*payslip})
+	if err != nil {
+		return nil, err
+	}
+	if len(skipped) > 0 {
+		return nil, skipped[0]
+	}
+	return &files[0], nil
+}
+
+// PayrollPayslips renders every payslip of a payroll into one zip, each encrypted with the
+// password of its employee. The employees without a payslip password are left out and listed
+// in skipped-payslips.txt of the zip
+func (s *PayslipServiceImpl) PayrollPayslips(payrollID uuid.UUID) (*PayslipFile, error) {
+	payroll, period, err := s.payrollPeriod(payrollID)
+	if err != nil {
+		return nil, err
+	}
+	payslips, err := s.PayslipRepo.GetPayslips(payrollID)
+	if err != nil {
+		return nil, err
+	}
+
+	files, skipped, err := s.render(payroll, period, payslips)
+	if err != nil {
+		return nil, err
+	}
+	if len(skipped) > 0 {
+		var report strings.Builder
+		for _, err := range skipped {
+			fmt.Fprintln(&report, err)
+		}
+		files = append(files, PayslipFile{Name: "skipped-payslips.txt", ContentType: "text/plain", Content: []byte(report.String())})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		fw, err := zw.Create(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(file.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return &PayslipFile{
+		Name:        fmt.Sprintf("payslips-%s-%s.zip", period.EndDate.Format("2006-01"), payroll.ID.String()[:8]),
+		ContentType: "application/zip",
+		Content:     buf.Bytes(),
+	}, nil
+}
+
+// ValidateTemplate parses the template and renders a sample payslip with it, so a template
+// that fails on real payslips is refused before it is saved
+func (s *PayslipServiceImpl) ValidateTemplate(body string) error {
+	tmpl, err := ParsePayslipTemplate(body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayslipTemplate, err)
+	}
+	if _, err := RenderPayslip(tmpl, samplePayslipData(), ""); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayslipTemplate, err)
+	}
+	return nil
+}
+
+func (s *PayslipServiceImpl) payrollPeriod(payrollID uuid.UUID) (*model.Payroll, *model.AttendancePeriod, error) {
+	payroll, err := s.PayslipRepo.GetPayroll(payrollID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrPayrollNotFound
+		}
+		return nil, nil, err
+	}
+	period, err := s.PayslipRepo.GetAttendancePeriod(payroll.PeriodID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payroll, period, nil
+}
+
+// render builds the data of each payslip with the company header, the employee and the year
+// to date totals and renders it with the current template, encrypted with the password the
+// company's scheme derives for the employee. The payslips of the employees without a password
+// are skipped, with an ErrNoPayslipPassword for each
+func (s *PayslipServiceImpl) render(payroll *model.Payroll, period *model.AttendancePeriod, payslips []model.Payslip) ([]PayslipFile, []error, error) {
+	settings, err := s.PayslipRepo.GetCompanySettings()
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl, err := s.template()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userIDs := []uuid.UUID{}
+	for _, p := range payslips {
+		userIDs = append(userIDs, p.UserID)
+	}
+	users, err := s.PayslipRepo.GetUsers(userIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	userMap := map[uuid.UUID]model.User{}
+	for _, u := range users {
+		userMap[u.ID] = u
+	}
+	yearToDate, err := s.PayslipRepo.GetYearToDate(userIDs, payroll.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	yearToDateMap := map[uuid.UUID]model.PayslipYearToDate{}
+	for _, ytd := range yearToDate {
+		yearToDateMap[ytd.UserID] = ytd
+	}
+
+	now := time.Now()
+	files := []PayslipFile{}
+	skipped := []error{}
+	for i := range payslips {
+		p := &payslips[i]
+		user := userMap[p.UserID]
+		data := NewPayslipData(p, &user, payroll, period, settings, yearToDateMap[p.UserID])
+		data.GeneratedAt = now
+
+		password, err := PayslipPassword(settings.PayslipPasswordScheme, &user)
+		if err != nil {
+			if errors.Is(err, ErrNoPayslipPassword) {
+				skipped = append(skipped, err)
+				continue
+			}
+			return nil, nil, err
+		}
+		content, err := RenderPayslip(tmpl, data, password)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, PayslipFile{
+			Name:        fmt.Sprintf("payslip-%s-%s.pdf", sanitizeFileName(user.Username), period.EndDate.Format("2006-01")),
+			ContentType: "application/pdf",
+			Content:     content,
+		})
+	}
+	return files, skipped, nil
+}
+
+// template returns the template saved by HR, or the built-in one
+func (s *PayslipServiceImpl) template() (*template.Template, error) {
+	body := DefaultPayslipTemplate
+	saved, err := s.PayslipRepo.GetTemplate()
+	switch {
+	case err == nil:
+		body = saved.Body
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+	return ParsePayslipTemplate(body)
+}
+
+// NewPayslipData builds the template data of a payslip with the year to date totals up to and
+// including it
+func NewPayslipData(p *model.Payslip, user *model.User, payroll *model.Payroll, period *model.AttendancePeriod, settings *model.CompanySettings, yearToDate model.PayslipYearToDate) *PayslipData {
+	data := &PayslipData{
+		Company: PayslipCompany{
+			Name:    settings.CompanyName,
+			Address: settings.CompanyAddress,
+			NPWP:    settings.CompanyNPWP,
+		},
+		Employee: PayslipEmployee{
+			ID:         user.ID,
+			Username:   user.Username,
+			Grade:      user.Grade,
+			PTKPStatus: user.PTKPStatus,
+			NPWP:       user.NPWP,
+		},
+		Period:          PayslipPeriod{Start: period.StartDate, End: period.EndDate},
+		PayrollID:       payroll.ID,
+		WorkingDays:     payroll.WorkingDays,
+		BaseSalary:      p.BaseSalary,
+		AttendanceDays:  p.AttendanceDays,
+		PaidLeaveDays:   p.PaidLeaveDays,
+		UnpaidLeaveDays: p.UnpaidLeaveDays,
+		OvertimeHours:   p.OvertimeHours,
+		Earnings:        []PayslipLine{},
+		Deductions:      []PayslipLine{},
+		EmployerCosts:   []PayslipLine{},
+		GrossPay:        p.GrossPay,
+		TaxAmount:       p.TaxAmount,
+		TotalDeductions: p.TotalDeductions,
+		NetPay:          p.NetPay,
+		EmployerCost:    p.EmployerCost,
+		YearToDate: PayslipTotals{
+			GrossPay:        yearToDate.GrossPay,
+			TaxAmount:       yearToDate.TaxAmount,
+			TotalDeductions: yearToDate.TotalDeductions,
+			NetPay:          yearToDate.NetPay,
+		},
+	}
+	for _, item := range p.Items {
+		line := PayslipLine{Code: item.Code, Name: item.Name, Quantity: item.Quantity, Amount: item.Amount}
+		switch item.Type {
+		case model.PayslipItemDeduction:
+			data.Deductions = append(data.Deductions, line)
+		case model.PayslipItemEmployerCost:
+			data.EmployerCosts = append(data.EmployerCosts, line)
+		default:
+			data.Earnings = append(data.Earnings, line)
+		}
+	}
+	return data
+}
+
+// samplePayslipData is a payslip with every field set to try templates on
+func samplePayslipData() *PayslipData {
+	start := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	return &PayslipData{
+		Company:         PayslipCompany{Name: "PT Contoh Sejahtera", Address: "Jl. Sudirman 1, Jakarta", NPWP: "01.234.567.8-901.000"},
+		Employee:        PayslipEmployee{ID: uuid.Nil, Username: "employee", Grade: "staff", PTKPStatus: "TK/0", NPWP: "09.876.543.2-109.000"},
+		Period:          PayslipPeriod{Start: start, End: start.AddDate(0, 1, -1)},
+		WorkingDays:     21,
+		BaseSalary:      10000000,
+		AttendanceDays:  20,
+		PaidLeaveDays:   1,
+		UnpaidLeaveDays: 0,
+		OvertimeHours:   3.5,
+		Earnings:        []PayslipLine{{Code: "BASIC_SALARY", Name: "Basic salary", Amount: 10000000}, {Code: "OVERTIME_WORKDAY_1", Name: "Overtime workday 1.5x", Quantity: 1, Amount: 86705}},
+		Deductions:      []PayslipLine{{Code: "PPH21", Name: "Income tax", Amount: 250000}, {Code: "BPJS_JHT_EE", Name: "BPJS JHT", Amount: 200000}},
+		EmployerCosts:   []PayslipLine{{Code: "BPJS_JHT_ER", Name: "BPJS JHT", Amount: 370000}},
+		GrossPay:        10086705,
+		TaxAmount:       250000,
+		TotalDeductions: 450000,
+		NetPay:          9636705,
+		EmployerCost:    370000,
+		YearToDate:      PayslipTotals{GrossPay: 30086705, TaxAmount: 750000, TotalDeductions: 1350000, NetPay: 28736705},
+		GeneratedAt:     start.AddDate(0, 1, 0),
+	}
+}
+
+// sanitizeFileName keeps a name usable in an archive or a download
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 32 {
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"payslip-generation-system/internal/mailer"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"time"
+)
+
+const (
+	defaultDeliveryInterval    = 30 * time.Second
+	defaultDeliveryMaxAttempts = 5
+	defaultDeliveryBatchSize   = 20
+	// how long a claimed delivery is left to its worker before another one picks it up
+	deliveryLease = 5 * time.Minute
+	// the delay before the first retry, doubled after every failed attempt
+	deliveryRetryDelay    = time.Minute
+	deliveryMaxRetryDelay = time.Hour
+)
+
+const payslipEmailBody = `Hello,
+
+Your payslip is attached (%s).
+
+The PDF is protected with your payslip password.
+`
+
+// PayslipDispatcher emails the payslips queued when a payroll is published, a failed email
+// is retried with a growing delay until it runs out of attempts
+type PayslipDispatcher struct {
+	DeliveryRepo   repository.PayslipDeliveryRepository
+	PayslipService PayslipService
+	Mailer         mailer.Mailer
+	Interval       time.Duration
+	MaxAttempts    int
+	BatchSize      int
+}
+
+func NewPayslipDispatcher(deliveryRepo repository.PayslipDeliveryRepository, payslipService PayslipService, m mailer.Mailer) *PayslipDispatcher {
+	return &PayslipDispatcher{
+		DeliveryRepo:   deliveryRepo,
+		PayslipService: payslipService,
+		Mailer:         m,
+		Interval:       defaultDeliveryInterval,
+		MaxAttempts:    defaultDeliveryMaxAttempts,
+		BatchSize:      defaultDeliveryBatchSize,
+	}
+}
+
+// Run dispatches the due deliveries every interval until the context is done
+func (d *PayslipDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.DispatchDue(time.Now()); err != nil {
+			log.Printf("payslip delivery: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue sends the deliveries due by now, batch after batch, and returns how many were sent
+func (d *PayslipDispatcher) DispatchDue(now time.Time) (int, error) {
+	sent := 0
+	for {
+		deliveries, err := d.DeliveryRepo.ClaimDue(now, d.BatchSize, deliveryLease)
+		if err != nil {
+			return sent, err
+		}
+		for i := range deliveries {
+			delivery := &deliveries[i]
+			d.deliver(delivery, now)
+			if err := d.DeliveryRepo.UpdateDelivery(delivery); err != nil {
+				return sent, err
+			}
+			if delivery.Status == model.DeliverySent {
+				sent++
+			}
+		}
+		if len(deliveries) < d.BatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// deliver renders and emails the payslip and records the outcome on the delivery, a payslip
+// that can't be rendered for the employee fails without retrying
+func (d *PayslipDispatcher) deliver(delivery *model.PayslipDelivery, now time.Time) {
+	delivery.Attempts++
+
+	file, err := d.PayslipService.EmployeePayslip(delivery.UserID, delivery.PayrollID)
+	if err == nil {
+		err = d.Mailer.Send(&mailer.Message{
+			To:      delivery.Email,
+			Subject: "Your payslip",
+			Body:    fmt.Sprintf(payslipEmailBody, file.Name),
+			Attachments: []mailer.Attachment{
+				{Name: file.Name, ContentType: file.ContentType, Content: file.Content},
+			},
+		})
+	}
+
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliverySent
+		delivery.LastError = ""
+		delivery.SentAt = &now
+	case errors.Is(err, ErrNoPayslipPassword), errors.Is(err, ErrPayslipNotFound), errors.Is(err, mailer.ErrInvalidAddress),
+		delivery.Attempts >= d.MaxAttempts:
+		delivery.Status = model.DeliveryFailed
+		delivery.LastError = err.Error()
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(RetryDelay(delivery.Attempts))
+	}
+}
+
+// RetryDelay is how long a delivery waits after its failed attempts before it is tried again
+func RetryDelay(attempts int) time.Duration {
+	delay := deliveryRetryDelay
+	for i := 1; i < attempts && delay < deliveryMaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > deliveryMaxRetryDelay {
+		return deliveryMaxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"payslip-generation-system/internal/model"
+)
+
+var (
+	ErrNoPayslipPassword = errors.New("payslip password is not set up")
+	ErrInvalidPayslipPIN = errors.New("payslip PIN must be 6 to 12 digits")
+	ErrNoPayslipPINKey   = errors.New("PAYSLIP_PIN_KEY is not set")
+)
+
+func IsValidPayslipPasswordScheme(scheme string) bool {
+	return scheme == model.PayslipPasswordBirthdate || scheme == model.PayslipPasswordPIN
+}
+
+// PayslipPassword derives the password the payslip PDFs of the employee are encrypted with,
+// an employee missing what the scheme needs gets no payslip rather than an unprotected one
+func PayslipPassword(scheme string, user *model.User) (string, error) {
+	switch scheme {
+	case model.PayslipPasswordPIN:
+		if user.PayslipPIN == "" {
+			return "", fmt.Errorf("%w: %s has not set a payslip PIN", ErrNoPayslipPassword, user.Username)
+		}
+		return decryptPayslipPIN(user.PayslipPIN)
+	default:
+		if user.BirthDate == nil || user.EmployeeNumber == "" {
+			return "", fmt.Errorf("%w: %s has no birth date or employee number", ErrNoPayslipPassword, user.Username)
+		}
+		return user.BirthDate.Format("02012006") + user.EmployeeNumber, nil
+	}
+}
+
+// ValidatePayslipPIN checks a PIN an employee chose for their payslips
+func ValidatePayslipPIN(pin string) error {
+	if len(pin) < 6 || len(pin) > 12 {
+		return ErrInvalidPayslipPIN
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return ErrInvalidPayslipPIN
+		}
+	}
+	return nil
+}
+
+// payslipPINCipher is the AES-256-GCM cipher of the application key PAYSLIP_PIN_KEY the PINs
+// are stored encrypted with, so a leaked database does not hand out the passwords of the PDFs
+func payslipPINCipher() (cipher.AEAD, error) {
+	secret := os.Getenv("PAYSLIP_PIN_KEY")
+	if secret == "" {
+		return nil, ErrNoPayslipPINKey
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptPayslipPIN encrypts a PIN for the payslip_pin column, as base64 of the nonce followed
+// by the sealed PIN
+func EncryptPayslipPIN(pin string) (string, error) {
+	gcm, err := payslipPINCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(pin), nil)), nil
+}
+
+func decryptPayslipPIN(stored string) (string, error) {
+	gcm, err := payslipPINCipher()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(stored)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("payslip PIN is not encrypted with PAYSLIP_PIN_KEY")
+	}
+	pin, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("payslip PIN is not encrypted with PAYSLIP_PIN_KEY: %w", err)
+	}
+	return string(pin), nil
+}
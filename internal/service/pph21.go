@@ -0,0 +1,208 @@
+package service
+
+import (
+	"fmt"
+	"payslip-generation-system/internal/model"
+	"time"
+)
+
+// PTKP (penghasilan tidak kena pajak) of a year per tax status
+var ptkpAmounts = map[string]int{
+	"TK/0": 54_000_000,
+	"TK/1": 58_500_000,
+	"TK/2": 63_000_000,
+	"TK/3": 67_500_000,
+	"K/0":  58_500_000,
+	"K/1":  63_000_000,
+	"K/2":  67_500_000,
+	"K/3":  72_000_000,
+}
+
+// TER (tarif efektif rata-rata) category of each tax status following PP 58/2023
+var terCategories = map[string]string{
+	"TK/0": "A",
+	"TK/1": "A",
+	"K/0":  "A",
+	"TK/2": "B",
+	"TK/3": "B",
+	"K/1":  "B",
+	"K/2":  "B",
+	"K/3":  "C",
+}
+
+// terBracket is the monthly rate in basis points applied to a gross income up to Limit
+type terBracket struct {
+	Limit int
+	Rate  int
+}
+
+const noLimit = int(^uint(0) >> 1)
+
+var terRates = map[string][]terBracket{
+	"A": {
+		{5_400_000, 0}, {5_650_000, 25}, {5_950_000, 50}, {6_300_000, 75},
+		{6_750_000, 100}, {7_500_000, 125}, {8_550_000, 150}, {9_650_000, 175},
+		{10_050_000, 200}, {10_350_000, 225}, {10_700_000, 250}, {11_050_000, 300},
+		{11_600_000, 350}, {12_500_000, 400}, {13_750_000, 500}, {15_100_000, 600},
+		{16_950_000, 700}, {19_750_000, 800}, {24_150_000, 900}, {26_450_000, 1000},
+		{28_000_000, 1100}, {30_050_000, 1200}, {32_400_000, 1300}, {35_400_000, 1400},
+		{39_100_000, 1500}, {43_850_000, 1600}, {47_800_000, 1700}, {51_400_000, 1800},
+		{56_300_000, 1900}, {62_200_000, 2000}, {68_600_000, 2100}, {77_500_000, 2200},
+		{89_000_000, 2300}, {103_000_000, 2400}, {125_000_000, 2500}, {157_000_000, 2600},
+		{206_000_000, 2700}, {337_000_000, 2800}, {454_000_000, 2900}, {550_000_000, 3000},
+		{695_000_000, 3100}, {910_000_000, 3200}, {1_400_000_000, 3300}, {noLimit, 3400},
+	},
+	"B": {
+		{6_200_000, 0}, {6_500_000, 25}, {6_850_000, 50}, {7_300_000, 75},
+		{9_200_000, 100}, {10_750_000, 150}, {11_250_000, 200}, {11_600_000, 250},
+		{12_600_000, 300}, {13_600_000, 400}, {14_950_000, 500}, {16_400_000, 600},
+		{18_450_000, 700}, {21_850_000, 800}, {26_000_000, 900}, {27_700_000, 1000},
+		{29_350_000, 1100}, {31_450_000, 1200}, {33_950_000, 1300}, {37_100_000, 1400},
+		{41_100_000, 1500}, {45_800_000, 1600}, {49_500_000, 1700}, {53_800_000, 1800},
+		{58_500_000, 1900}, {64_000_000, 2000}, {71_000_000, 2100}, {80_000_000, 2200},
+		{93_000_000, 2300}, {109_000_000, 2400}, {129_000_000, 2500}, {163_000_000, 2600},
+		{211_000_000, 2700}, {374_000_000, 2800}, {459_000_000, 2900}, {555_000_000, 3000},
+		{704_000_000, 3100}, {957_000_000, 3200}, {1_405_000_000, 3300}, {noLimit, 3400},
+	},
+	"C": {
+		{6_600_000, 0}, {6_950_000, 25}, {7_350_000, 50}, {7_800_000, 75},
+		{8_850_000, 100}, {9_800_000, 125}, {10_950_000, 150}, {11_200_000, 175},
+		{12_050_000, 200}, {12_950_000, 300}, {14_150_000, 400}, {15_550_000, 500},
+		{17_050_000, 600}, {19_500_000, 700}, {22_700_000, 800}, {26_600_000, 900},
+		{28_100_000, 1000}, {30_100_000, 1100}, {32_600_000, 1200}, {35_400_000, 1300},
+		{38_900_000, 1400}, {43_000_000, 1500}, {47_400_000, 1600}, {51_200_000, 1700},
+		{55_800_000, 1800}, {60_400_000, 1900}, {66_700_000, 2000}, {74_500_000, 2100},
+		{83_200_000, 2200}, {95_600_000, 2300}, {110_000_000, 2400}, {134_000_000, 2500},
+		{169_000_000, 2600}, {221_000_000, 2700}, {390_000_000, 2800}, {463_000_000, 2900},
+		{561_000_000, 3000}, {709_000_000, 3100}, {965_000_000, 3200}, {1_419_000_000, 3300},
+		{noLimit, 3400},
+	},
+}
+
+// progressive rates of article 17 (UU HPP) applied on the annual taxable income
+var annualBrackets = []terBracket{
+	{60_000_000, 500},
+	{250_000_000, 1500},
+	{500_000_000, 2500},
+	{5_000_000_000, 3000},
+	{noLimit, 3500},
+}
+
+const (
+	occupationalCostRate = 500
+	occupationalCostMax  = 6_000_000
+	// employees without NPWP are withheld 20% higher
+	noNPWPSurcharge = 120
+)
+
+func IsValidPTKPStatus(status string) bool {
+	_, ok := ptkpAmounts[status]
+	return ok
+}
+
+// CalculateMonthlyPPh21 withholds the monthly tax using the TER rate of the employee's category
+func CalculateMonthlyPPh21(ptkpStatus string, hasNPWP bool, gross int) (int, error) {
+	category, ok := terCategories[ptkpStatus]
+	if !ok {
+		return 0, fmt.Errorf("unknown PTKP status %q", ptkpStatus)
+	}
+
+	rate := 0
+	for _, bracket := range terRates[category] {
+		if gross <= bracket.Limit {
+			rate = bracket.Rate
+			break
+		}
+	}
+
+	tax := gross * rate / 10000
+	if !hasNPWP {
+		tax = tax * noNPWPSurcharge / 100
+	}
+	return tax, nil
+}
+
+// CalculateAnnualPPh21 computes the tax owed for the whole year on the annual gross,
+// reduced by the occupational cost, the employee pension contributions, and the PTKP
+func CalculateAnnualPPh21(ptkpStatus string, hasNPWP bool, annualGross, pensionContribution int) (int, error) {
+	ptkp, ok := ptkpAmounts[ptkpStatus]
+	if !ok {
+		return 0, fmt.Errorf("unknown PTKP status %q", ptkpStatus)
+	}
+
+	occupationalCost := annualGross * occupationalCostRate / 10000
+	if occupationalCost > occupationalCostMax {
+		occupationalCost = occupationalCostMax
+	}
+
+	// taxable income is rounded down to the thousand
+	taxable := annualGross - occupationalCost - pensionContribution - ptkp
+	taxable = taxable / 1000 * 1000
+	if taxable <= 0 {
+		return 0, nil
+	}
+
+	tax := 0
+	lower := 0
+	for _, bracket := range annualBrackets {
+		if taxable <= lower {
+			break
+		}
+		upper := bracket.Limit
+		if taxable < upper {
+			upper = taxable
+		}
+		tax += (upper - lower) * bracket.Rate / 10000
+		lower = bracket.Limit
+	}
+
+	if !hasNPWP {
+		tax = tax * noNPWPSurcharge / 100
+	}
+	return tax, nil
+}
+
+// PPh21Component withholds the employee income tax, using the TER rate every month
+// and reconciling the whole year in December
+type PPh21Component struct{}
+
+func (c *PPh21Component) Code() string  { return "PPH21" }
+func (c *PPh21Component) Sequence() int { return SequenceTax }
+
+func (c *PPh21Component) Evaluate(ctx *PayContext) ([]model.PayslipItem, error) {
+	gross := ctx.TaxableIncome()
+
+	var tax int
+	var err error
+	if ctx.PeriodEnd.Month() == time.December {
+		pension := ctx.PriorPension + ctx.PensionContribution()
+		annualTax, annualErr := CalculateAnnualPPh21(ctx.PTKPStatus, ctx.HasNPWP, ctx.PriorTaxableIncome+gross, pension)
+		tax, err = annualTax-ctx.PriorTaxWithheld, annualErr
+	} else {
+		tax, err = CalculateMonthlyPPh21(ctx.PTKPStatus, ctx.HasNPWP, gross)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.TaxAmount = tax
+	if tax == 0 {
+		return nil, nil
+	}
+
+	// over-withheld tax of the year is paid back to the employee on the reconciliation
+	if tax < 0 {
+		return []model.PayslipItem{{
+			Code:   "PPH21_REFUND",
+			Name:   "PPh 21 refund",
+			Type:   model.PayslipItemEarning,
+			Amount: -tax,
+		}}, nil
+	}
+
+	return []model.PayslipItem{{
+		Name:   "PPh 21",
+		Type:   model.PayslipItemDeduction,
+		Amount: tax,
+	}}, nil
+}
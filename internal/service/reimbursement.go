@@ -0,0 +1,144 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReimbursementNotFound    = errors.New("reimbursement not found")
+	ErrReimbursementNotPending  = errors.New("reimbursement is no longer pending")
+	ErrCategoryNotAllowed       = errors.New("reimbursement category is not available for the employee's grade")
+	ErrInvalidApprovedAmount    = errors.New("approved amount must be above 0 and at most the claimed amount")
+	ErrNotReimbursementReviewer = errors.New("only the employee's manager or an admin can review this reimbursement")
+)
+
+// ReimbursementLimitError reports the per claim or per period limit a claim goes over
+type ReimbursementLimitError struct {
+	Limit   string
+	Max     int
+	Claimed int
+}
+
+func (e *ReimbursementLimitError) Error() string {
+	if e.Limit == "claim" {
+		return fmt.Sprintf("reimbursement exceeds the per claim limit of %d", e.Max)
+	}
+	return fmt.Sprintf("reimbursement exceeds the per period limit of %d, %d is already claimed", e.Max, e.Claimed)
+}
+
+// CheckReimbursementLimit checks the amount against the limits of the category, claimed is
+// what the employee already claimed of the category in the period
+func CheckReimbursementLimit(limit *model.ReimbursementLimit, claimed, amount int) error {
+	if limit.PerClaimLimit != nil && amount > *limit.PerClaimLimit {
+		return &ReimbursementLimitError{Limit: "claim", Max: *limit.PerClaimLimit}
+	}
+	if limit.PerPeriodLimit != nil && claimed+amount > *limit.PerPeriodLimit {
+		return &ReimbursementLimitError{Limit: "period", Max: *limit.PerPeriodLimit, Claimed: claimed}
+	}
+	return nil
+}
+
+type ReimbursementService interface {
+	ReviewReimbursement(reimbursementID, reviewerID uuid.UUID, reviewerRole string, approve bool, approvedAmount *int, note string) (*model.Reimbursement, error)
+}
+
+type ReimbursementServiceImpl struct {
+	UnitOfWork repository.UnitOfWork
+}
+
+func NewReimbursementService(uow repository.UnitOfWork) ReimbursementService {
+	return &ReimbursementServiceImpl{UnitOfWork: uow}
+}
+
+// ReviewReimbursement approves a pending claim in full or in part, or rejects it. The approved
+// amount is checked again against the period limit, counting the approved claims only, and the
+// claim is assigned to the first period open for payroll from its expense date on
+func (s *ReimbursementServiceImpl) ReviewReimbursement(reimbursementID, reviewerID uuid.UUID, reviewerRole string, approve bool, approvedAmount *int, note string) (*model.Reimbursement, error) {
+	var reimbursement *model.Reimbursement
+	err := s.UnitOfWork.Do(func(repos *repository.Repositories) error {
+		var err error
+		reimbursement, err = repos.Reimbursement.GetReimbursement(reimbursementID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReimbursementNotFound
+			}
+			return err
+		}
+		if reimbursement.Status != model.ReimbursementPending {
+			return ErrReimbursementNotPending
+		}
+
+		if reviewerRole != "admin" {
+			employee, err := repos.Reimbursement.GetUser(reimbursement.UserID)
+			if err != nil {
+				return err
+			}
+			if employee.ManagerID == nil || *employee.ManagerID != reviewerID {
+				return ErrNotReimbursementReviewer
+			}
+		}
+
+		now := time.Now()
+		reimbursement.ReviewedBy = &reviewerID
+		reimbursement.ReviewedAt = &now
+		reimbursement.ReviewNote = note
+		reimbursement.UpdatedAt = now
+
+		// a rejected claim gives its receipts back for a corrected claim
+		if !approve {
+			reimbursement.Status = model.ReimbursementRejected
+			if err := repos.Reimbursement.UpdateReimbursement(reimbursement); err != nil {
+				return err
+			}
+			return repos.Reimbursement.ReleaseAttachments(reimbursement.ID)
+		}
+
+		amount := reimbursement.Amount
+		if approvedAmount != nil {
+			amount = *approvedAmount
+		}
+		if amount <= 0 || amount > reimbursement.Amount {
+			return ErrInvalidApprovedAmount
+		}
+
+		limit, err := repos.Reimbursement.GetReimbursementLimit(reimbursement.UserID, reimbursement.Category)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCategoryNotAllowed
+			}
+			return err
+		}
+		start, end, err := repos.Reimbursement.GetClaimPeriod(reimbursement.ExpenseDate)
+		if err != nil {
+			return err
+		}
+		approved, err := repos.Reimbursement.GetClaimedAmount(reimbursement.UserID, reimbursement.Category, start, end, true, reimbursement.ID)
+		if err != nil {
+			return err
+		}
+		if err := CheckReimbursementLimit(limit, approved, amount); err != nil {
+			return err
+		}
+
+		// without an open period yet the claim is paid by the next payroll run
+		period, err := repos.Reimbursement.GetOpenPeriod(reimbursement.ExpenseDate)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if period != nil {
+			reimbursement.PeriodID = &period.ID
+		}
+
+		reimbursement.Status = model.ReimbursementApproved
+		reimbursement.ApprovedAmount = &amount
+		return repos.Reimbursement.UpdateReimbursement(reimbursement)
+	})
+	return reimbursement, err
+}
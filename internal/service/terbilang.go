@@ -0,0 +1,62 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+)
+
+var satuan = []string{"", "satu", "dua", "tiga", "empat", "lima", "enam", "tujuh", "delapan", "sembilan", "sepuluh", "sebelas"}
+
+// Terbilang spells the number out in Indonesian, as amounts are written on payslips and receipts
+func Terbilang(n int) string {
+	switch {
+	case n == 0:
+		return "nol"
+	case n < 0:
+		return "minus " + Terbilang(-n)
+	}
+	return strings.Join(strings.Fields(spell(int64(n))), " ")
+}
+
+func spell(n int64) string {
+	switch {
+	case n < 12:
+		return satuan[n]
+	case n < 20:
+		return spell(n-10) + " belas"
+	case n < 100:
+		return spell(n/10) + " puluh " + spell(n%10)
+	case n < 200:
+		return "seratus " + spell(n-100)
+	case n < 1000:
+		return spell(n/100) + " ratus " + spell(n%100)
+	case n < 2000:
+		return "seribu " + spell(n-1000)
+	case n < 1_000_000:
+		return spell(n/1000) + " ribu " + spell(n%1000)
+	case n < 1_000_000_000:
+		return spell(n/1_000_000) + " juta " + spell(n%1_000_000)
+	case n < 1_000_000_000_000:
+		return spell(n/1_000_000_000) + " miliar " + spell(n%1_000_000_000)
+	default:
+		return spell(n/1_000_000_000_000) + " triliun " + spell(n%1_000_000_000_000)
+	}
+}
+
+// FormatRupiah writes the amount with dots between the thousands, as in Rp 7.250.000
+func FormatRupiah(n int) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	digits := strconv.Itoa(n)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+	return sign + "Rp " + b.String()
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultStorageDir = "storage"
+
+var (
+	ErrFileNotFound = errors.New("file not found")
+	ErrInvalidKey   = errors.New("invalid storage key")
+)
+
+// Storage keeps uploaded files under a slash separated key
+type Storage interface {
+	Put(key string, content io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// LocalStorage keeps the files in a directory of the local filesystem
+type LocalStorage struct {
+	Root string
+}
+
+func NewLocalStorage(root string) *LocalStorage {
+	return &LocalStorage{Root: root}
+}
+
+// LocalStorageFromEnv keeps the files under STORAGE_DIR, falling back to ./storage
+func LocalStorageFromEnv() Storage {
+	root := os.Getenv("STORAGE_DIR")
+	if root == "" {
+		root = defaultStorageDir
+	}
+	return NewLocalStorage(root)
+}
+
+// Put writes the file to a temporary file first, so a failed upload never leaves a partial file under the key
+func (ls *LocalStorage) Put(key string, content io.Reader) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (ls *LocalStorage) Get(key string) (io.ReadCloser, error) {
+	path, err := ls.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrFileNotFound
+	}
+	return file, err
+}
+
+// Delete removes the file, deleting a missing file is not an error
+func (ls *LocalStorage) Delete(key string) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path maps the key inside the root, keys that are absolute or climb out of the root are rejected
+func (ls *LocalStorage) path(key string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(ls.Root, clean), nil
+}
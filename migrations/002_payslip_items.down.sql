@@ -0,0 +1,15 @@
+ALTER TABLE payslips
+  ADD COLUMN prorated_salary INTEGER,
+  ADD COLUMN overtime_pay INTEGER,
+  ADD COLUMN reimbursement_total INTEGER;
+
+UPDATE payslips p
+SET prorated_salary = (SELECT COALESCE(SUM(amount), 0) FROM payslip_items i WHERE i.payslip_id = p.id AND i.code = 'BASIC_SALARY'),
+    overtime_pay = (SELECT COALESCE(SUM(amount), 0) FROM payslip_items i WHERE i.payslip_id = p.id AND i.code = 'OVERTIME'),
+    reimbursement_total = (SELECT COALESCE(SUM(amount), 0) FROM payslip_items i WHERE i.payslip_id = p.id AND i.code = 'REIMBURSEMENT');
+
+ALTER TABLE payslips
+  DROP COLUMN gross_pay,
+  DROP COLUMN total_deductions;
+
+DROP TABLE IF EXISTS payslip_items;
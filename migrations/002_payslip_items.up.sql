@@ -0,0 +1,39 @@
+CREATE TABLE payslip_items (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  payslip_id UUID NOT NULL REFERENCES payslips(id) ON DELETE CASCADE,
+  code TEXT NOT NULL,
+  name TEXT NOT NULL,
+  type TEXT CHECK (type IN ('earning', 'deduction')) NOT NULL,
+  quantity NUMERIC(10, 2),
+  amount INTEGER NOT NULL,
+  sequence INTEGER NOT NULL
+);
+
+CREATE INDEX idx_payslip_items_payslip_id ON payslip_items(payslip_id);
+
+-- move the fixed payslip columns into line items
+INSERT INTO payslip_items (payslip_id, code, name, type, quantity, amount, sequence)
+SELECT id, 'BASIC_SALARY', 'Basic salary', 'earning', attendance_days, COALESCE(prorated_salary, 0), 1
+FROM payslips;
+
+INSERT INTO payslip_items (payslip_id, code, name, type, quantity, amount, sequence)
+SELECT id, 'OVERTIME', 'Overtime', 'earning', overtime_hours, overtime_pay, 2
+FROM payslips
+WHERE COALESCE(overtime_pay, 0) > 0;
+
+INSERT INTO payslip_items (payslip_id, code, name, type, quantity, amount, sequence)
+SELECT id, 'REIMBURSEMENT', 'Reimbursement', 'earning', NULL, reimbursement_total, 3
+FROM payslips
+WHERE COALESCE(reimbursement_total, 0) > 0;
+
+ALTER TABLE payslips
+  ADD COLUMN gross_pay INTEGER,
+  ADD COLUMN total_deductions INTEGER NOT NULL DEFAULT 0;
+
+UPDATE payslips
+SET gross_pay = COALESCE(prorated_salary, 0) + COALESCE(overtime_pay, 0) + COALESCE(reimbursement_total, 0);
+
+ALTER TABLE payslips
+  DROP COLUMN prorated_salary,
+  DROP COLUMN overtime_pay,
+  DROP COLUMN reimbursement_total;
@@ -0,0 +1,11 @@
+ALTER TABLE payslips RENAME COLUMN net_pay TO take_home_pay;
+
+ALTER TABLE payslips
+  DROP COLUMN taxable_income,
+  DROP COLUMN tax_amount;
+
+ALTER TABLE payslip_items DROP COLUMN taxable;
+
+ALTER TABLE users
+  DROP COLUMN ptkp_status,
+  DROP COLUMN npwp;
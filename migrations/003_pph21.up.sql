@@ -0,0 +1,18 @@
+ALTER TABLE users
+  ADD COLUMN ptkp_status TEXT NOT NULL DEFAULT 'TK/0'
+    CHECK (ptkp_status IN ('TK/0', 'TK/1', 'TK/2', 'TK/3', 'K/0', 'K/1', 'K/2', 'K/3')),
+  ADD COLUMN npwp TEXT;
+
+ALTER TABLE payslip_items
+  ADD COLUMN taxable BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE payslip_items SET taxable = true WHERE code IN ('BASIC_SALARY', 'OVERTIME');
+
+ALTER TABLE payslips
+  ADD COLUMN taxable_income INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN tax_amount INTEGER NOT NULL DEFAULT 0;
+
+UPDATE payslips p
+SET taxable_income = (SELECT COALESCE(SUM(amount), 0) FROM payslip_items i WHERE i.payslip_id = p.id AND i.taxable);
+
+ALTER TABLE payslips RENAME COLUMN take_home_pay TO net_pay;
@@ -0,0 +1,11 @@
+ALTER TABLE payslips DROP COLUMN employer_cost;
+
+DELETE FROM payslip_items WHERE type = 'employer_cost';
+
+ALTER TABLE payslip_items
+  DROP CONSTRAINT payslip_items_type_check,
+  ADD CONSTRAINT payslip_items_type_check CHECK (type IN ('earning', 'deduction'));
+
+DROP TABLE IF EXISTS bpjs_rates;
+
+ALTER TABLE users DROP COLUMN jkk_risk_class;
@@ -0,0 +1,36 @@
+ALTER TABLE users
+  ADD COLUMN jkk_risk_class SMALLINT NOT NULL DEFAULT 1 CHECK (jkk_risk_class BETWEEN 1 AND 5);
+
+CREATE TABLE bpjs_rates (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  program TEXT CHECK (program IN ('KESEHATAN', 'JHT', 'JP', 'JKK', 'JKM')) NOT NULL,
+  risk_class SMALLINT CHECK (risk_class BETWEEN 1 AND 5),
+  employer_rate NUMERIC(5, 2) NOT NULL,
+  employee_rate NUMERIC(5, 2) NOT NULL,
+  salary_cap INTEGER,
+  effective_from DATE NOT NULL,
+  created_by UUID,
+  request_ip TEXT,
+  created_at TIMESTAMP DEFAULT now(),
+  UNIQUE NULLS NOT DISTINCT (program, risk_class, effective_from)
+);
+
+-- rates are in percent, salary_cap NULL means the whole salary is contributed on
+INSERT INTO bpjs_rates (program, risk_class, employer_rate, employee_rate, salary_cap, effective_from) VALUES
+  ('KESEHATAN', NULL, 4.00, 1.00, 12000000, '2020-01-01'),
+  ('JHT', NULL, 3.70, 2.00, NULL, '2015-07-01'),
+  ('JP', NULL, 2.00, 1.00, 10042300, '2024-03-01'),
+  ('JP', NULL, 2.00, 1.00, 10547400, '2025-03-01'),
+  ('JKK', 1, 0.24, 0.00, NULL, '2015-07-01'),
+  ('JKK', 2, 0.54, 0.00, NULL, '2015-07-01'),
+  ('JKK', 3, 0.89, 0.00, NULL, '2015-07-01'),
+  ('JKK', 4, 1.27, 0.00, NULL, '2015-07-01'),
+  ('JKK', 5, 1.74, 0.00, NULL, '2015-07-01'),
+  ('JKM', NULL, 0.30, 0.00, NULL, '2015-07-01');
+
+ALTER TABLE payslip_items
+  DROP CONSTRAINT payslip_items_type_check,
+  ADD CONSTRAINT payslip_items_type_check CHECK (type IN ('earning', 'deduction', 'employer_cost'));
+
+ALTER TABLE payslips
+  ADD COLUMN employer_cost INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+ALTER TABLE users ADD COLUMN salary INTEGER NOT NULL DEFAULT 0;
+
+-- restore the salary in force today
+UPDATE users u
+SET salary = h.salary
+FROM (
+  SELECT DISTINCT ON (user_id) user_id, salary
+  FROM salary_histories
+  WHERE effective_from <= CURRENT_DATE
+  ORDER BY user_id, effective_from DESC
+) h
+WHERE h.user_id = u.id;
+
+ALTER TABLE users ALTER COLUMN salary DROP DEFAULT;
+
+DROP TABLE IF EXISTS salary_histories;
@@ -0,0 +1,22 @@
+CREATE TABLE salary_histories (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES users(id),
+  salary INTEGER NOT NULL CHECK (salary >= 0),
+  effective_from DATE NOT NULL,
+  reason TEXT,
+  created_by UUID,
+  request_ip TEXT,
+  created_at TIMESTAMP DEFAULT now(),
+  UNIQUE (user_id, effective_from)
+);
+
+-- the salary so far was in force for every period already recorded, even the days before an
+-- account was created, so it takes effect from the earliest period or attendance
+INSERT INTO salary_histories (user_id, salary, effective_from, reason)
+SELECT id, salary,
+  LEAST(created_at::date, (SELECT MIN(start_date) FROM attendance_periods), (SELECT MIN(date) FROM attendances)),
+  'initial salary'
+FROM users
+WHERE role = 'employee';
+
+ALTER TABLE users DROP COLUMN salary;
@@ -0,0 +1,8 @@
+ALTER TABLE payrolls
+  DROP COLUMN proration_basis,
+  DROP COLUMN working_days,
+  DROP COLUMN proration_divisor,
+  DROP COLUMN hours_per_day;
+
+DROP TABLE IF EXISTS holidays;
+DROP TABLE IF EXISTS company_settings;
@@ -0,0 +1,28 @@
+CREATE TABLE company_settings (
+  id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+  hours_per_day NUMERIC(4, 2) NOT NULL DEFAULT 8 CHECK (hours_per_day > 0),
+  proration_basis TEXT NOT NULL DEFAULT 'working_days'
+    CHECK (proration_basis IN ('working_days', 'calendar_days', 'fixed')),
+  fixed_divisor NUMERIC(5, 2) NOT NULL DEFAULT 21.67 CHECK (fixed_divisor > 0),
+  updated_by UUID,
+  updated_at TIMESTAMP DEFAULT now()
+);
+
+INSERT INTO company_settings (id) VALUES (1);
+
+CREATE TABLE holidays (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  date DATE UNIQUE NOT NULL,
+  name TEXT NOT NULL,
+  created_by UUID,
+  request_ip TEXT,
+  created_at TIMESTAMP DEFAULT now(),
+  updated_at TIMESTAMP DEFAULT now()
+);
+
+-- the calendar used by a run is kept so its results can be reproduced
+ALTER TABLE payrolls
+  ADD COLUMN proration_basis TEXT,
+  ADD COLUMN working_days INTEGER,
+  ADD COLUMN proration_divisor NUMERIC(6, 2),
+  ADD COLUMN hours_per_day NUMERIC(4, 2);
@@ -0,0 +1,3 @@
+ALTER TABLE company_settings DROP COLUMN holiday_overtime_multiplier;
+
+ALTER TABLE holidays DROP COLUMN category;
@@ -0,0 +1,6 @@
+ALTER TABLE holidays
+  ADD COLUMN category TEXT NOT NULL DEFAULT 'company'
+    CHECK (category IN ('national', 'cuti_bersama', 'company'));
+
+ALTER TABLE company_settings
+  ADD COLUMN holiday_overtime_multiplier NUMERIC(4, 2) NOT NULL DEFAULT 3 CHECK (holiday_overtime_multiplier > 0);
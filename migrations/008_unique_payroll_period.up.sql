@@ -0,0 +1,3 @@
+-- a period can only be paid by one payroll, concurrent runs fail on this constraint
+ALTER TABLE payrolls
+  ADD CONSTRAINT payrolls_period_id_key UNIQUE (period_id);
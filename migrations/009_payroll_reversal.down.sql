@@ -0,0 +1,28 @@
+ALTER TABLE audit_logs DROP COLUMN reason;
+
+UPDATE audit_logs SET action = 'UPDATE' WHERE action = 'VOID';
+
+ALTER TABLE audit_logs
+  DROP CONSTRAINT audit_logs_action_check,
+  ADD CONSTRAINT audit_logs_action_check CHECK (action IN ('CREATE', 'UPDATE', 'DELETE'));
+
+ALTER TABLE payslips DROP COLUMN supersedes_payslip_id;
+
+DROP INDEX IF EXISTS payrolls_period_id_in_force_key;
+
+-- only the payroll in force of each period can be kept under the unique constraint
+DELETE FROM payslip_items WHERE payslip_id IN (
+  SELECT s.id FROM payslips s JOIN payrolls r ON s.payroll_id = r.id WHERE r.status = 'voided'
+);
+DELETE FROM payslips WHERE payroll_id IN (SELECT id FROM payrolls WHERE status = 'voided');
+UPDATE payrolls SET supersedes_payroll_id = NULL;
+DELETE FROM payrolls WHERE status = 'voided';
+
+ALTER TABLE payrolls ADD CONSTRAINT payrolls_period_id_key UNIQUE (period_id);
+
+ALTER TABLE payrolls
+  DROP COLUMN status,
+  DROP COLUMN void_reason,
+  DROP COLUMN voided_by,
+  DROP COLUMN voided_at,
+  DROP COLUMN supersedes_payroll_id;
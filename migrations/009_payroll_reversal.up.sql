@@ -0,0 +1,18 @@
+ALTER TABLE payrolls
+  ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'voided')),
+  ADD COLUMN void_reason TEXT,
+  ADD COLUMN voided_by UUID,
+  ADD COLUMN voided_at TIMESTAMP,
+  ADD COLUMN supersedes_payroll_id UUID REFERENCES payrolls(id);
+
+-- voided payrolls stay in history, only one payroll of a period can be in force
+ALTER TABLE payrolls DROP CONSTRAINT payrolls_period_id_key;
+CREATE UNIQUE INDEX payrolls_period_id_in_force_key ON payrolls(period_id) WHERE status <> 'voided';
+
+ALTER TABLE payslips
+  ADD COLUMN supersedes_payslip_id UUID REFERENCES payslips(id);
+
+ALTER TABLE audit_logs
+  DROP CONSTRAINT audit_logs_action_check,
+  ADD CONSTRAINT audit_logs_action_check CHECK (action IN ('CREATE', 'UPDATE', 'DELETE', 'VOID')),
+  ADD COLUMN reason TEXT;
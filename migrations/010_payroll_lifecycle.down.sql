@@ -0,0 +1,12 @@
+ALTER TABLE payrolls
+  DROP COLUMN approved_by,
+  DROP COLUMN approved_at,
+  DROP COLUMN published_at,
+  DROP COLUMN paid_at,
+  DROP CONSTRAINT payrolls_status_check;
+
+UPDATE payrolls SET status = 'active' WHERE status <> 'voided';
+
+ALTER TABLE payrolls
+  ALTER COLUMN status SET DEFAULT 'active',
+  ADD CONSTRAINT payrolls_status_check CHECK (status IN ('active', 'voided'));
@@ -0,0 +1,13 @@
+ALTER TABLE payrolls DROP CONSTRAINT payrolls_status_check;
+
+-- payrolls run so far were already visible to the employees
+UPDATE payrolls SET status = 'published' WHERE status = 'active';
+
+ALTER TABLE payrolls
+  ALTER COLUMN status SET DEFAULT 'draft',
+  ADD CONSTRAINT payrolls_status_check
+    CHECK (status IN ('draft', 'calculated', 'approved', 'published', 'paid', 'voided')),
+  ADD COLUMN approved_by UUID,
+  ADD COLUMN approved_at TIMESTAMP,
+  ADD COLUMN published_at TIMESTAMP,
+  ADD COLUMN paid_at TIMESTAMP;
@@ -0,0 +1,3 @@
+ALTER TABLE attendance_periods
+  DROP COLUMN locked_at,
+  DROP COLUMN locked_by;
@@ -0,0 +1,9 @@
+ALTER TABLE attendance_periods
+  ADD COLUMN locked_at TIMESTAMP,
+  ADD COLUMN locked_by UUID;
+
+-- periods already paid out are locked by the admin who ran their payroll
+UPDATE attendance_periods p
+SET locked_at = r.created_at, locked_by = r.created_by
+FROM payrolls r
+WHERE r.period_id = p.id AND r.status <> 'voided';
@@ -0,0 +1,3 @@
+ALTER TABLE attendance_periods
+  DROP CONSTRAINT attendance_periods_no_overlap,
+  DROP CONSTRAINT attendance_periods_date_range_check;
@@ -0,0 +1,5 @@
+-- overlapping periods already in the table have to be fixed by hand before this migration
+ALTER TABLE attendance_periods
+  ADD CONSTRAINT attendance_periods_date_range_check CHECK (start_date <= end_date),
+  ADD CONSTRAINT attendance_periods_no_overlap
+    EXCLUDE USING gist (daterange(start_date, end_date, '[]') WITH &&);
@@ -0,0 +1,10 @@
+ALTER TABLE company_settings
+  DROP COLUMN lateness_deduction,
+  DROP COLUMN lateness_grace_minutes;
+
+ALTER TABLE attendances
+  DROP COLUMN check_in_at,
+  DROP COLUMN check_out_at,
+  DROP COLUMN worked_minutes,
+  DROP COLUMN late_minutes,
+  DROP COLUMN early_leave_minutes;
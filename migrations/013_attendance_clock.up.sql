@@ -0,0 +1,13 @@
+ALTER TABLE attendances
+  ADD COLUMN check_in_at TIMESTAMP,
+  ADD COLUMN check_out_at TIMESTAMP,
+  ADD COLUMN worked_minutes INT NOT NULL DEFAULT 0,
+  ADD COLUMN late_minutes INT NOT NULL DEFAULT 0,
+  ADD COLUMN early_leave_minutes INT NOT NULL DEFAULT 0;
+
+-- attendances were submitted on arrival
+UPDATE attendances SET check_in_at = created_at;
+
+ALTER TABLE company_settings
+  ADD COLUMN lateness_deduction BOOLEAN NOT NULL DEFAULT false,
+  ADD COLUMN lateness_grace_minutes INT NOT NULL DEFAULT 0 CHECK (lateness_grace_minutes >= 0);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS rosters;
+DROP TABLE IF EXISTS shifts;
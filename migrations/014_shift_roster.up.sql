@@ -0,0 +1,24 @@
+CREATE TABLE shifts (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  name TEXT UNIQUE NOT NULL,
+  -- a shift ending at or before its start time ends on the next day
+  start_time TIME NOT NULL,
+  end_time TIME NOT NULL,
+  break_minutes INT NOT NULL DEFAULT 0 CHECK (break_minutes >= 0),
+  created_by UUID,
+  request_ip TEXT,
+  created_at TIMESTAMP DEFAULT now(),
+  updated_at TIMESTAMP DEFAULT now()
+);
+
+CREATE TABLE rosters (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES users(id),
+  date DATE NOT NULL,
+  shift_id UUID NOT NULL REFERENCES shifts(id),
+  created_by UUID,
+  request_ip TEXT,
+  created_at TIMESTAMP DEFAULT now(),
+  updated_at TIMESTAMP DEFAULT now(),
+  UNIQUE (user_id, date)
+);
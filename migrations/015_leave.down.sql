@@ -0,0 +1,7 @@
+ALTER TABLE payslips DROP COLUMN paid_leave_days, DROP COLUMN unpaid_leave_days;
+
+DROP TABLE IF EXISTS leave_requests;
+DROP TABLE IF EXISTS leave_balances;
+DROP TABLE IF EXISTS leave_types;
+
+ALTER TABLE users DROP COLUMN manager_id;
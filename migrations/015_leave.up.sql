@@ -0,0 +1,62 @@
+-- the manager approves the leave requests of the employee
+ALTER TABLE users ADD COLUMN manager_id UUID REFERENCES users(id);
+
+CREATE TABLE leave_types (
+  code TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  paid BOOLEAN NOT NULL,
+  -- leave types that accrue are taken from a yearly balance
+  accrues BOOLEAN NOT NULL DEFAULT false,
+  annual_entitlement INT NOT NULL DEFAULT 0 CHECK (annual_entitlement >= 0),
+  max_carry_over INT NOT NULL DEFAULT 0 CHECK (max_carry_over >= 0),
+  max_days_per_request INT CHECK (max_days_per_request > 0)
+);
+
+INSERT INTO leave_types (code, name, paid, accrues, annual_entitlement, max_carry_over, max_days_per_request) VALUES
+  ('ANNUAL', 'Annual leave', true, true, 12, 6, NULL),
+  ('SICK', 'Sick leave', true, false, 0, 0, NULL),
+  ('MATERNITY', 'Maternity leave', true, false, 0, 0, 90),
+  ('UNPAID', 'Unpaid leave', false, false, 0, 0, NULL);
+
+CREATE TABLE leave_balances (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES users(id),
+  leave_type TEXT NOT NULL REFERENCES leave_types(code),
+  year INT NOT NULL,
+  entitled INT NOT NULL DEFAULT 0,
+  carried_over INT NOT NULL DEFAULT 0,
+  used INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP DEFAULT now(),
+  updated_at TIMESTAMP DEFAULT now(),
+  UNIQUE (user_id, leave_type, year),
+  CHECK (used <= entitled + carried_over)
+);
+
+CREATE TABLE leave_requests (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  user_id UUID NOT NULL REFERENCES users(id),
+  leave_type TEXT NOT NULL REFERENCES leave_types(code),
+  start_date DATE NOT NULL,
+  end_date DATE NOT NULL,
+  -- working days taken from the balance
+  days INT NOT NULL CHECK (days > 0),
+  -- the days after new year of a leave over the year end, taken from the next year's balance
+  next_year_days INT NOT NULL DEFAULT 0 CHECK (next_year_days BETWEEN 0 AND days),
+  reason TEXT,
+  status TEXT NOT NULL DEFAULT 'pending'
+    CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+  reviewed_by UUID REFERENCES users(id),
+  reviewed_at TIMESTAMP,
+  review_note TEXT,
+  created_by UUID,
+  request_ip TEXT,
+  created_at TIMESTAMP DEFAULT now(),
+  updated_at TIMESTAMP DEFAULT now(),
+  CHECK (start_date <= end_date)
+);
+
+CREATE INDEX leave_requests_user_dates_idx ON leave_requests (user_id, start_date, end_date);
+
+ALTER TABLE payslips
+  ADD COLUMN paid_leave_days INT NOT NULL DEFAULT 0,
+  ADD COLUMN unpaid_leave_days INT NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS overtimes_status_idx;
+
+ALTER TABLE overtimes
+  DROP COLUMN review_note,
+  DROP COLUMN reviewed_at,
+  DROP COLUMN reviewed_by,
+  DROP COLUMN status;
@@ -0,0 +1,11 @@
+-- overtime submitted before the approval workflow was already paid as approved
+ALTER TABLE overtimes
+  ADD COLUMN status TEXT NOT NULL DEFAULT 'approved'
+    CHECK (status IN ('pending', 'approved', 'rejected', 'withdrawn')),
+  ADD COLUMN reviewed_by UUID REFERENCES users(id),
+  ADD COLUMN reviewed_at TIMESTAMP,
+  ADD COLUMN review_note TEXT;
+
+ALTER TABLE overtimes ALTER COLUMN status SET DEFAULT 'pending';
+
+CREATE INDEX overtimes_status_idx ON overtimes (status, date);
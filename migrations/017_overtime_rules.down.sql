@@ -0,0 +1,12 @@
+ALTER TABLE company_settings
+  DROP COLUMN overtime_divisor,
+  ADD COLUMN holiday_overtime_multiplier NUMERIC(4, 2) NOT NULL DEFAULT 3 CHECK (holiday_overtime_multiplier > 0);
+
+-- a flat public holiday tier goes back to being the holiday overtime multiplier
+UPDATE company_settings SET holiday_overtime_multiplier = r.multiplier
+FROM overtime_rules r
+WHERE r.day_type = 'public_holiday' AND r.from_hour = 0 AND r.to_hour IS NULL;
+
+DROP TABLE IF EXISTS overtime_rules;
+
+ALTER TABLE overtimes DROP COLUMN day_type;
@@ -0,0 +1,62 @@
+-- the kind of day the overtime was worked on decides its multipliers
+ALTER TABLE overtimes
+  ADD COLUMN day_type TEXT NOT NULL DEFAULT 'workday'
+    CHECK (day_type IN ('workday', 'rest_day', 'public_holiday'));
+
+UPDATE overtimes o SET day_type = 'public_holiday'
+WHERE EXISTS (SELECT 1 FROM holidays h WHERE h.date = o.date);
+
+-- an unrostered date is a rest day on the weekend, or any day for an employee rostered on other
+-- dates of its attendance period (of its month when no period covers it)
+UPDATE overtimes o SET day_type = 'rest_day'
+WHERE o.day_type = 'workday'
+  AND NOT EXISTS (SELECT 1 FROM rosters r WHERE r.user_id = o.user_id AND r.date = o.date)
+  AND (EXTRACT(ISODOW FROM o.date) IN (6, 7) OR EXISTS (
+    SELECT 1 FROM rosters r
+    WHERE r.user_id = o.user_id
+      AND r.date BETWEEN
+        COALESCE((SELECT p.start_date FROM attendance_periods p WHERE o.date BETWEEN p.start_date AND p.end_date),
+                 date_trunc('month', o.date)::date)
+      AND
+        COALESCE((SELECT p.end_date FROM attendance_periods p WHERE o.date BETWEEN p.start_date AND p.end_date),
+                 (date_trunc('month', o.date) + INTERVAL '1 month - 1 day')::date)
+  ));
+
+-- the hours of a day above from_hour up to to_hour are paid at the multiplier,
+-- the last tier of a day type has no upper hour
+CREATE TABLE overtime_rules (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  day_type TEXT NOT NULL CHECK (day_type IN ('workday', 'rest_day', 'public_holiday')),
+  from_hour INT NOT NULL CHECK (from_hour >= 0),
+  to_hour INT CHECK (to_hour > from_hour),
+  multiplier NUMERIC(4, 2) NOT NULL CHECK (multiplier > 0),
+  updated_by UUID,
+  updated_at TIMESTAMP DEFAULT now(),
+  UNIQUE (day_type, from_hour)
+);
+
+-- statutory overtime of a five working day week
+INSERT INTO overtime_rules (day_type, from_hour, to_hour, multiplier) VALUES
+  ('workday', 0, 1, 1.5),
+  ('workday', 1, NULL, 2),
+  ('rest_day', 0, 8, 2),
+  ('rest_day', 8, 9, 3),
+  ('rest_day', 9, NULL, 4);
+
+-- a holiday overtime multiplier the company changed from the default 3 keeps paying every
+-- public holiday hour, otherwise public holidays follow the statutory tiers too
+INSERT INTO overtime_rules (day_type, from_hour, to_hour, multiplier)
+SELECT 'public_holiday', 0, NULL, holiday_overtime_multiplier
+FROM company_settings
+WHERE holiday_overtime_multiplier <> 3
+LIMIT 1;
+
+INSERT INTO overtime_rules (day_type, from_hour, to_hour, multiplier)
+SELECT 'public_holiday', t.from_hour, t.to_hour, t.multiplier
+FROM (VALUES (0, 8, 2), (8, 9, 3), (9, NULL, 4)) AS t (from_hour, to_hour, multiplier)
+WHERE NOT EXISTS (SELECT 1 FROM overtime_rules WHERE day_type = 'public_holiday');
+
+-- overtime is paid at 1/173 of the monthly salary an hour, the ladder replaces the holiday multiplier
+ALTER TABLE company_settings
+  ADD COLUMN overtime_divisor NUMERIC(6, 2) NOT NULL DEFAULT 173 CHECK (overtime_divisor > 0),
+  DROP COLUMN holiday_overtime_multiplier;
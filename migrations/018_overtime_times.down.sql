@@ -0,0 +1,12 @@
+ALTER TABLE company_settings
+  DROP COLUMN max_overtime_hours_per_week,
+  DROP COLUMN max_overtime_hours_per_day;
+
+ALTER TABLE payslips ALTER COLUMN overtime_hours TYPE INTEGER USING ROUND(overtime_hours);
+
+ALTER TABLE overtimes
+  DROP CONSTRAINT IF EXISTS overtimes_start_end_check,
+  DROP COLUMN end_at,
+  DROP COLUMN start_at,
+  DROP CONSTRAINT IF EXISTS overtimes_hours_check,
+  ALTER COLUMN hours TYPE INTEGER USING CEIL(hours);
@@ -0,0 +1,14 @@
+-- overtime is submitted as a start and end time, its hours are fractional
+ALTER TABLE overtimes
+  DROP CONSTRAINT IF EXISTS overtimes_hours_check,
+  ALTER COLUMN hours TYPE NUMERIC(5, 2),
+  ADD CONSTRAINT overtimes_hours_check CHECK (hours > 0),
+  ADD COLUMN start_at TIMESTAMP,
+  ADD COLUMN end_at TIMESTAMP,
+  ADD CONSTRAINT overtimes_start_end_check CHECK (end_at > start_at);
+
+ALTER TABLE payslips ALTER COLUMN overtime_hours TYPE NUMERIC(6, 2);
+
+ALTER TABLE company_settings
+  ADD COLUMN max_overtime_hours_per_day NUMERIC(4, 2) NOT NULL DEFAULT 4 CHECK (max_overtime_hours_per_day > 0),
+  ADD COLUMN max_overtime_hours_per_week NUMERIC(5, 2) NOT NULL DEFAULT 18 CHECK (max_overtime_hours_per_week > 0);
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS reimbursements_status_idx;
+
+ALTER TABLE reimbursements
+  DROP CONSTRAINT IF EXISTS reimbursements_approved_amount_check,
+  DROP COLUMN review_note,
+  DROP COLUMN reviewed_at,
+  DROP COLUMN reviewed_by,
+  DROP COLUMN approved_amount,
+  DROP COLUMN status,
+  DROP COLUMN category;
+
+DROP TABLE IF EXISTS reimbursement_limits;
+DROP TABLE IF EXISTS reimbursement_categories;
+
+ALTER TABLE users DROP COLUMN grade;
@@ -0,0 +1,58 @@
+-- the grade of an employee decides their reimbursement limits
+ALTER TABLE users ADD COLUMN grade TEXT NOT NULL DEFAULT 'staff';
+
+CREATE TABLE reimbursement_categories (
+  code TEXT PRIMARY KEY,
+  name TEXT NOT NULL
+);
+
+INSERT INTO reimbursement_categories (code, name) VALUES
+  ('MEDICAL', 'Medical'),
+  ('TRANSPORT', 'Transport'),
+  ('MEALS', 'Meals'),
+  ('TRAINING', 'Training'),
+  ('OTHER', 'Other');
+
+-- a grade can only claim the categories it has a limit for, a NULL limit is unlimited
+CREATE TABLE reimbursement_limits (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  category TEXT NOT NULL REFERENCES reimbursement_categories(code),
+  grade TEXT NOT NULL,
+  per_claim_limit INT CHECK (per_claim_limit > 0),
+  per_period_limit INT CHECK (per_period_limit > 0),
+  updated_by UUID,
+  updated_at TIMESTAMP DEFAULT now(),
+  UNIQUE (category, grade)
+);
+
+INSERT INTO reimbursement_limits (category, grade, per_claim_limit, per_period_limit) VALUES
+  ('MEDICAL', 'staff', 2500000, 5000000),
+  ('TRANSPORT', 'staff', 500000, 1500000),
+  ('MEALS', 'staff', 150000, 1000000),
+  ('TRAINING', 'staff', 5000000, 5000000),
+  ('OTHER', 'staff', 500000, 1000000),
+  ('MEDICAL', 'manager', 5000000, 10000000),
+  ('TRANSPORT', 'manager', 1000000, 3000000),
+  ('MEALS', 'manager', 300000, 2000000),
+  ('TRAINING', 'manager', 10000000, 10000000),
+  ('OTHER', 'manager', 1000000, 2000000);
+
+-- claims submitted before the approval workflow were already paid as approved in full
+ALTER TABLE reimbursements
+  ADD COLUMN category TEXT NOT NULL DEFAULT 'OTHER' REFERENCES reimbursement_categories(code),
+  ADD COLUMN status TEXT NOT NULL DEFAULT 'approved'
+    CHECK (status IN ('pending', 'approved', 'rejected')),
+  ADD COLUMN approved_amount INT,
+  ADD COLUMN reviewed_by UUID REFERENCES users(id),
+  ADD COLUMN reviewed_at TIMESTAMP,
+  ADD COLUMN review_note TEXT;
+
+UPDATE reimbursements SET approved_amount = amount;
+
+ALTER TABLE reimbursements
+  ALTER COLUMN category DROP DEFAULT,
+  ALTER COLUMN status SET DEFAULT 'pending',
+  ADD CONSTRAINT reimbursements_approved_amount_check
+    CHECK (approved_amount > 0 AND approved_amount <= amount);
+
+CREATE INDEX reimbursements_status_idx ON reimbursements (status, created_at);
@@ -0,0 +1,17 @@
+CREATE TABLE reimbursement_attachments (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  reimbursement_id UUID NOT NULL REFERENCES reimbursements(id) ON DELETE CASCADE,
+  file_name TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  size BIGINT NOT NULL CHECK (size > 0),
+  -- SHA-256 of the file, a receipt can be attached to one active claim only
+  checksum TEXT NOT NULL,
+  storage_key TEXT NOT NULL,
+  -- cleared when the claim is rejected, so the receipt can be claimed again
+  active BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMP DEFAULT now()
+);
+
+CREATE UNIQUE INDEX reimbursement_attachments_checksum_key ON reimbursement_attachments (checksum) WHERE active;
+
+CREATE INDEX reimbursement_attachments_reimbursement_idx ON reimbursement_attachments (reimbursement_id);
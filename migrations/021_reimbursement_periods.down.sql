@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS reimbursements_unpaid_idx;
+
+ALTER TABLE reimbursements
+  DROP COLUMN paid_in_payroll_id,
+  DROP COLUMN period_id,
+  DROP COLUMN expense_date;
@@ -0,0 +1,28 @@
+-- the expense date decides the claim limits, the period is assigned when the claim is approved
+-- and the payroll that paid the claim is recorded so an unpaid claim rolls into the next run
+ALTER TABLE reimbursements
+  ADD COLUMN expense_date DATE,
+  ADD COLUMN period_id UUID REFERENCES attendance_periods(id) ON DELETE SET NULL,
+  ADD COLUMN paid_in_payroll_id UUID REFERENCES payrolls(id);
+
+UPDATE reimbursements SET expense_date = created_at::date;
+
+ALTER TABLE reimbursements ALTER COLUMN expense_date SET NOT NULL;
+
+-- approved claims were paid by the payroll of the period they were submitted in
+UPDATE reimbursements r SET period_id = p.id
+FROM attendance_periods p
+WHERE r.status = 'approved' AND r.created_at::date BETWEEN p.start_date AND p.end_date;
+
+-- only where the run was made after the claim and paid the employee a reimbursement
+UPDATE reimbursements r SET paid_in_payroll_id = pr.id
+FROM payrolls pr
+WHERE pr.period_id = r.period_id AND pr.status <> 'voided' AND r.created_at <= pr.created_at
+  AND EXISTS (
+    SELECT 1 FROM payslips s
+    JOIN payslip_items i ON i.payslip_id = s.id
+    WHERE s.payroll_id = pr.id AND s.user_id = r.user_id AND i.code = 'REIMBURSEMENT'
+  );
+
+CREATE INDEX reimbursements_unpaid_idx ON reimbursements (period_id)
+  WHERE status = 'approved' AND paid_in_payroll_id IS NULL;
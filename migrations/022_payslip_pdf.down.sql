@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS payslip_templates;
+
+ALTER TABLE company_settings
+  DROP COLUMN company_npwp,
+  DROP COLUMN company_address,
+  DROP COLUMN company_name;
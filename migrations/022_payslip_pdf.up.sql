@@ -0,0 +1,13 @@
+-- the company header of the payslip PDF
+ALTER TABLE company_settings
+  ADD COLUMN company_name TEXT NOT NULL DEFAULT '',
+  ADD COLUMN company_address TEXT NOT NULL DEFAULT '',
+  ADD COLUMN company_npwp TEXT NOT NULL DEFAULT '';
+
+-- the payslip template customised by HR, the built-in template is used while there is none
+CREATE TABLE payslip_templates (
+  id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+  body TEXT NOT NULL,
+  updated_by UUID REFERENCES users(id),
+  updated_at TIMESTAMP DEFAULT now()
+);
@@ -0,0 +1,9 @@
+ALTER TABLE company_settings
+  DROP COLUMN payslip_password_scheme;
+
+DROP INDEX IF EXISTS users_employee_number_key;
+
+ALTER TABLE users
+  DROP COLUMN payslip_pin,
+  DROP COLUMN employee_number,
+  DROP COLUMN birth_date;
@@ -0,0 +1,15 @@
+-- what the password of an employee's payslip PDFs is derived from. Existing employees are left
+-- without a birth date and employee number, HR fills them in through the personal profile and
+-- until then their payslips are withheld. payslip_pin holds the employee's PIN encrypted with
+-- AES-256-GCM under the application key PAYSLIP_PIN_KEY, never the PIN itself, so a dump of the
+-- database alone does not open the payslips
+ALTER TABLE users
+  ADD COLUMN birth_date DATE,
+  ADD COLUMN employee_number TEXT NOT NULL DEFAULT '',
+  ADD COLUMN payslip_pin TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX users_employee_number_key ON users(employee_number) WHERE employee_number <> '';
+
+ALTER TABLE company_settings
+  ADD COLUMN payslip_password_scheme TEXT NOT NULL DEFAULT 'birthdate_employee_number'
+    CHECK (payslip_password_scheme IN ('birthdate_employee_number', 'pin'));
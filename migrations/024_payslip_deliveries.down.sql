@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS payslip_deliveries;
+
+ALTER TABLE users
+  DROP COLUMN email;
@@ -0,0 +1,20 @@
+ALTER TABLE users
+  ADD COLUMN email TEXT NOT NULL DEFAULT '';
+
+-- the payslip email of each employee of a published payroll, sent by the delivery worker
+CREATE TABLE payslip_deliveries (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  payroll_id UUID NOT NULL REFERENCES payrolls(id),
+  user_id UUID NOT NULL REFERENCES users(id),
+  email TEXT NOT NULL,
+  status TEXT CHECK (status IN ('pending', 'sent', 'failed', 'cancelled')) NOT NULL DEFAULT 'pending',
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT NOT NULL DEFAULT '',
+  next_attempt_at TIMESTAMP NOT NULL DEFAULT now(),
+  sent_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT now(),
+  updated_at TIMESTAMP DEFAULT now(),
+  UNIQUE (payroll_id, user_id)
+);
+
+CREATE INDEX payslip_deliveries_due_idx ON payslip_deliveries(next_attempt_at) WHERE status = 'pending';
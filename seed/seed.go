@@ -14,18 +14,31 @@ import (
 func SeedUsers(db *gorm.DB) error {
 	password := "password"
 	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	startOfYear := time.Date(time.Now().Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
 
 	for i := 1; i <= 100; i++ {
+		birthDate := time.Date(1985+i%15, time.Month(1+i%12), 1+i%28, 0, 0, 0, 0, time.UTC)
 		employee := model.User{
-			ID:           uuid.New(),
-			Username:     fmt.Sprintf("employee%03d", i),
-			PasswordHash: string(hash),
-			Role:         "employee",
-			Salary:       rand.Intn(5_000_000) + 5_000_000,
-			CreatedAt:    time.Now(),
-			UpdatedAt:    time.Now(),
+			ID:             uuid.New(),
+			Username:       fmt.Sprintf("employee%03d", i),
+			PasswordHash:   string(hash),
+			Role:           "employee",
+			BirthDate:      &birthDate,
+			EmployeeNumber: fmt.Sprintf("EMP%03d", i),
+			Email:          fmt.Sprintf("employee%03d@example.com", i),
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
 		}
 		db.Create(&employee)
+
+		salary := model.SalaryHistory{
+			UserID:        employee.ID,
+			Salary:        rand.Intn(5_000_000) + 5_000_000,
+			EffectiveFrom: startOfYear,
+			Reason:        "initial salary",
+			CreatedAt:     time.Now(),
+		}
+		db.Create(&salary)
 	}
 
 	admin := model.User{
@@ -33,7 +46,6 @@ func SeedUsers(db *gorm.DB) error {
 		Username:     "admin",
 		PasswordHash: string(hash),
 		Role:         "admin",
-		Salary:       0,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
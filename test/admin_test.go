@@ -7,18 +7,21 @@ import (
 	"net/http/httptest"
 	"payslip-generation-system/internal/handler"
 	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
 	"payslip-generation-system/internal/repository"
 	"payslip-generation-system/internal/service"
 	"payslip-generation-system/test/testutils"
 	"testing"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 func TestSubmitAttendancePeriod_Success(t *testing.T) {
 	db := testutils.DB
 	repo := repository.NewAdminRepository(db)
 	payrollRepo := repository.NewPayrollRepository(db)
-	service := service.NewPayrollService(payrollRepo)
+	service := service.NewPayrollService(payrollRepo, repository.NewUnitOfWork(db))
 	adminHandler := handler.NewAdminHandler(repo, service)
 	h := adminHandler.CreateAttendancePeriodHandler()
 	protected := middleware.AuthMiddleware(h)
@@ -49,7 +52,7 @@ func TestRunPayroll_Success(t *testing.T) {
 	db := testutils.DB
 	repo := repository.NewAdminRepository(db)
 	payrollRepo := repository.NewPayrollRepository(db)
-	service := service.NewPayrollService(payrollRepo)
+	service := service.NewPayrollService(payrollRepo, repository.NewUnitOfWork(db))
 	adminHandler := handler.NewAdminHandler(repo, service)
 	runHandler := adminHandler.RunPayroll()
 
@@ -76,7 +79,7 @@ func TestSummaryPayslips_Success(t *testing.T) {
 	db := testutils.DB
 	repo := repository.NewAdminRepository(db)
 	payrollRepo := repository.NewPayrollRepository(db)
-	service := service.NewPayrollService(payrollRepo)
+	service := service.NewPayrollService(payrollRepo, repository.NewUnitOfWork(db))
 	adminHandler := handler.NewAdminHandler(repo, service)
 	h := adminHandler.GetPayslipSummaryHandler()
 	protected := middleware.AuthMiddleware(h)
@@ -104,3 +107,259 @@ func TestSummaryPayslips_Success(t *testing.T) {
 		t.Error("expected summary payslip data as list")
 	}
 }
+
+func TestCreateSalaryChange_PaidPeriod(t *testing.T) {
+	db := testutils.DB
+	repo := repository.NewAdminRepository(db)
+	payrollRepo := repository.NewPayrollRepository(db)
+	service := service.NewPayrollService(payrollRepo, repository.NewUnitOfWork(db))
+	adminHandler := handler.NewAdminHandler(repo, service)
+	protected := middleware.AuthMiddleware(adminHandler.CreateSalaryChangeHandler())
+
+	token := testutils.GetTokenFor(t, "admin", "password")
+	employee := testutils.SeedEmployee(t, "salary004")
+	t.Cleanup(func() { db.Exec("DELETE FROM salary_histories WHERE user_id = ?", employee.ID) })
+
+	// the earliest period has a payroll, a change from its start would alter a paid salary
+	payroll, err := payrollRepo.GetPayroll(testutils.SeedPayroll(t, "employee999", model.PayrollCalculated))
+	if err != nil {
+		t.Fatalf("failed to get seeded payroll: %v", err)
+	}
+	period, err := repo.GetAttendancePeriod(payroll.PeriodID)
+	if err != nil {
+		t.Fatalf("failed to get seeded period: %v", err)
+	}
+
+	create := func(effectiveFrom time.Time) int {
+		body := map[string]interface{}{
+			"userID":        employee.ID.String(),
+			"salary":        9000000,
+			"effectiveFrom": effectiveFrom.Format("2006-01-02"),
+		}
+		jsonBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/salary", bytes.NewReader(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		protected.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := create(period.StartDate); code != http.StatusConflict {
+		t.Errorf("expected status 409 for a paid period, got %d", code)
+	}
+	future := time.Date(2999, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if code := create(future); code != http.StatusCreated {
+		t.Fatalf("expected status 201 for a future change, got %d", code)
+	}
+
+	history, err := repo.GetSalaryHistory(employee.ID)
+	if err != nil {
+		t.Fatalf("failed to get salary history: %v", err)
+	}
+	if len(history) != 1 || history[0].Salary != 9000000 || !history[0].EffectiveFrom.Equal(future) {
+		t.Errorf("expected only the future salary saved, got %+v", history)
+	}
+}
+
+func TestPreviewPayroll_MatchesRun(t *testing.T) {
+	db := testutils.DB
+	repo := repository.NewAdminRepository(db)
+	payrollRepo := repository.NewPayrollRepository(db)
+	service := service.NewPayrollService(payrollRepo, repository.NewUnitOfWork(db))
+	adminHandler := handler.NewAdminHandler(repo, service)
+	h := adminHandler.PreviewPayrollHandler()
+
+	token := testutils.GetTokenFor(t, "admin", "password")
+	employee := testutils.SeedEmployee(t, "preview008")
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM payslips WHERE user_id = ?", employee.ID)
+		db.Exec("DELETE FROM salary_histories WHERE user_id = ?", employee.ID)
+	})
+	db.Create(&model.SalaryHistory{
+		UserID:        employee.ID,
+		Salary:        9000000,
+		EffectiveFrom: time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:     time.Now(),
+	})
+	period := testutils.SeedPeriod(t)
+
+	body := map[string]interface{}{
+		"attendancePeriodId": period.ID.String(),
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/payroll-preview", bytes.NewReader(jsonBody))
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	middleware.AuthMiddleware(h).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp struct {
+		Data handler.PayrollPreviewResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode preview: %v", err)
+	}
+
+	var preview *handler.PayslipPreviewResponse
+	netPay := 0
+	for i, e := range resp.Data.Employees {
+		netPay += e.NetPay
+		if e.UserID == employee.ID {
+			preview = &resp.Data.Employees[i]
+		}
+	}
+	if preview == nil {
+		t.Fatal("expected the employee in the preview")
+	}
+	if preview.BaseSalary != 9000000 {
+		t.Errorf("expected base salary 9000000, got %d", preview.BaseSalary)
+	}
+	if resp.Data.Totals.NetPay != netPay || resp.Data.Totals.Employees != len(resp.Data.Employees) {
+		t.Errorf("expected the totals of the employees, got %+v", resp.Data.Totals)
+	}
+
+	// nothing is saved, the period can still be run and pays what the preview showed
+	if run, err := payrollRepo.IsPayrollRun(period.ID); err != nil || run {
+		t.Fatalf("expected no payroll saved by the preview, got %v (%v)", run, err)
+	}
+	var admin model.User
+	db.Where("username = ?", "admin").First(&admin)
+	if err := service.ProcessPayroll(period.ID, admin.ID, "127.0.0.1", uuid.NewString()); err != nil {
+		t.Fatalf("failed to run payroll: %v", err)
+	}
+	var payslip model.Payslip
+	if err := db.Joins("JOIN payrolls ON payrolls.id = payslips.payroll_id").
+		Where("payrolls.period_id = ? AND payslips.user_id = ?", period.ID, employee.ID).First(&payslip).Error; err != nil {
+		t.Fatalf("failed to get payslip: %v", err)
+	}
+	if payslip.NetPay != preview.NetPay || payslip.GrossPay != preview.GrossPay {
+		t.Errorf("expected the run to pay the preview's %d net of %d gross, got %d of %d",
+			preview.NetPay, preview.GrossPay, payslip.NetPay, payslip.GrossPay)
+	}
+}
+
+func TestReversePayroll_AllowsRerun(t *testing.T) {
+	db := testutils.DB
+	repo := repository.NewAdminRepository(db)
+	payrollRepo := repository.NewPayrollRepository(db)
+	service := service.NewPayrollService(payrollRepo, repository.NewUnitOfWork(db))
+	adminHandler := handler.NewAdminHandler(repo, service)
+	h := middleware.AuthMiddleware(adminHandler.ReversePayrollHandler())
+
+	token := testutils.GetTokenFor(t, "admin", "password")
+	payrollID := testutils.SeedPayroll(t, "employee999", model.PayrollPublished)
+	payslips, err := payrollRepo.GetPayslips(payrollID)
+	if err != nil || len(payslips) != 1 {
+		t.Fatalf("failed to get seeded payslip: %v", err)
+	}
+
+	reverse := func() int {
+		body := map[string]interface{}{
+			"payrollID": payrollID.String(),
+			"reason":    "attendance entered wrong",
+		}
+		jsonBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/payroll-reverse", bytes.NewReader(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := reverse(); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	voided, err := payrollRepo.GetPayroll(payrollID)
+	if err != nil {
+		t.Fatalf("failed to get payroll: %v", err)
+	}
+	if voided.Status != model.PayrollVoided || voided.VoidReason != "attendance entered wrong" || voided.VoidedBy == nil {
+		t.Errorf("expected the payroll voided with its reason, got %s %q", voided.Status, voided.VoidReason)
+	}
+	if code := reverse(); code != http.StatusConflict {
+		t.Errorf("expected reversing again status 409, got %d", code)
+	}
+
+	// the period runs again and the new payslip points at the one it replaces
+	if err := service.ProcessPayroll(voided.PeriodID, voided.CreatedBy, "127.0.0.1", uuid.NewString()); err != nil {
+		t.Fatalf("failed to re-run payroll: %v", err)
+	}
+	var rerun model.Payroll
+	if err := db.Where("period_id = ? AND status <> ?", voided.PeriodID, model.PayrollVoided).First(&rerun).Error; err != nil {
+		t.Fatalf("failed to get the re-run payroll: %v", err)
+	}
+	if rerun.SupersedesPayrollID == nil || *rerun.SupersedesPayrollID != payrollID {
+		t.Errorf("expected the re-run to supersede %s, got %v", payrollID, rerun.SupersedesPayrollID)
+	}
+	var payslip model.Payslip
+	if err := db.Where("payroll_id = ? AND user_id = ?", rerun.ID, payslips[0].UserID).First(&payslip).Error; err != nil {
+		t.Fatalf("failed to get the re-run payslip: %v", err)
+	}
+	if payslip.SupersedesPayslipID == nil || *payslip.SupersedesPayslipID != payslips[0].ID {
+		t.Errorf("expected the payslip to supersede %s, got %v", payslips[0].ID, payslip.SupersedesPayslipID)
+	}
+}
+
+func TestUnlockAttendancePeriod_Audited(t *testing.T) {
+	db := testutils.DB
+	repo := repository.NewAdminRepository(db)
+	payrollRepo := repository.NewPayrollRepository(db)
+	service := service.NewPayrollService(payrollRepo, repository.NewUnitOfWork(db))
+	adminHandler := handler.NewAdminHandler(repo, service)
+	h := middleware.AuthMiddleware(adminHandler.UnlockAttendancePeriodHandler())
+
+	token := testutils.GetTokenFor(t, "admin", "password")
+	period := testutils.SeedPeriod(t)
+	if err := payrollRepo.LockAttendancePeriod(period.ID, period.CreatedBy); err != nil {
+		t.Fatalf("failed to lock period: %v", err)
+	}
+
+	unlock := func(reason string) int {
+		body := map[string]interface{}{
+			"attendancePeriodId": period.ID.String(),
+			"reason":             reason,
+		}
+		jsonBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/attendance-period-unlock", bytes.NewReader(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+	locked := func() bool {
+		p, err := repo.GetAttendancePeriod(period.ID)
+		if err != nil {
+			t.Fatalf("failed to get period: %v", err)
+		}
+		return p.LockedAt != nil
+	}
+
+	if code := unlock(" "); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without a reason, got %d", code)
+	}
+	if !locked() {
+		t.Fatal("expected the period to stay locked without a reason")
+	}
+
+	if code := unlock("late sick note"); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if locked() {
+		t.Error("expected the period unlocked")
+	}
+	var audit model.AuditLog
+	if err := db.Where("record_id = ? AND reason = ?", period.ID, "late sick note").First(&audit).Error; err != nil {
+		t.Errorf("expected the unlock audited with its reason: %v", err)
+	}
+
+	if code := unlock("late sick note"); code != http.StatusConflict {
+		t.Errorf("expected unlocking an open period status 409, got %d", code)
+	}
+}
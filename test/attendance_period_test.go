@@ -0,0 +1,102 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"payslip-generation-system/internal/handler"
+	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"payslip-generation-system/internal/service"
+	"payslip-generation-system/test/testutils"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestCreateAttendancePeriod_Overlap(t *testing.T) {
+	db := testutils.DB
+	repo := repository.NewAdminRepository(db)
+	payrollRepo := repository.NewPayrollRepository(db)
+	service := service.NewPayrollService(payrollRepo, repository.NewUnitOfWork(db))
+	adminHandler := handler.NewAdminHandler(repo, service)
+	h := middleware.AuthMiddleware(adminHandler.CreateAttendancePeriodHandler())
+
+	token := testutils.GetTokenFor(t, "admin", "password")
+
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM attendance_periods WHERE start_date IN ?", []string{"1990-03-01", "1990-05-01"})
+	})
+	create := func(start, end string) int {
+		body := map[string]interface{}{
+			"startDate": start,
+			"endDate":   end,
+		}
+		jsonBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/attendance-period", bytes.NewReader(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := create("1990-03-01", "1990-03-31"); code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", code)
+	}
+	if code := create("1990-03-15", "1990-04-14"); code != http.StatusConflict {
+		t.Errorf("expected overlapping period status 409, got %d", code)
+	}
+	var count int64
+	db.Model(&model.AttendancePeriod{}).Where("start_date = ?", "1990-03-15").Count(&count)
+	if count != 0 {
+		t.Error("expected the overlapping period not saved")
+	}
+	if code := create("1990-05-01", "1990-05-01"); code != http.StatusCreated {
+		t.Errorf("expected one day period status 201, got %d", code)
+	}
+}
+
+func TestDeleteAttendancePeriod_PayrollRun(t *testing.T) {
+	db := testutils.DB
+	repo := repository.NewAdminRepository(db)
+	payrollRepo := repository.NewPayrollRepository(db)
+	service := service.NewPayrollService(payrollRepo, repository.NewUnitOfWork(db))
+	adminHandler := handler.NewAdminHandler(repo, service)
+	h := middleware.AuthMiddleware(adminHandler.DeleteAttendancePeriodHandler())
+
+	payrollID := testutils.SeedPayroll(t, "employee999", model.PayrollCalculated)
+	payroll, err := payrollRepo.GetPayroll(payrollID)
+	if err != nil {
+		t.Fatalf("failed to get seeded payroll: %v", err)
+	}
+	open := testutils.SeedPeriod(t)
+
+	token := testutils.GetTokenFor(t, "admin", "password")
+
+	remove := func(id uuid.UUID) int {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/attendance-period-delete?id="+id.String(), nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// a period with a payroll keeps it, one without is removed
+	if code := remove(payroll.PeriodID); code != http.StatusConflict {
+		t.Errorf("expected status 409, got %d", code)
+	}
+	if _, err := repo.GetAttendancePeriod(payroll.PeriodID); err != nil {
+		t.Errorf("expected the period of the payroll kept, got %v", err)
+	}
+	if code := remove(open.ID); code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", code)
+	}
+	if _, err := repo.GetAttendancePeriod(open.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected the period without payroll removed, got %v", err)
+	}
+}
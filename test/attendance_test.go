@@ -0,0 +1,68 @@
+package test
+
+import (
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/service"
+	"testing"
+	"time"
+)
+
+func TestWorkSchedule(t *testing.T) {
+	schedule := service.WorkSchedule{Start: 9 * time.Hour, End: 17 * time.Hour}
+	date := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC)
+
+	checkIn := time.Date(2025, time.March, 3, 9, 12, 30, 0, time.UTC)
+	if got := schedule.LateMinutes(date, checkIn); got != 12 {
+		t.Errorf("expected 12 minutes late, got %d", got)
+	}
+	if got := schedule.LateMinutes(date, checkIn.Add(-time.Hour)); got != 0 {
+		t.Errorf("expected early check in not to be late, got %d", got)
+	}
+
+	checkOut := time.Date(2025, time.March, 3, 16, 15, 0, 0, time.UTC)
+	if got := schedule.EarlyLeaveMinutes(date, checkOut); got != 45 {
+		t.Errorf("expected 45 minutes early leave, got %d", got)
+	}
+	if got := schedule.WorkedMinutes(checkIn, checkOut); got != 422 {
+		t.Errorf("expected 422 worked minutes, got %d", got)
+	}
+}
+
+func TestWorkScheduleFromShift_CrossingMidnight(t *testing.T) {
+	schedule, err := service.WorkScheduleFromShift(&model.Shift{StartTime: "22:00:00", EndTime: "06:00:00", BreakMinutes: 30})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !schedule.CrossesMidnight() {
+		t.Fatal("expected night shift to cross midnight")
+	}
+
+	date := time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC)
+	checkIn := time.Date(2025, time.March, 3, 22, 5, 0, 0, time.UTC)
+	checkOut := time.Date(2025, time.March, 4, 5, 30, 0, 0, time.UTC)
+
+	if got := schedule.LateMinutes(date, checkIn); got != 5 {
+		t.Errorf("expected 5 minutes late, got %d", got)
+	}
+	if got := schedule.EarlyLeaveMinutes(date, checkOut); got != 30 {
+		t.Errorf("expected 30 minutes early leave on the next morning, got %d", got)
+	}
+	if got := schedule.WorkedMinutes(checkIn, checkOut); got != 415 {
+		t.Errorf("expected 415 worked minutes after the break, got %d", got)
+	}
+}
+
+func TestWorkSchedule_LateEastOfUTC(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	schedule := service.WorkSchedule{Start: 6 * time.Hour, End: 14 * time.Hour}
+
+	// checked in at 06:20 in Jakarta, still the day before in UTC
+	checkIn := time.Date(2025, time.March, 4, 6, 20, 0, 0, jakarta)
+	today := service.Midnight(checkIn, checkIn.Location())
+	if today.Day() != 4 {
+		t.Fatalf("expected the local date, got %s", today)
+	}
+	if got := schedule.LateMinutes(today, checkIn); got != 20 {
+		t.Errorf("expected 20 minutes late, got %d", got)
+	}
+}
@@ -0,0 +1,18 @@
+package test
+
+import (
+	"payslip-generation-system/internal/service"
+	"testing"
+)
+
+func TestBPJSContribution(t *testing.T) {
+	healthCap := 12_000_000
+
+	if got := service.BPJSContribution(15_000_000, 1.00, &healthCap); got != 120_000 {
+		t.Errorf("expected capped contribution 120000, got %d", got)
+	}
+
+	if got := service.BPJSContribution(8_000_000, 3.70, nil); got != 296_000 {
+		t.Errorf("expected uncapped contribution 296000, got %d", got)
+	}
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/service"
+	"testing"
+	"time"
+)
+
+func TestWorkCalendar_WorkingDays(t *testing.T) {
+	period := &model.AttendancePeriod{
+		StartDate: time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC),
+	}
+	settings := &model.CompanySettings{
+		HoursPerDay:    8,
+		ProrationBasis: model.ProrationWorkingDays,
+		FixedDivisor:   21.67,
+	}
+	holidays := []model.Holiday{
+		{Date: time.Date(2025, time.June, 6, 0, 0, 0, 0, time.UTC), Name: "Idul Adha"},
+	}
+
+	calendar := service.NewWorkCalendar(period, settings, holidays)
+	if got := calendar.WorkingDays(); got != 20 {
+		t.Errorf("expected 20 working days, got %d", got)
+	}
+
+	settings.ProrationBasis = model.ProrationFixed
+	divisor, err := service.NewWorkCalendar(period, settings, holidays).Divisor()
+	if err != nil || divisor != 21.67 {
+		t.Errorf("expected fixed divisor 21.67, got %v (%v)", divisor, err)
+	}
+}
+
+func TestBaseSalary_FixedDivisorSalaryChange(t *testing.T) {
+	period := &model.AttendancePeriod{
+		StartDate: time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC),
+	}
+	settings := &model.CompanySettings{HoursPerDay: 8, ProrationBasis: model.ProrationFixed, FixedDivisor: 21.67}
+	calendar := service.NewWorkCalendar(period, settings, nil)
+
+	// attended every day of the period with a raise on the 16th, more days than the divisor
+	ctx := &service.PayContext{
+		AttendanceDates: calendar.Days(),
+		Calendar:        calendar,
+		Salaries: []model.SalaryHistory{
+			{Salary: 10000000, EffectiveFrom: period.StartDate},
+			{Salary: 20000000, EffectiveFrom: time.Date(2025, time.June, 16, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	items, err := (&service.BaseSalaryComponent{}).Evaluate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// half of the month at each salary
+	if len(items) != 2 || items[0].Amount != 5000000 || items[1].Amount != 10000000 {
+		t.Errorf("expected 5000000 and 10000000, got %+v", items)
+	}
+}
@@ -7,15 +7,20 @@ import (
 	"net/http/httptest"
 	"payslip-generation-system/internal/handler"
 	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
 	"payslip-generation-system/internal/repository"
+	"payslip-generation-system/internal/service"
 	"payslip-generation-system/test/testutils"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // func TestSubmitAttendance_Success(t *testing.T) {
 // 	db := testutils.DB
 // 	repo := repository.NewEmployeeRepository(db)
-// 	employeeHandler := handler.NewEmployeeHandler(repo)
+// 	employeeHandler := handler.NewEmployeeHandler(repo, service.NewPayslipService(repository.NewPayslipRepository(db)))
 // 	handler := employeeHandler.SubmitAttendanceHanlder()
 
 // 	// Simulate login
@@ -37,14 +42,21 @@ import (
 func TestSubmitOvertime_Success(t *testing.T) {
 	db := testutils.DB
 	repo := repository.NewEmployeeRepository(db)
-	employeeHandler := handler.NewEmployeeHandler(repo)
+	employeeHandler := handler.NewEmployeeHandler(repo, service.NewPayslipService(repository.NewPayslipRepository(db)))
 	h := employeeHandler.SubmitOvertimeHandler()
 	protected := middleware.AuthMiddleware(h)
 
 	token := testutils.GetTokenFor(t, "employee001", "password")
 
+	// a saturday of a past week no period covers, removed again so repeated runs don't overlap
+	saturday := time.Date(1980, time.January, 5, 0, 0, 0, 0, time.UTC)
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM overtimes WHERE date = ? AND user_id = (SELECT id FROM users WHERE username = ?)", saturday, "employee001")
+	})
 	body := map[string]interface{}{
-		"hours": 2,
+		"date":      saturday.Format("2006-01-02"),
+		"startTime": "09:00",
+		"endTime":   "11:30",
 	}
 	jsonBody, _ := json.Marshal(body)
 
@@ -65,13 +77,18 @@ func TestSubmitOvertime_Success(t *testing.T) {
 func TestSubmitReimbursement_Success(t *testing.T) {
 	db := testutils.DB
 	repo := repository.NewEmployeeRepository(db)
-	employeeHandler := handler.NewEmployeeHandler(repo)
+	employeeHandler := handler.NewEmployeeHandler(repo, service.NewPayslipService(repository.NewPayslipRepository(db)))
 	h := employeeHandler.SubmitReimbursementHandler()
 	protected := middleware.AuthMiddleware(h)
 
 	token := testutils.GetTokenFor(t, "employee001", "password")
 
+	// clear the pending claims of earlier runs so they don't add up to the period limit
+	db.Exec(`DELETE FROM reimbursements WHERE status = ? AND user_id = (SELECT id FROM users WHERE username = ?)`,
+		model.ReimbursementPending, "employee001")
+
 	body := map[string]interface{}{
+		"category":    "other",
 		"amount":      250000,
 		"description": "Internet allowance",
 	}
@@ -94,14 +111,15 @@ func TestSubmitReimbursement_Success(t *testing.T) {
 func TestGeneratePayslip_Success(t *testing.T) {
 	db := testutils.DB
 	repo := repository.NewEmployeeRepository(db)
-	employeeHandler := handler.NewEmployeeHandler(repo)
+	employeeHandler := handler.NewEmployeeHandler(repo, service.NewPayslipService(repository.NewPayslipRepository(db)))
 	h := employeeHandler.GetPayslipHandler()
 
-	// Seed attendance + reimbursement + period
-	token := testutils.GetTokenFor(t, "employee001", "password")
+	// Seed a published payroll with a payslip of the employee
+	payrollID := testutils.SeedPayroll(t, "employee999", model.PayrollPublished)
+	token := testutils.GetTokenFor(t, "employee999", "password")
 
 	body := map[string]interface{}{
-		"payrollID": "335b1d33-eddb-4a8d-9acf-eef397f6e7e2",
+		"payrollID": payrollID.String(),
 	}
 	jsonBody, _ := json.Marshal(body)
 
@@ -123,3 +141,404 @@ func TestGeneratePayslip_Success(t *testing.T) {
 		t.Error("expected payslip data in response")
 	}
 }
+
+func TestGeneratePayslip_YearToDate(t *testing.T) {
+	db := testutils.DB
+	repo := repository.NewEmployeeRepository(db)
+	employeeHandler := handler.NewEmployeeHandler(repo, service.NewPayslipService(repository.NewPayslipRepository(db)))
+	h := middleware.AuthMiddleware(employeeHandler.GetPayslipHandler())
+
+	employee := testutils.SeedEmployee(t, "ytd"+uuid.NewString()[:8])
+	t.Cleanup(func() { db.Exec("DELETE FROM payslips WHERE user_id = ?", employee.ID) })
+	token := testutils.GetTokenFor(t, employee.Username, "password")
+
+	// each seeded period comes before the last one, the later payroll is seeded first
+	later := testutils.SeedPayroll(t, employee.Username, model.PayrollPublished)
+	earlier := testutils.SeedPayroll(t, employee.Username, model.PayrollPublished)
+	db.Model(&model.Payslip{}).Where("payroll_id = ?", later).Updates(map[string]interface{}{"gross_pay": 2000000, "net_pay": 1900000})
+	db.Model(&model.Payslip{}).Where("payroll_id = ?", earlier).Updates(map[string]interface{}{"gross_pay": 1000000, "net_pay": 950000})
+
+	get := func(payrollID string) (int, handler.PayslipResponse) {
+		jsonBody, _ := json.Marshal(map[string]interface{}{"payrollID": payrollID})
+		req := httptest.NewRequest(http.MethodPost, "/employee/payslip", bytes.NewReader(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		var resp struct {
+			Data handler.PayslipResponse `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	if code, _ := get("not-a-uuid"); code != http.StatusBadRequest {
+		t.Errorf("expected a malformed payroll ID status 400, got %d", code)
+	}
+
+	code, payslip := get(earlier.String())
+	if code != http.StatusOK || payslip.GrossPay != 1000000 || payslip.YearToDate.GrossPay != 1000000 {
+		t.Errorf("expected the earlier payslip's year to date to be itself, got %d %+v", code, payslip)
+	}
+
+	// the later payslip adds up the earlier one of the same year
+	want := 2000000
+	laterPayroll, earlierPayroll := model.Payroll{}, model.Payroll{}
+	db.Where("id = ?", later).First(&laterPayroll)
+	db.Where("id = ?", earlier).First(&earlierPayroll)
+	laterPeriod, earlierPeriod := model.AttendancePeriod{}, model.AttendancePeriod{}
+	db.Where("id = ?", laterPayroll.PeriodID).First(&laterPeriod)
+	db.Where("id = ?", earlierPayroll.PeriodID).First(&earlierPeriod)
+	if laterPeriod.EndDate.Year() == earlierPeriod.EndDate.Year() {
+		want += 1000000
+	}
+	code, payslip = get(later.String())
+	if code != http.StatusOK || payslip.GrossPay != 2000000 || payslip.YearToDate.GrossPay != want {
+		t.Errorf("expected the later payslip's year to date gross %d, got %d %+v", want, code, payslip)
+	}
+}
+
+func TestGeneratePayslip_UnpublishedPayroll(t *testing.T) {
+	db := testutils.DB
+	repo := repository.NewEmployeeRepository(db)
+	employeeHandler := handler.NewEmployeeHandler(repo, service.NewPayslipService(repository.NewPayslipRepository(db)))
+	h := middleware.AuthMiddleware(employeeHandler.GetPayslipHandler())
+	payrollService := service.NewPayrollService(repository.NewPayrollRepository(db), repository.NewUnitOfWork(db))
+
+	// payslips of a payroll waiting for approval are not visible yet
+	payrollID := testutils.SeedPayroll(t, "employee999", model.PayrollCalculated)
+	token := testutils.GetTokenFor(t, "employee999", "password")
+	approver := testutils.SeedEmployee(t, "approver"+uuid.NewString()[:8])
+	db.Model(&approver).Update("role", "admin")
+
+	get := func() int {
+		body := map[string]interface{}{
+			"payrollID": payrollID.String(),
+		}
+		jsonBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(http.MethodPost, "/employee/payslip", bytes.NewReader(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// the payslip shows once the payroll is published, not before
+	for _, status := range []string{model.PayrollApproved, model.PayrollPublished} {
+		if code := get(); code != http.StatusNotFound {
+			t.Errorf("expected status 404 before %s, got %d", status, code)
+		}
+		if err := payrollService.TransitionPayroll(payrollID, approver.ID, status, "127.0.0.1", uuid.NewString()); err != nil {
+			t.Fatalf("failed to move the payroll to %s: %v", status, err)
+		}
+	}
+	if code := get(); code != http.StatusOK {
+		t.Errorf("expected status 200 once published, got %d", code)
+	}
+}
+
+func TestGeneratePayslip_PDF(t *testing.T) {
+	db := testutils.DB
+	repo := repository.NewEmployeeRepository(db)
+	employeeHandler := handler.NewEmployeeHandler(repo, service.NewPayslipService(repository.NewPayslipRepository(db)))
+	h := employeeHandler.GetPayslipHandler()
+
+	payrollID := testutils.SeedPayroll(t, "employee999", model.PayrollPublished)
+	token := testutils.GetTokenFor(t, "employee999", "password")
+
+	body := map[string]interface{}{
+		"payrollID": payrollID.String(),
+		"format":    "pdf",
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	generate := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/employee/payslip", bytes.NewReader(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		middleware.AuthMiddleware(h).ServeHTTP(w, req)
+		return w
+	}
+
+	// without a birth date and employee number there is no password to encrypt the PDF with
+	if w := generate(); w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 without a payslip password, got %d", w.Code)
+	}
+
+	birthDate := time.Date(1990, time.August, 15, 0, 0, 0, 0, time.UTC)
+	if err := db.Model(&model.User{}).Where("username = ?", "employee999").
+		Updates(map[string]interface{}{"birth_date": birthDate, "employee_number": "EMP999"}).Error; err != nil {
+		t.Fatalf("failed to set the personal profile: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Model(&model.User{}).Where("username = ?", "employee999").
+			Updates(map[string]interface{}{"birth_date": nil, "employee_number": ""})
+	})
+
+	w := generate()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/pdf" {
+		t.Errorf("expected a PDF, got %s", contentType)
+	}
+	if !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")) || !bytes.Contains(w.Body.Bytes(), []byte("/Encrypt ")) {
+		t.Error("expected an encrypted PDF")
+	}
+}
+
+func TestSubmitReimbursement_LockedPeriodRollsOver(t *testing.T) {
+	db := testutils.DB
+	repo := repository.NewEmployeeRepository(db)
+	employeeHandler := handler.NewEmployeeHandler(repo, service.NewPayslipService(repository.NewPayslipRepository(db)))
+	protected := middleware.AuthMiddleware(employeeHandler.SubmitReimbursementHandler())
+
+	var admin model.User
+	if err := db.Where("username = ?", "admin").First(&admin).Error; err != nil {
+		t.Fatalf("failed to find admin: %v", err)
+	}
+	employee := testutils.SeedEmployee(t, "claimant021")
+	token := testutils.GetTokenFor(t, "claimant021", "password")
+
+	// the expense falls in a locked period, the open period after it pays the claim
+	open := testutils.SeedPeriod(t)
+	locked := testutils.SeedPeriod(t)
+	db.Model(&locked).Update("locked_at", time.Now())
+	t.Cleanup(func() { db.Exec("DELETE FROM reimbursements WHERE user_id = ?", employee.ID) })
+
+	body := map[string]interface{}{
+		"category":    "transport",
+		"amount":      100000,
+		"description": "Taxi to client site",
+		"expenseDate": locked.StartDate.Format("2006-01-02"),
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/employee/reimbursement", bytes.NewReader(jsonBody))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	protected.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", w.Code)
+	}
+
+	var claim model.Reimbursement
+	if err := db.Where("user_id = ?", employee.ID).First(&claim).Error; err != nil {
+		t.Fatalf("failed to get claim: %v", err)
+	}
+	reimbursementService := service.NewReimbursementService(repository.NewUnitOfWork(db))
+	approved, err := reimbursementService.ReviewReimbursement(claim.ID, admin.ID, "admin", true, nil, "")
+	if err != nil {
+		t.Fatalf("failed to approve claim: %v", err)
+	}
+	if approved.PeriodID == nil || *approved.PeriodID != open.ID {
+		t.Errorf("expected the claim assigned to the open period %s, got %v", open.ID, approved.PeriodID)
+	}
+}
+
+func TestUpdatePayslipPIN(t *testing.T) {
+	t.Setenv("PAYSLIP_PIN_KEY", "test-payslip-pin-key")
+	db := testutils.DB
+	repo := repository.NewEmployeeRepository(db)
+	employeeHandler := handler.NewEmployeeHandler(repo, service.NewPayslipService(repository.NewPayslipRepository(db)))
+	protected := middleware.AuthMiddleware(employeeHandler.UpdatePayslipPINHandler())
+
+	employee := testutils.SeedEmployee(t, "pin"+uuid.NewString()[:8])
+	token := testutils.GetTokenFor(t, employee.Username, "password")
+
+	update := func(pin string) int {
+		jsonBody, _ := json.Marshal(map[string]string{"pin": pin})
+		req := httptest.NewRequest(http.MethodPut, "/employee/payslip-pin", bytes.NewReader(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		protected.ServeHTTP(w, req)
+		return w.Code
+	}
+	stored := func() model.User {
+		var user model.User
+		if err := db.Where("id = ?", employee.ID).First(&user).Error; err != nil {
+			t.Fatalf("failed to get employee: %v", err)
+		}
+		return user
+	}
+
+	if code := update("12ab56"); code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", code)
+	}
+	if user := stored(); user.PayslipPIN != "" {
+		t.Errorf("expected an invalid PIN not saved, got %q", user.PayslipPIN)
+	}
+
+	if code := update("246810"); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	user := stored()
+	if user.PayslipPIN == "" || user.PayslipPIN == "246810" {
+		t.Errorf("expected the PIN saved encrypted, got %q", user.PayslipPIN)
+	}
+	if password, err := service.PayslipPassword(model.PayslipPasswordPIN, &user); err != nil || password != "246810" {
+		t.Errorf("expected the PIN as the payslip password, got %q (%v)", password, err)
+	}
+}
+
+func TestGetPayslips_Success(t *testing.T) {
+	db := testutils.DB
+	repo := repository.NewEmployeeRepository(db)
+	employeeHandler := handler.NewEmployeeHandler(repo, service.NewPayslipService(repository.NewPayslipRepository(db)))
+	protected := middleware.AuthMiddleware(employeeHandler.GetPayslipsHandler())
+
+	employee := testutils.SeedEmployee(t, "history"+uuid.NewString()[:8])
+
+	// month end periods of a year before every other period: published and paid payslips are
+	// listed, the voided and the not yet published ones are not
+	var earliest model.AttendancePeriod
+	year := 2000
+	if err := db.Order("start_date").First(&earliest).Error; err == nil {
+		year = earliest.StartDate.Year() - 2
+	}
+	seed := []struct {
+		month  time.Month
+		status string
+		gross  int
+		tax    int
+	}{
+		{time.March, model.PayrollPublished, 1000000, 50000},
+		{time.April, model.PayrollPaid, 2000000, 100000},
+		{time.May, model.PayrollVoided, 4000000, 200000},
+		{time.June, model.PayrollCalculated, 8000000, 400000},
+	}
+	for _, sp := range seed {
+		date := time.Date(year, sp.month+1, 0, 0, 0, 0, 0, time.UTC)
+		period := model.AttendancePeriod{ID: uuid.New(), StartDate: date, EndDate: date, CreatedBy: employee.ID, CreatedAt: time.Now()}
+		payroll := model.Payroll{ID: uuid.New(), PeriodID: period.ID, Status: sp.status, CreatedBy: employee.ID, CreatedAt: time.Now()}
+		payslip := model.Payslip{
+			ID: uuid.New(), PayrollID: payroll.ID, UserID: employee.ID,
+			GrossPay: sp.gross, TaxAmount: sp.tax, TotalDeductions: sp.tax, NetPay: sp.gross - sp.tax,
+		}
+		for _, record := range []interface{}{&period, &payroll, &payslip} {
+			if err := db.Create(record).Error; err != nil {
+				t.Fatalf("failed to seed payslips: %v", err)
+			}
+		}
+		t.Cleanup(func() {
+			db.Delete(&payslip)
+			db.Delete(&payroll)
+			db.Delete(&period)
+		})
+	}
+
+	token := testutils.GetTokenFor(t, employee.Username, "password")
+
+	req := httptest.NewRequest(http.MethodGet, "/employee/payslips?page=1&pageSize=5", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	w := httptest.NewRecorder()
+	protected.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Data handler.PayslipHistoryResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Data.Page != 1 || resp.Data.PageSize != 5 || resp.Data.Total != 2 || len(resp.Data.Payslips) != 2 {
+		t.Fatalf("expected the published and the paid payslip, got %+v", resp.Data)
+	}
+
+	// the latest period first, its year to date adds up both payslips
+	april, march := resp.Data.Payslips[0], resp.Data.Payslips[1]
+	if april.GrossPay != 2000000 || april.YearToDate.GrossPay != 3000000 || april.YearToDate.TaxAmount != 150000 ||
+		april.YearToDate.TotalDeductions != 150000 || april.YearToDate.NetPay != 2850000 {
+		t.Errorf("expected the april year to date to add up march and april, got %+v", april)
+	}
+	if march.GrossPay != 1000000 || march.YearToDate.GrossPay != 1000000 || march.YearToDate.NetPay != 950000 {
+		t.Errorf("expected the march year to date to be march alone, got %+v", march)
+	}
+
+	// the second page of one keeps march's year to date, a page of more than 100 is refused
+	list := func(query string) (int, handler.PayslipHistoryResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/employee/payslips?"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		protected.ServeHTTP(w, req)
+
+		var resp struct {
+			Data handler.PayslipHistoryResponse `json:"data"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+	code, page := list("page=2&pageSize=1")
+	if code != http.StatusOK || page.Total != 2 || len(page.Payslips) != 1 ||
+		page.Payslips[0].GrossPay != 1000000 || page.Payslips[0].YearToDate.GrossPay != 1000000 {
+		t.Errorf("expected march alone on the second page, got %d %+v", code, page)
+	}
+	if code, _ := list("pageSize=1000"); code != http.StatusBadRequest {
+		t.Errorf("expected a page size over 100 status 400, got %d", code)
+	}
+}
+
+func TestSubmitOvertime_ShiftWorkerUnrosteredDay(t *testing.T) {
+	db := testutils.DB
+	repo := repository.NewEmployeeRepository(db)
+	employeeHandler := handler.NewEmployeeHandler(repo, service.NewPayslipService(repository.NewPayslipRepository(db)))
+	h := employeeHandler.SubmitOvertimeHandler()
+	protected := middleware.AuthMiddleware(h)
+
+	employee := testutils.SeedEmployee(t, "shiftworker017")
+	token := testutils.GetTokenFor(t, "shiftworker017", "password")
+
+	// rostered on a wednesday of a past month no period covers, the thursday after is a day off
+	rostered := time.Date(1980, time.February, 6, 0, 0, 0, 0, time.UTC)
+	thursday := rostered.AddDate(0, 0, 1)
+	shift := model.Shift{ID: uuid.New(), Name: "night", StartTime: "22:00:00", EndTime: "06:00:00", CreatedBy: employee.ID, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	roster := model.Roster{ID: uuid.New(), UserID: employee.ID, Date: rostered, ShiftID: shift.ID, CreatedBy: employee.ID, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	for _, record := range []interface{}{&shift, &roster} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("failed to seed roster: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM overtimes WHERE user_id = ?", employee.ID)
+		db.Delete(&roster)
+		db.Delete(&shift)
+	})
+
+	body := map[string]interface{}{
+		"date":      thursday.Format("2006-01-02"),
+		"startTime": "09:00",
+		"endTime":   "11:00",
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/employee/overtime", bytes.NewReader(jsonBody))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	protected.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", w.Code)
+	}
+
+	var response struct {
+		Data handler.OvertimeResponse `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Data.DayType != model.DayTypeRestDay {
+		t.Errorf("expected day type %s, got %s", model.DayTypeRestDay, response.Data.DayType)
+	}
+}
@@ -0,0 +1,59 @@
+package test
+
+import (
+	"bytes"
+	"payslip-generation-system/internal/ics"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestICSParse_MultiDayEvent(t *testing.T) {
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20250331",
+		"DTEND;VALUE=DATE:20250402",
+		"SUMMARY:Hari Raya Idul",
+		"  Fitri",
+		"CATEGORIES:NATIONAL",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	events, err := ics.Parse(strings.NewReader(calendar))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 days, got %d", len(events))
+	}
+	if events[1].Date.Format("2006-01-02") != "2025-04-01" {
+		t.Errorf("expected second day 2025-04-01, got %s", events[1].Date.Format("2006-01-02"))
+	}
+	if events[0].Summary != "Hari Raya Idul Fitri" || events[0].Category != "national" {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+}
+
+func TestICSWrite_RoundTrip(t *testing.T) {
+	events := []ics.Event{{
+		UID:      "1@test",
+		Date:     time.Date(2025, time.December, 26, 0, 0, 0, 0, time.UTC),
+		Summary:  "Cuti bersama Natal, kantor tutup",
+		Category: "cuti_bersama",
+	}}
+
+	var buf bytes.Buffer
+	if err := ics.Write(&buf, "-//test//EN", events); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	parsed, err := ics.Parse(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(parsed) != 1 || parsed[0].Summary != events[0].Summary || parsed[0].Category != "cuti_bersama" {
+		t.Errorf("round trip mismatch: %+v", parsed)
+	}
+}
@@ -0,0 +1,157 @@
+package test
+
+import (
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"payslip-generation-system/internal/service"
+	"payslip-generation-system/test/testutils"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCountLeaveDays(t *testing.T) {
+	// friday to the next tuesday with the monday off
+	start := time.Date(2025, time.June, 6, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, time.June, 10, 0, 0, 0, 0, time.UTC)
+	holidays := []model.Holiday{
+		{Date: time.Date(2025, time.June, 9, 0, 0, 0, 0, time.UTC), Name: "Cuti bersama"},
+	}
+
+	if got := service.CountLeaveDays(start, end, holidays, nil); got != 2 {
+		t.Errorf("expected 2 leave days, got %d", got)
+	}
+}
+
+func TestCountLeaveDays_Rostered(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, time.June, d, 0, 0, 0, 0, time.UTC) }
+	holidays := []model.Holiday{{Date: day(9), Name: "Cuti bersama"}}
+
+	// rostered on the saturday, sunday and the monday off, the weekdays in between are days off
+	rostered := []time.Time{day(7), day(8), day(9)}
+	if got := service.CountLeaveDays(day(6), day(10), holidays, rostered); got != 2 {
+		t.Errorf("expected the 2 rostered working days, got %d", got)
+	}
+}
+
+func TestRequestLeave_OverYearEnd(t *testing.T) {
+	db := testutils.DB
+	var admin model.User
+	if err := db.Where("username = ?", "admin").First(&admin).Error; err != nil {
+		t.Fatalf("failed to find admin: %v", err)
+	}
+	employee := testutils.SeedEmployee(t, "leaveyearend015")
+
+	// monday 30 december to friday 3 january, with balances of both years
+	start := time.Date(1985, time.December, 30, 0, 0, 0, 0, time.UTC)
+	end := time.Date(1986, time.January, 3, 0, 0, 0, 0, time.UTC)
+	for _, year := range []int{1985, 1986} {
+		balance := model.LeaveBalance{ID: uuid.New(), UserID: employee.ID, LeaveType: "ANNUAL", Year: year, Entitled: 3, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		if err := db.Create(&balance).Error; err != nil {
+			t.Fatalf("failed to seed leave balance: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM leave_requests WHERE user_id = ?", employee.ID)
+		db.Exec("DELETE FROM leave_balances WHERE user_id = ?", employee.ID)
+	})
+
+	leaveService := service.NewLeaveService(repository.NewLeaveRepository(db), repository.NewUnitOfWork(db))
+	request, err := leaveService.RequestLeave(employee.ID, "ANNUAL", start, end, "new year", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("failed to request leave: %v", err)
+	}
+	if _, err := leaveService.ReviewLeave(request.ID, admin.ID, "admin", true, ""); err != nil {
+		t.Fatalf("failed to approve leave: %v", err)
+	}
+
+	var balances []model.LeaveBalance
+	if err := db.Where("user_id = ?", employee.ID).Order("year").Find(&balances).Error; err != nil {
+		t.Fatalf("failed to get leave balances: %v", err)
+	}
+	holidays, _ := repository.NewLeaveRepository(db).GetHolidays(start, end)
+	newYear := time.Date(1986, time.January, 1, 0, 0, 0, 0, time.UTC)
+	nextYearDays := service.CountLeaveDays(newYear, end, holidays, nil)
+	if len(balances) != 2 || balances[0].Used != 2 || balances[1].Used != nextYearDays {
+		t.Errorf("expected 2 days taken from 1985 and %d from 1986, got %+v", nextYearDays, balances)
+	}
+}
+
+func TestAccrueLeave_Prorated(t *testing.T) {
+	db := testutils.DB
+	employee := testutils.SeedEmployee(t, "leaveaccrual015")
+
+	// started mid july, entitled to the 6 months from july on
+	salary := model.SalaryHistory{UserID: employee.ID, Salary: 5000000, EffectiveFrom: time.Date(1984, time.July, 15, 0, 0, 0, 0, time.UTC), CreatedAt: time.Now()}
+	if err := db.Create(&salary).Error; err != nil {
+		t.Fatalf("failed to seed salary: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM leave_balances WHERE year = ?", 1984)
+		db.Delete(&salary)
+	})
+
+	if _, err := repository.NewLeaveRepository(db).AccrueLeave(1984); err != nil {
+		t.Fatalf("failed to accrue leave: %v", err)
+	}
+
+	var balance model.LeaveBalance
+	if err := db.Where("user_id = ? AND leave_type = ? AND year = ?", employee.ID, "ANNUAL", 1984).First(&balance).Error; err != nil {
+		t.Fatalf("failed to get leave balance: %v", err)
+	}
+	if balance.Entitled != 6 {
+		t.Errorf("expected 6 days entitled, got %d", balance.Entitled)
+	}
+}
+
+func TestBaseSalary_PaidLeave(t *testing.T) {
+	period := &model.AttendancePeriod{
+		StartDate: time.Date(2025, time.June, 2, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, time.June, 6, 0, 0, 0, 0, time.UTC),
+	}
+	settings := &model.CompanySettings{HoursPerDay: 8, ProrationBasis: model.ProrationWorkingDays}
+	day := func(d int) time.Time { return time.Date(2025, time.June, d, 0, 0, 0, 0, time.UTC) }
+
+	// three days attended, one day of paid leave and one day absent
+	ctx := &service.PayContext{
+		AttendanceDates: []time.Time{day(2), day(3), day(4)},
+		PaidLeaveDates:  []time.Time{day(5)},
+		Calendar:        service.NewWorkCalendar(period, settings, nil),
+		Salaries:        []model.SalaryHistory{{Salary: 5000000, EffectiveFrom: day(1)}},
+	}
+
+	items, err := (&service.BaseSalaryComponent{}).Evaluate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Amount != 4000000 {
+		t.Errorf("expected 4 of 5 days paid, got %+v", items)
+	}
+}
+
+func TestBaseSalary_PaidLeaveRostered(t *testing.T) {
+	period := &model.AttendancePeriod{
+		StartDate: time.Date(2025, time.June, 2, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, time.June, 8, 0, 0, 0, 0, time.UTC),
+	}
+	settings := &model.CompanySettings{HoursPerDay: 8, ProrationBasis: model.ProrationWorkingDays}
+	day := func(d int) time.Time { return time.Date(2025, time.June, d, 0, 0, 0, 0, time.UTC) }
+
+	// rostered monday to wednesday and saturday, on leave the unrostered thursday and the saturday
+	ctx := &service.PayContext{
+		AttendanceDates: []time.Time{day(2), day(3), day(4)},
+		PaidLeaveDates:  []time.Time{day(5), day(7)},
+		Roster:          service.NewRosterDays([]time.Time{day(2), day(3), day(4), day(7)}),
+		Calendar:        service.NewWorkCalendar(period, settings, nil),
+		Salaries:        []model.SalaryHistory{{Salary: 5000000, EffectiveFrom: day(1)}},
+	}
+
+	items, err := (&service.BaseSalaryComponent{}).Evaluate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Quantity != 4 || items[0].Amount != 4000000 {
+		t.Errorf("expected the 3 attended days and the rostered saturday paid, got %+v", items)
+	}
+}
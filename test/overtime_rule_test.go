@@ -0,0 +1,168 @@
+package test
+
+import (
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/service"
+	"testing"
+	"time"
+)
+
+func hour(h int) *int { return &h }
+
+func statutoryRules() []model.OvertimeRule {
+	return []model.OvertimeRule{
+		{DayType: model.DayTypeWorkday, FromHour: 0, ToHour: hour(1), Multiplier: 1.5},
+		{DayType: model.DayTypeWorkday, FromHour: 1, Multiplier: 2},
+		{DayType: model.DayTypeRestDay, FromHour: 0, ToHour: hour(8), Multiplier: 2},
+		{DayType: model.DayTypeRestDay, FromHour: 8, ToHour: hour(9), Multiplier: 3},
+		{DayType: model.DayTypeRestDay, FromHour: 9, Multiplier: 4},
+	}
+}
+
+func TestSplitOvertimeHours(t *testing.T) {
+	restDay := statutoryRules()[2:]
+	got := service.SplitOvertimeHours(restDay, 10)
+	if len(got) != 3 || got[0] != 8 || got[1] != 1 || got[2] != 1 {
+		t.Errorf("expected 8/1/1 hours, got %v", got)
+	}
+
+	got = service.SplitOvertimeHours(restDay, 5)
+	if got[0] != 5 || got[1] != 0 || got[2] != 0 {
+		t.Errorf("expected 5/0/0 hours, got %v", got)
+	}
+}
+
+func TestValidateOvertimeRules(t *testing.T) {
+	if err := service.ValidateOvertimeRules(statutoryRules()[:2]); err != nil {
+		t.Errorf("expected statutory workday rules to be valid, got %v", err)
+	}
+
+	gap := []model.OvertimeRule{
+		{FromHour: 0, ToHour: hour(1), Multiplier: 1.5},
+		{FromHour: 2, Multiplier: 2},
+	}
+	if err := service.ValidateOvertimeRules(gap); err == nil {
+		t.Error("expected a gap between tiers to be invalid")
+	}
+
+	closed := []model.OvertimeRule{{FromHour: 0, ToHour: hour(1), Multiplier: 1.5}}
+	if err := service.ValidateOvertimeRules(closed); err == nil {
+		t.Error("expected a closed last tier to be invalid")
+	}
+}
+
+func TestOvertimeComponent_Tiers(t *testing.T) {
+	date := time.Date(2025, time.June, 3, 0, 0, 0, 0, time.UTC)
+	ctx := &service.PayContext{
+		Salaries:        []model.SalaryHistory{{Salary: 1730000, EffectiveFrom: date.AddDate(0, -1, 0)}},
+		OvertimeDivisor: 173,
+		OvertimeRules:   statutoryRules(),
+		Overtimes:       []model.Overtime{{Date: date, Hours: 3, DayType: model.DayTypeWorkday}},
+	}
+
+	items, err := (&service.OvertimeComponent{}).Evaluate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one hour at 1.5x and two hours at 2x of 10000 an hour
+	if len(items) != 2 || items[0].Amount != 15000 || items[1].Amount != 40000 {
+		t.Errorf("expected 15000 and 40000, got %+v", items)
+	}
+}
+
+func TestOvertimeComponent_SalaryChange(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, time.June, d, 0, 0, 0, 0, time.UTC) }
+	ctx := &service.PayContext{
+		Salaries: []model.SalaryHistory{
+			{Salary: 1730000, EffectiveFrom: day(1)},
+			{Salary: 3460000, EffectiveFrom: day(16)},
+		},
+		BaseSalary:      3460000,
+		OvertimeDivisor: 173,
+		OvertimeRules:   statutoryRules(),
+		Overtimes: []model.Overtime{
+			{Date: day(3), Hours: 1, DayType: model.DayTypeWorkday},
+			{Date: day(17), Hours: 1, DayType: model.DayTypeWorkday},
+		},
+	}
+
+	items, err := (&service.OvertimeComponent{}).Evaluate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first hour at 1.5x of 10000 an hour before the raise and of 20000 after it
+	if len(items) != 1 || items[0].Quantity != 2 || items[0].Amount != 45000 {
+		t.Errorf("expected 2 hours paying 45000, got %+v", items)
+	}
+}
+
+func TestLatenessComponent_HourlyRate(t *testing.T) {
+	date := time.Date(2025, time.June, 3, 0, 0, 0, 0, time.UTC)
+	ctx := &service.PayContext{
+		Salaries:          []model.SalaryHistory{{Salary: 1730000, EffectiveFrom: date.AddDate(0, -1, 0)}},
+		OvertimeDivisor:   173,
+		LatenessDeduction: true,
+		LateDays:          []service.LateDay{{Date: date, Minutes: 60}, {Date: date.AddDate(0, 0, 1), Minutes: 30}},
+		Items:             []model.PayslipItem{{Type: model.PayslipItemEarning, Amount: 1730000, Taxable: true}},
+	}
+
+	items, err := (&service.LatenessComponent{}).Evaluate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an hour and a half at the overtime hourly rate of 10000
+	if len(items) != 1 || items[0].Amount != 15000 {
+		t.Fatalf("expected 15000, got %+v", items)
+	}
+
+	ctx.Items = append(ctx.Items, items...)
+	if got := ctx.TaxableIncome(); got != 1715000 {
+		t.Errorf("expected the lateness deducted from the taxable income, got %d", got)
+	}
+}
+
+func TestOvertimeWindow_CrossingMidnight(t *testing.T) {
+	date := time.Date(2025, time.June, 3, 0, 0, 0, 0, time.UTC)
+	start, end, err := service.OvertimeWindow(date, "22:30", "01:15", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !end.Equal(time.Date(2025, time.June, 4, 1, 15, 0, 0, time.UTC)) {
+		t.Errorf("expected the overtime to end the next day, got %v", end)
+	}
+	if hours := service.OvertimeHours(start, end); hours != 2.75 {
+		t.Errorf("expected 2.75 hours, got %v", hours)
+	}
+}
+
+func TestCheckOvertimeLimits(t *testing.T) {
+	at := func(d, h int) *time.Time {
+		v := time.Date(2025, time.June, d, h, 0, 0, 0, time.UTC)
+		return &v
+	}
+	monday := time.Date(2025, time.June, 2, 0, 0, 0, 0, time.UTC)
+	existing := []model.Overtime{
+		{Date: monday, Hours: 3, StartAt: at(2, 18), EndAt: at(2, 21), Status: model.OvertimeApproved},
+		{Date: monday.AddDate(0, 0, 1), Hours: 4, StartAt: at(3, 18), EndAt: at(3, 22), Status: model.OvertimePending},
+		{Date: monday.AddDate(0, 0, 2), Hours: 4, StartAt: at(4, 18), EndAt: at(4, 22), Status: model.OvertimeRejected},
+	}
+
+	overlap := &model.Overtime{Date: monday, Hours: 1, StartAt: at(2, 20), EndAt: at(2, 21)}
+	if err := service.CheckOvertimeLimits(existing, overlap, 4, 18); err != service.ErrOvertimeOverlap {
+		t.Errorf("expected overlap, got %v", err)
+	}
+
+	overDaily := &model.Overtime{Date: monday, Hours: 2, StartAt: at(2, 21), EndAt: at(2, 23)}
+	if err := service.CheckOvertimeLimits(existing, overDaily, 4, 18); err == nil {
+		t.Error("expected the daily maximum to be exceeded")
+	}
+
+	// the rejected overtime doesn't count towards the week
+	overWeekly := &model.Overtime{Date: monday.AddDate(0, 0, 4), Hours: 4, StartAt: at(6, 18), EndAt: at(6, 22)}
+	if err := service.CheckOvertimeLimits(existing, overWeekly, 4, 10); err == nil {
+		t.Error("expected the weekly maximum to be exceeded")
+	}
+	if err := service.CheckOvertimeLimits(existing, overWeekly, 4, 11); err != nil {
+		t.Errorf("expected the overtime within the limits, got %v", err)
+	}
+}
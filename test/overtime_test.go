@@ -0,0 +1,194 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"payslip-generation-system/internal/handler"
+	"payslip-generation-system/internal/middleware"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"payslip-generation-system/internal/service"
+	"payslip-generation-system/test/testutils"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// seedPendingOvertime creates a pending overtime of a new employee on a date before every period
+func seedPendingOvertime(t *testing.T) (model.User, model.Overtime) {
+	db := testutils.DB
+	employee := testutils.SeedEmployee(t, "overtime"+uuid.NewString()[:8])
+	t.Cleanup(func() { db.Exec("DELETE FROM overtimes WHERE user_id = ?", employee.ID) })
+
+	overtime := model.Overtime{
+		ID: uuid.New(), UserID: employee.ID, Date: time.Date(1970, time.January, 3, 0, 0, 0, 0, time.UTC),
+		Hours: 2, DayType: model.DayTypeRestDay, Status: model.OvertimePending,
+		CreatedBy: employee.ID, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}
+	if err := db.Create(&overtime).Error; err != nil {
+		t.Fatalf("failed to seed overtime: %v", err)
+	}
+	return employee, overtime
+}
+
+func getOvertime(t *testing.T, id uuid.UUID) model.Overtime {
+	var overtime model.Overtime
+	if err := testutils.DB.Where("id = ?", id).First(&overtime).Error; err != nil {
+		t.Fatalf("failed to get overtime: %v", err)
+	}
+	return overtime
+}
+
+func TestWithdrawOvertime_Pending(t *testing.T) {
+	db := testutils.DB
+	overtimeHandler := handler.NewOvertimeHandler(repository.NewOvertimeRepository(db), service.NewOvertimeService(repository.NewUnitOfWork(db)))
+	h := middleware.AuthMiddleware(overtimeHandler.WithdrawOvertimeHandler())
+
+	employee, overtime := seedPendingOvertime(t)
+	other := testutils.SeedEmployee(t, "overtime"+uuid.NewString()[:8])
+
+	withdraw := func(username string) int {
+		token := testutils.GetTokenFor(t, username, "password")
+		body := map[string]interface{}{
+			"overtimeID": overtime.ID.String(),
+		}
+		jsonBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(http.MethodPost, "/employee/overtime-withdraw", bytes.NewReader(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// another employee's overtime is not theirs to withdraw
+	if code := withdraw(other.Username); code != http.StatusNotFound {
+		t.Errorf("expected status 404 for another employee, got %d", code)
+	}
+	if got := getOvertime(t, overtime.ID).Status; got != model.OvertimePending {
+		t.Errorf("expected the overtime to stay pending, got %s", got)
+	}
+
+	if code := withdraw(employee.Username); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if got := getOvertime(t, overtime.ID).Status; got != model.OvertimeWithdrawn {
+		t.Errorf("expected the overtime withdrawn, got %s", got)
+	}
+	if code := withdraw(employee.Username); code != http.StatusConflict {
+		t.Errorf("expected withdrawing again status 409, got %d", code)
+	}
+}
+
+func TestReviewOvertime_RejectWithNote(t *testing.T) {
+	db := testutils.DB
+	overtimeHandler := handler.NewOvertimeHandler(repository.NewOvertimeRepository(db), service.NewOvertimeService(repository.NewUnitOfWork(db)))
+	h := middleware.AuthMiddleware(overtimeHandler.ReviewOvertimeHandler())
+
+	_, overtime := seedPendingOvertime(t)
+	token := testutils.GetTokenFor(t, "admin", "password")
+
+	review := func(approve bool, note string) int {
+		body := map[string]interface{}{
+			"overtimeID": overtime.ID.String(),
+			"approve":    approve,
+			"note":       note,
+		}
+		jsonBody, _ := json.Marshal(body)
+
+		req := httptest.NewRequest(http.MethodPost, "/admin/overtime-review", bytes.NewReader(jsonBody))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := review(false, " "); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without a note, got %d", code)
+	}
+	if got := getOvertime(t, overtime.ID).Status; got != model.OvertimePending {
+		t.Errorf("expected the overtime to stay pending, got %s", got)
+	}
+
+	if code := review(false, "not agreed with the manager"); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	rejected := getOvertime(t, overtime.ID)
+	if rejected.Status != model.OvertimeRejected || rejected.ReviewNote != "not agreed with the manager" || rejected.ReviewedBy == nil {
+		t.Errorf("expected the overtime rejected with the note, got %s %q", rejected.Status, rejected.ReviewNote)
+	}
+	if code := review(true, ""); code != http.StatusConflict {
+		t.Errorf("expected approving a rejected overtime status 409, got %d", code)
+	}
+}
+
+func TestReviewOvertime_ApprovedIsPaid(t *testing.T) {
+	db := testutils.DB
+	var employee, admin model.User
+	if err := db.Where("username = ?", "employee999").First(&employee).Error; err != nil {
+		t.Fatalf("failed to find employee999: %v", err)
+	}
+	if err := db.Where("username = ?", "admin").First(&admin).Error; err != nil {
+		t.Fatalf("failed to find admin: %v", err)
+	}
+
+	period := testutils.SeedPeriod(t)
+	checkIn := period.StartDate.Add(9 * time.Hour)
+	checkOut := period.StartDate.Add(20 * time.Hour)
+	salary := model.SalaryHistory{UserID: employee.ID, Salary: 7000000, EffectiveFrom: period.StartDate, CreatedAt: time.Now()}
+	attendance := model.Attendance{ID: uuid.New(), UserID: employee.ID, Date: period.StartDate, CheckInAt: &checkIn, CheckOutAt: &checkOut, CreatedBy: employee.ID, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	overtime := model.Overtime{ID: uuid.New(), UserID: employee.ID, Date: period.StartDate, Hours: 2, DayType: model.DayTypeWorkday, Status: model.OvertimePending, CreatedBy: employee.ID, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	for _, record := range []interface{}{&salary, &attendance, &overtime} {
+		if err := db.Create(record).Error; err != nil {
+			t.Fatalf("failed to seed overtime: %v", err)
+		}
+	}
+	t.Cleanup(func() { db.Delete(&salary) })
+
+	overtimeHandler := handler.NewOvertimeHandler(repository.NewOvertimeRepository(db), service.NewOvertimeService(repository.NewUnitOfWork(db)))
+	h := overtimeHandler.ReviewOvertimeHandler()
+
+	token := testutils.GetTokenFor(t, "admin", "password")
+
+	body := map[string]interface{}{
+		"overtimeID": overtime.ID.String(),
+		"approve":    true,
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/overtime-review", bytes.NewReader(jsonBody))
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	middleware.AuthMiddleware(h).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	payrollService := service.NewPayrollService(repository.NewPayrollRepository(db), repository.NewUnitOfWork(db))
+	if err := payrollService.ProcessPayroll(period.ID, admin.ID, "127.0.0.1", uuid.NewString()); err != nil {
+		t.Fatalf("failed to run payroll: %v", err)
+	}
+
+	var items []model.PayslipItem
+	err := db.Raw(`
+		SELECT i.* FROM payslip_items i
+		JOIN payslips s ON s.id = i.payslip_id
+		JOIN payrolls r ON r.id = s.payroll_id
+		WHERE r.period_id = ? AND s.user_id = ? AND i.code LIKE 'OVERTIME%'
+	`, period.ID, employee.ID).Scan(&items).Error
+	if err != nil {
+		t.Fatalf("failed to get payslip items: %v", err)
+	}
+	hours, amount := 0.0, 0
+	for _, item := range items {
+		hours += item.Quantity
+		amount += item.Amount
+	}
+	if hours != 2 || amount <= 0 {
+		t.Errorf("expected the 2 approved overtime hours on the payslip, got %+v", items)
+	}
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"errors"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/repository"
+	"payslip-generation-system/internal/service"
+	"payslip-generation-system/test/testutils"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCanTransitionPayroll(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{model.PayrollDraft, model.PayrollCalculated, true},
+		{model.PayrollCalculated, model.PayrollApproved, true},
+		{model.PayrollApproved, model.PayrollPublished, true},
+		{model.PayrollApproved, model.PayrollCalculated, false},
+		{model.PayrollPublished, model.PayrollPaid, true},
+		{model.PayrollPaid, model.PayrollVoided, true},
+		{model.PayrollCalculated, model.PayrollPublished, false},
+		{model.PayrollPaid, model.PayrollPublished, false},
+		{model.PayrollVoided, model.PayrollCalculated, false},
+	}
+
+	for _, c := range cases {
+		if got := service.CanTransitionPayroll(c.from, c.to); got != c.want {
+			t.Errorf("%s to %s: expected %v, got %v", c.from, c.to, c.want, got)
+		}
+	}
+}
+
+func TestProcessPayroll_Lifecycle(t *testing.T) {
+	db := testutils.DB
+	var admin model.User
+	if err := db.Where("username = ?", "admin").First(&admin).Error; err != nil {
+		t.Fatalf("failed to find admin: %v", err)
+	}
+	approver := testutils.SeedEmployee(t, "approver"+uuid.NewString()[:8])
+	db.Model(&approver).Update("role", "admin")
+
+	period := testutils.SeedPeriod(t)
+	payrollService := service.NewPayrollService(repository.NewPayrollRepository(db), repository.NewUnitOfWork(db))
+	if err := payrollService.ProcessPayroll(period.ID, admin.ID, "127.0.0.1", uuid.NewString()); err != nil {
+		t.Fatalf("failed to run payroll: %v", err)
+	}
+
+	var payroll model.Payroll
+	status := func() string {
+		if err := db.Where("period_id = ?", period.ID).First(&payroll).Error; err != nil {
+			t.Fatalf("failed to get payroll: %v", err)
+		}
+		return payroll.Status
+	}
+	if got := status(); got != model.PayrollCalculated {
+		t.Fatalf("expected the run to end calculated, got %s", got)
+	}
+
+	err := payrollService.TransitionPayroll(payroll.ID, admin.ID, model.PayrollApproved, "127.0.0.1", uuid.NewString())
+	if !errors.Is(err, service.ErrPayrollSelfApproval) {
+		t.Errorf("expected the runner's approval to be refused, got %v", err)
+	}
+	if err := payrollService.TransitionPayroll(payroll.ID, approver.ID, model.PayrollApproved, "127.0.0.1", uuid.NewString()); err != nil {
+		t.Fatalf("failed to approve payroll: %v", err)
+	}
+	if got := status(); got != model.PayrollApproved || payroll.ApprovedBy == nil || *payroll.ApprovedBy != approver.ID {
+		t.Errorf("expected the payroll approved by the other admin, got %s by %v", got, payroll.ApprovedBy)
+	}
+
+	err = payrollService.TransitionPayroll(payroll.ID, approver.ID, model.PayrollCalculated, "127.0.0.1", uuid.NewString())
+	if !errors.Is(err, service.ErrInvalidPayrollStatus) {
+		t.Errorf("expected an approved payroll not to go back to calculated, got %v", err)
+	}
+	if got := status(); got != model.PayrollApproved {
+		t.Errorf("expected the payroll to stay approved, got %s", got)
+	}
+}
@@ -0,0 +1,197 @@
+package test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"payslip-generation-system/internal/mailer"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/service"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// smtpSink accepts one message on a local port like MailHog does and hands it over
+func smtpSink(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		io.WriteString(conn, "220 sink\r\n")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				io.WriteString(conn, "250 sink\r\n")
+			case cmd == "DATA":
+				io.WriteString(conn, "354 go ahead\r\n")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				received <- data.String()
+				io.WriteString(conn, "250 queued\r\n")
+			case cmd == "QUIT":
+				io.WriteString(conn, "221 bye\r\n")
+				return
+			default:
+				io.WriteString(conn, "250 ok\r\n")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	addr, received := smtpSink(t)
+	host, port, _ := net.SplitHostPort(addr)
+	m := mailer.NewSMTPMailer(host, port, "", "", "payroll@example.com")
+
+	pdf := []byte("%PDF-1.4 payslip")
+	err := m.Send(&mailer.Message{
+		To:          "employee001@example.com",
+		Subject:     "Your payslip",
+		Body:        "Your payslip is attached.",
+		Attachments: []mailer.Attachment{{Name: "payslip-employee001-2025-03.pdf", ContentType: "application/pdf", Content: pdf}},
+	})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(<-received))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+	if msg.Header.Get("Subject") != "Your payslip" || msg.Header.Get("To") != "<employee001@example.com>" {
+		t.Errorf("unexpected headers %v", msg.Header)
+	}
+
+	_, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	if _, err := mr.NextPart(); err != nil {
+		t.Fatalf("body part: %v", err)
+	}
+	part, err := mr.NextPart()
+	if err != nil {
+		t.Fatalf("attachment part: %v", err)
+	}
+	if part.FileName() != "payslip-employee001-2025-03.pdf" {
+		t.Errorf("expected the payslip file name, got %q", part.FileName())
+	}
+}
+
+func TestSMTPMailer_InvalidAddress(t *testing.T) {
+	m := mailer.NewSMTPMailer("127.0.0.1", "1", "", "", "payroll@example.com")
+	if err := m.Send(&mailer.Message{To: "not an address"}); !errors.Is(err, mailer.ErrInvalidAddress) {
+		t.Errorf("expected an invalid address, got %v", err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	for attempts, expected := range map[int]time.Duration{1: time.Minute, 2: 2 * time.Minute, 4: 8 * time.Minute, 20: time.Hour} {
+		if delay := service.RetryDelay(attempts); delay != expected {
+			t.Errorf("%d attempts: expected %v, got %v", attempts, expected, delay)
+		}
+	}
+}
+
+type deliveryQueue struct {
+	due     []model.PayslipDelivery
+	updated []model.PayslipDelivery
+}
+
+func (q *deliveryQueue) EnqueuePayroll(uuid.UUID) error { return nil }
+func (q *deliveryQueue) CancelPayroll(uuid.UUID) error  { return nil }
+func (q *deliveryQueue) ClaimDue(now time.Time, limit int, lease time.Duration) ([]model.PayslipDelivery, error) {
+	due := q.due
+	q.due = nil
+	return due, nil
+}
+func (q *deliveryQueue) UpdateDelivery(delivery *model.PayslipDelivery) error {
+	q.updated = append(q.updated, *delivery)
+	return nil
+}
+func (q *deliveryQueue) GetDeliveries(uuid.UUID, string) ([]model.PayslipDeliveryStatus, error) {
+	return nil, nil
+}
+
+type payslipFiles struct {
+	service.PayslipService
+	noPassword uuid.UUID
+}
+
+func (f *payslipFiles) EmployeePayslip(userID, payrollID uuid.UUID) (*service.PayslipFile, error) {
+	if userID == f.noPassword {
+		return nil, service.ErrNoPayslipPassword
+	}
+	return &service.PayslipFile{Name: "payslip.pdf", ContentType: "application/pdf", Content: []byte("%PDF")}, nil
+}
+
+type outbox struct{ failTo string }
+
+func (o *outbox) Send(msg *mailer.Message) error {
+	if msg.To == o.failTo {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestPayslipDispatcher_DispatchDue(t *testing.T) {
+	noPassword := uuid.New()
+	queue := &deliveryQueue{due: []model.PayslipDelivery{
+		{ID: uuid.New(), UserID: uuid.New(), Email: "sent@example.com", Status: model.DeliveryPending},
+		{ID: uuid.New(), UserID: uuid.New(), Email: "down@example.com", Status: model.DeliveryPending},
+		{ID: uuid.New(), UserID: uuid.New(), Email: "down@example.com", Status: model.DeliveryPending, Attempts: 4},
+		{ID: uuid.New(), UserID: noPassword, Email: "locked@example.com", Status: model.DeliveryPending},
+	}}
+	dispatcher := service.NewPayslipDispatcher(queue, &payslipFiles{noPassword: noPassword}, &outbox{failTo: "down@example.com"})
+
+	now := time.Now()
+	sent, err := dispatcher.DispatchDue(now)
+	if err != nil || sent != 1 {
+		t.Fatalf("expected 1 sent, got %d (%v)", sent, err)
+	}
+
+	expected := []struct {
+		status      string
+		attempts    int
+		nextAttempt time.Time
+	}{
+		{model.DeliverySent, 1, time.Time{}},
+		{model.DeliveryPending, 1, now.Add(time.Minute)},
+		{model.DeliveryFailed, 5, time.Time{}},
+		{model.DeliveryFailed, 1, time.Time{}},
+	}
+	for i, e := range expected {
+		d := queue.updated[i]
+		if d.Status != e.status || d.Attempts != e.attempts || d.NextAttemptAt != e.nextAttempt {
+			t.Errorf("delivery %d: expected %s after %d attempts next at %v, got %s after %d next at %v (%s)",
+				i, e.status, e.attempts, e.nextAttempt, d.Status, d.Attempts, d.NextAttemptAt, d.LastError)
+		}
+	}
+}
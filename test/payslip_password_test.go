@@ -0,0 +1,186 @@
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/pdf"
+	"payslip-generation-system/internal/service"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	pdfmodel "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"gorm.io/gorm"
+)
+
+func TestPayslipPassword(t *testing.T) {
+	t.Setenv("PAYSLIP_PIN_KEY", "test-payslip-pin-key")
+	pin, err := service.EncryptPayslipPIN("246810")
+	if err != nil {
+		t.Fatalf("encrypt PIN: %v", err)
+	}
+	birthDate := time.Date(1990, time.August, 15, 0, 0, 0, 0, time.UTC)
+	user := &model.User{Username: "employee001", BirthDate: &birthDate, EmployeeNumber: "EMP001", PayslipPIN: pin}
+
+	password, err := service.PayslipPassword(model.PayslipPasswordBirthdate, user)
+	if err != nil || password != "15081990EMP001" {
+		t.Errorf("expected 15081990EMP001, got %q (%v)", password, err)
+	}
+	password, err = service.PayslipPassword(model.PayslipPasswordPIN, user)
+	if err != nil || password != "246810" {
+		t.Errorf("expected the PIN, got %q (%v)", password, err)
+	}
+
+	// the column holds the PIN only encrypted, another key cannot read it back
+	if strings.Contains(pin, "246810") {
+		t.Errorf("expected the stored PIN encrypted, got %q", pin)
+	}
+	t.Setenv("PAYSLIP_PIN_KEY", "another-payslip-pin-key")
+	if password, err := service.PayslipPassword(model.PayslipPasswordPIN, user); err == nil {
+		t.Errorf("expected the PIN unreadable with another key, got %q", password)
+	}
+
+	// an employee missing what the scheme needs gets no payslip rather than an unprotected one
+	missing := &model.User{Username: "employee002", BirthDate: &birthDate}
+	for _, scheme := range []string{model.PayslipPasswordBirthdate, model.PayslipPasswordPIN} {
+		if _, err := service.PayslipPassword(scheme, missing); !errors.Is(err, service.ErrNoPayslipPassword) {
+			t.Errorf("%s: expected no payslip password, got %v", scheme, err)
+		}
+	}
+}
+
+func TestValidatePayslipPIN(t *testing.T) {
+	for pin, valid := range map[string]bool{"123456": true, "123456789012": true, "12345": false, "1234567890123": false, "12ab56": false, "": false} {
+		if err := service.ValidatePayslipPIN(pin); (err == nil) != valid {
+			t.Errorf("%q: expected valid %v, got %v", pin, valid, err)
+		}
+	}
+}
+
+func TestEncryptedDocument(t *testing.T) {
+	doc := pdf.Layout("# Payslip\n| Net pay | Rp 6.900.000")
+	doc.Title = "Payslip employee001"
+
+	doc.Encrypt("15081990EMP001", "")
+	content, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	if !bytes.Contains(content, []byte("/Encrypt ")) || !bytes.Contains(content, []byte("/V 5")) || !bytes.Contains(content, []byte("/R 6")) {
+		t.Error("expected the AES-256 standard security handler in the trailer")
+	}
+	if bytes.Contains(content, []byte("employee001")) {
+		t.Error("expected the document info encrypted")
+	}
+
+	// the user password opens the document and shows its text, any other password does not
+	conf := pdfmodel.NewDefaultConfiguration()
+	conf.UserPW = "15081990EMP001"
+	ctx, err := api.ReadValidateAndOptimize(bytes.NewReader(content), conf)
+	if err != nil {
+		t.Fatalf("decrypt with the user password: %v", err)
+	}
+	page, err := pdfcpu.ExtractPageContent(ctx, 1)
+	if err != nil {
+		t.Fatalf("extract page: %v", err)
+	}
+	text, _ := io.ReadAll(page)
+	if !bytes.Contains(text, []byte("(Net pay)")) {
+		t.Errorf("expected the payslip text once decrypted, got %q", text)
+	}
+
+	conf = pdfmodel.NewDefaultConfiguration()
+	conf.UserPW = "15081990EMP002"
+	if _, err := api.ReadValidateAndOptimize(bytes.NewReader(content), conf); err == nil {
+		t.Error("expected a wrong password to be refused")
+	}
+
+	again, _ := doc.Bytes()
+	if bytes.Equal(content, again) {
+		t.Error("expected a random owner password for each rendering")
+	}
+}
+
+// payslipStore serves a payroll with its payslips and users from memory
+type payslipStore struct {
+	payroll  model.Payroll
+	period   model.AttendancePeriod
+	payslips []model.Payslip
+	users    []model.User
+}
+
+func (s *payslipStore) GetPayroll(uuid.UUID) (*model.Payroll, error) { return &s.payroll, nil }
+func (s *payslipStore) GetAttendancePeriod(uuid.UUID) (*model.AttendancePeriod, error) {
+	return &s.period, nil
+}
+func (s *payslipStore) GetReleasedPayslip(userID, _ uuid.UUID) (*model.Payslip, error) {
+	for _, p := range s.payslips {
+		if p.UserID == userID {
+			return &p, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+func (s *payslipStore) GetPayslips(uuid.UUID) ([]model.Payslip, error) { return s.payslips, nil }
+func (s *payslipStore) GetUsers([]uuid.UUID) ([]model.User, error)     { return s.users, nil }
+func (s *payslipStore) GetYearToDate([]uuid.UUID, uuid.UUID) ([]model.PayslipYearToDate, error) {
+	return nil, nil
+}
+func (s *payslipStore) GetCompanySettings() (*model.CompanySettings, error) {
+	return &model.CompanySettings{CompanyName: "PT Contoh", PayslipPasswordScheme: model.PayslipPasswordBirthdate}, nil
+}
+func (s *payslipStore) GetTemplate() (*model.PayslipTemplate, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+func (s *payslipStore) SaveTemplate(*model.PayslipTemplate) error { return nil }
+func (s *payslipStore) DeleteTemplate() error                     { return nil }
+
+func TestPayrollPayslips_SkipsWithoutPassword(t *testing.T) {
+	birthDate := time.Date(1990, time.August, 15, 0, 0, 0, 0, time.UTC)
+	ready := model.User{ID: uuid.New(), Username: "employee001", BirthDate: &birthDate, EmployeeNumber: "EMP001"}
+	missing := model.User{ID: uuid.New(), Username: "employee002"}
+	store := &payslipStore{
+		payroll: model.Payroll{ID: uuid.New(), Status: model.PayrollPublished},
+		period: model.AttendancePeriod{
+			StartDate: time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC),
+		},
+		payslips: []model.Payslip{{UserID: ready.ID, NetPay: 6900000}, {UserID: missing.ID, NetPay: 5000000}},
+		users:    []model.User{ready, missing},
+	}
+	payslipService := service.NewPayslipService(store)
+
+	file, err := payslipService.PayrollPayslips(store.payroll.ID)
+	if err != nil {
+		t.Fatalf("expected the zip despite the missing password, got %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(file.Content), int64(len(file.Content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		names[f.Name] = string(content)
+	}
+	if _, ok := names["payslip-employee001-2025-06.pdf"]; !ok || len(names) != 2 {
+		t.Errorf("expected the payslip of employee001 and the report, got %v", len(names))
+	}
+	if report := names["skipped-payslips.txt"]; !bytes.Contains([]byte(report), []byte("employee002")) {
+		t.Errorf("expected employee002 reported as skipped, got %q", report)
+	}
+
+	// the employee's own download has nothing to fall back on
+	if _, err := payslipService.EmployeePayslip(missing.ID, store.payroll.ID); !errors.Is(err, service.ErrNoPayslipPassword) {
+		t.Errorf("expected ErrNoPayslipPassword, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/service"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTerbilang(t *testing.T) {
+	cases := map[int]string{
+		0:             "nol",
+		11:            "sebelas",
+		15:            "lima belas",
+		100:           "seratus",
+		1001:          "seribu satu",
+		1500000:       "satu juta lima ratus ribu",
+		7250000:       "tujuh juta dua ratus lima puluh ribu",
+		2000000000:    "dua miliar",
+		-250:          "minus dua ratus lima puluh",
+		9636705:       "sembilan juta enam ratus tiga puluh enam ribu tujuh ratus lima",
+		1000000000000: "satu triliun",
+	}
+	for n, want := range cases {
+		if got := service.Terbilang(n); got != want {
+			t.Errorf("%d: expected %q, got %q", n, want, got)
+		}
+	}
+}
+
+func TestFormatRupiah(t *testing.T) {
+	cases := map[int]string{0: "Rp 0", 999: "Rp 999", 1000: "Rp 1.000", 7250000: "Rp 7.250.000", -15000: "-Rp 15.000"}
+	for n, want := range cases {
+		if got := service.FormatRupiah(n); got != want {
+			t.Errorf("%d: expected %q, got %q", n, want, got)
+		}
+	}
+}
+
+func TestRenderPayslip_DefaultTemplate(t *testing.T) {
+	tmpl, err := service.ParsePayslipTemplate(service.DefaultPayslipTemplate)
+	if err != nil {
+		t.Fatalf("default template: %v", err)
+	}
+
+	start := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	payslip := &model.Payslip{
+		BaseSalary: 7000000,
+		GrossPay:   7000000,
+		TaxAmount:  100000,
+		NetPay:     6900000,
+		Items: []model.PayslipItem{
+			{Code: "BASIC_SALARY", Name: "Basic salary", Type: model.PayslipItemEarning, Amount: 7000000},
+			{Code: "PPH21", Name: "Income tax", Type: model.PayslipItemDeduction, Amount: 100000},
+		},
+		TotalDeductions: 100000,
+	}
+	data := service.NewPayslipData(payslip,
+		&model.User{ID: uuid.New(), Username: "employee001", Grade: "staff", PTKPStatus: "TK/0"},
+		&model.Payroll{ID: uuid.New(), WorkingDays: 21},
+		&model.AttendancePeriod{StartDate: start, EndDate: start.AddDate(0, 1, -1)},
+		&model.CompanySettings{CompanyName: "PT Contoh (Indonesia)"},
+		model.PayslipYearToDate{GrossPay: 21000000, TaxAmount: 300000, NetPay: 20700000},
+	)
+	if data.YearToDate.GrossPay != 21000000 || data.YearToDate.TaxAmount != 300000 || data.YearToDate.NetPay != 20700000 {
+		t.Errorf("expected the year to date totals of the payslip, got %+v", data.YearToDate)
+	}
+	if len(data.Earnings) != 1 || len(data.Deductions) != 1 {
+		t.Errorf("expected the items split into earnings and deductions, got %+v", data)
+	}
+
+	content, err := service.RenderPayslip(tmpl, data, "")
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !bytes.HasPrefix(content, []byte("%PDF-1.4")) || !bytes.HasSuffix(content, []byte("%%EOF\n")) {
+		t.Error("expected a complete PDF document")
+	}
+	if !bytes.Contains(content, []byte(`/Author (PT Contoh \(Indonesia\))`)) {
+		t.Error("expected the parentheses of the company name escaped in the document info")
+	}
+}
+
+func TestValidatePayslipTemplate(t *testing.T) {
+	payslipService := service.NewPayslipService(nil)
+
+	if err := payslipService.ValidateTemplate("# {{.Company.Name}}\n| Net pay | {{rupiah .NetPay}}"); err != nil {
+		t.Errorf("expected a valid template, got %v", err)
+	}
+	for _, body := range []string{"# {{.Company.Name", "| {{.Unknown}}", "{{rupiah .Employee.Username}}"} {
+		if err := payslipService.ValidateTemplate(body); !errors.Is(err, service.ErrInvalidPayslipTemplate) {
+			t.Errorf("%q: expected an invalid template, got %v", body, err)
+		}
+	}
+}
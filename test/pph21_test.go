@@ -0,0 +1,43 @@
+package test
+
+import (
+	"payslip-generation-system/internal/service"
+	"testing"
+)
+
+func TestCalculateMonthlyPPh21(t *testing.T) {
+	cases := []struct {
+		status  string
+		hasNPWP bool
+		gross   int
+		want    int
+	}{
+		{"TK/0", true, 10_000_000, 200_000},
+		{"TK/0", false, 10_000_000, 240_000},
+		{"K/3", true, 6_600_000, 0},
+	}
+
+	for _, c := range cases {
+		got, err := service.CalculateMonthlyPPh21(c.status, c.hasNPWP, c.gross)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != c.want {
+			t.Errorf("%s gross %d: expected %d, got %d", c.status, c.gross, c.want, got)
+		}
+	}
+}
+
+func TestCalculateAnnualPPh21(t *testing.T) {
+	got, err := service.CalculateAnnualPPh21("K/1", true, 200_000_000, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 13_650_000 {
+		t.Errorf("expected 13650000, got %d", got)
+	}
+
+	if _, err := service.CalculateAnnualPPh21("X/9", true, 200_000_000, 0); err == nil {
+		t.Error("expected error for unknown PTKP status")
+	}
+}
@@ -0,0 +1,71 @@
+package test
+
+import (
+	"errors"
+	"payslip-generation-system/internal/model"
+	"payslip-generation-system/internal/service"
+	"testing"
+	"time"
+)
+
+func TestCheckReimbursementLimit(t *testing.T) {
+	perClaim, perPeriod := 500000, 1500000
+	limit := &model.ReimbursementLimit{Category: "TRANSPORT", Grade: "staff", PerClaimLimit: &perClaim, PerPeriodLimit: &perPeriod}
+
+	tests := []struct {
+		name    string
+		claimed int
+		amount  int
+		limit   string
+	}{
+		{"within both limits", 1000000, 500000, ""},
+		{"over the claim limit", 0, 500001, "claim"},
+		{"over the period limit", 1200000, 400000, "period"},
+	}
+
+	for _, tt := range tests {
+		err := service.CheckReimbursementLimit(limit, tt.claimed, tt.amount)
+		var limitErr *service.ReimbursementLimitError
+		switch {
+		case tt.limit == "" && err != nil:
+			t.Errorf("%s: expected no error, got %v", tt.name, err)
+		case tt.limit != "" && !errors.As(err, &limitErr):
+			t.Errorf("%s: expected a limit error, got %v", tt.name, err)
+		case tt.limit != "" && limitErr.Limit != tt.limit:
+			t.Errorf("%s: expected the %s limit, got %s", tt.name, tt.limit, limitErr.Limit)
+		}
+	}
+
+	// a category without limits takes any amount
+	if err := service.CheckReimbursementLimit(&model.ReimbursementLimit{}, 100000000, 100000000); err != nil {
+		t.Errorf("expected no error without limits, got %v", err)
+	}
+}
+
+func TestReimbursementPayEngine(t *testing.T) {
+	period := &model.AttendancePeriod{
+		StartDate: time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, time.June, 30, 0, 0, 0, 0, time.UTC),
+	}
+	settings := &model.CompanySettings{HoursPerDay: 8, ProrationBasis: model.ProrationCalendarDays}
+
+	// on the calendar days basis the weekends would be paid salary, taxed and charged BPJS
+	ctx := &service.PayContext{
+		Calendar:           service.NewWorkCalendar(period, settings, nil),
+		Salaries:           []model.SalaryHistory{{Salary: 7000000, EffectiveFrom: period.StartDate}},
+		BaseSalary:         7000000,
+		ReimbursementTotal: 250000,
+		PeriodEnd:          period.EndDate,
+		PTKPStatus:         "TK/0",
+	}
+	if err := service.NewReimbursementPayEngine().Evaluate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ctx.Items) != 1 || ctx.Items[0].Code != "REIMBURSEMENT" {
+		t.Errorf("expected only the reimbursement, got %+v", ctx.Items)
+	}
+	if gross, deductions := ctx.Total(model.PayslipItemEarning), ctx.Total(model.PayslipItemDeduction); gross != 250000 || deductions != 0 {
+		t.Errorf("expected 250000 gross without deductions, got %d and %d", gross, deductions)
+	}
+}
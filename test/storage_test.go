@@ -0,0 +1,47 @@
+package test
+
+import (
+	"errors"
+	"io"
+	"payslip-generation-system/internal/storage"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorage_PutGetDelete(t *testing.T) {
+	store := storage.NewLocalStorage(t.TempDir())
+
+	if err := store.Put("receipts/claim/receipt.pdf", strings.NewReader("%PDF-1.4 receipt")); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+
+	file, err := store.Get("receipts/claim/receipt.pdf")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	content, _ := io.ReadAll(file)
+	file.Close()
+	if string(content) != "%PDF-1.4 receipt" {
+		t.Errorf("expected the stored content, got %q", content)
+	}
+
+	if err := store.Delete("receipts/claim/receipt.pdf"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := store.Get("receipts/claim/receipt.pdf"); !errors.Is(err, storage.ErrFileNotFound) {
+		t.Errorf("expected file not found after delete, got %v", err)
+	}
+	if err := store.Delete("receipts/claim/receipt.pdf"); err != nil {
+		t.Errorf("expected deleting a missing file to succeed, got %v", err)
+	}
+}
+
+func TestLocalStorage_InvalidKey(t *testing.T) {
+	store := storage.NewLocalStorage(t.TempDir())
+
+	for _, key := range []string{"", "..", "../outside.pdf", "receipts/../../outside.pdf", "/etc/passwd"} {
+		if err := store.Put(key, strings.NewReader("x")); !errors.Is(err, storage.ErrInvalidKey) {
+			t.Errorf("key %q: expected invalid key, got %v", key, err)
+		}
+	}
+}
@@ -34,14 +34,20 @@ func SetupTestDB(t *testing.T) {
 
 func seedTestUser() {
 	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
-	DB.Create(&model.User{
+	user := model.User{
 		ID:           uuid.New(),
 		Username:     "employee999",
 		PasswordHash: string(hash),
 		Role:         "employee",
-		Salary:       7000000,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
+	}
+	DB.Create(&user)
+	DB.Create(&model.SalaryHistory{
+		UserID:        user.ID,
+		Salary:        7000000,
+		EffectiveFrom: time.Date(time.Now().Year(), time.January, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:     time.Now(),
 	})
 }
 
@@ -52,8 +58,84 @@ func seedTestAdmin() {
 		Username:     "admin",
 		PasswordHash: string(hash),
 		Role:         "admin",
-		Salary:       7000000,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	})
 }
+
+// SeedEmployee creates an employee with the password "password", removed again when the test ends
+func SeedEmployee(t *testing.T, username string) model.User {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+	user := model.User{
+		ID:           uuid.New(),
+		Username:     username,
+		PasswordHash: string(hash),
+		Role:         "employee",
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := DB.Create(&user).Error; err != nil {
+		t.Fatalf("failed to seed %s: %v", username, err)
+	}
+	t.Cleanup(func() { DB.Delete(&user) })
+	return user
+}
+
+// SeedPeriod creates a one day attendance period on the last weekday before every other
+// period, so it doesn't overlap the periods of other tests and has a working day
+func SeedPeriod(t *testing.T) model.AttendancePeriod {
+	var admin model.User
+	if err := DB.Where("username = ?", "admin").First(&admin).Error; err != nil {
+		t.Fatalf("failed to find admin: %v", err)
+	}
+
+	var earliest model.AttendancePeriod
+	date := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if err := DB.Order("start_date").First(&earliest).Error; err == nil {
+		date = earliest.StartDate
+	}
+	date = date.AddDate(0, 0, -1)
+	for date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		date = date.AddDate(0, 0, -1)
+	}
+
+	period := model.AttendancePeriod{
+		ID:        uuid.New(),
+		StartDate: date,
+		EndDate:   date,
+		CreatedBy: admin.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := DB.Create(&period).Error; err != nil {
+		t.Fatalf("failed to seed period: %v", err)
+	}
+	return period
+}
+
+// SeedPayroll creates a payroll in the given status with a payslip of the user on a period of SeedPeriod
+func SeedPayroll(t *testing.T, username, status string) uuid.UUID {
+	var user model.User
+	if err := DB.Where("username = ?", username).First(&user).Error; err != nil {
+		t.Fatalf("failed to find %s: %v", username, err)
+	}
+
+	period := SeedPeriod(t)
+	payroll := model.Payroll{
+		ID:        uuid.New(),
+		PeriodID:  period.ID,
+		Status:    status,
+		CreatedBy: period.CreatedBy,
+		CreatedAt: time.Now(),
+	}
+	payslip := model.Payslip{
+		ID:        uuid.New(),
+		PayrollID: payroll.ID,
+		UserID:    user.ID,
+	}
+	for _, record := range []interface{}{&payroll, &payslip} {
+		if err := DB.Create(record).Error; err != nil {
+			t.Fatalf("failed to seed payroll: %v", err)
+		}
+	}
+	return payroll.ID
+}